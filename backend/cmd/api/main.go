@@ -1,10 +1,12 @@
 package main
 
 import (
+	"context"
 	"leetcode-anki/backend/config"
 	"leetcode-anki/backend/internal/database"
 	"leetcode-anki/backend/internal/handlers"
 	"leetcode-anki/backend/internal/middleware"
+	"leetcode-anki/backend/internal/services"
 	"log/slog"
 	"os"
 	"time"
@@ -32,6 +34,22 @@ func main() {
 	logger.Info("✅ Database connected successfully")
 	defer database.Close()
 
+	// Keep the shipped curated lists in sync with the embedded list files
+	if lists, err := services.ImportBuiltinProblemLists(); err != nil {
+		logger.Warn("Failed to import built-in problem lists", "error", err)
+	} else {
+		logger.Info("📋 Built-in problem lists loaded", "count", len(lists))
+	}
+
+	// Keep the local LeetCode problem catalog fresh for ID/slug lookups and random picks
+	if config.AppConfig.ProblemSource == "leetcode" && config.AppConfig.CatalogSyncIntervalHours > 0 {
+		services.NewLeetCodeService().StartCatalogSync(
+			context.Background(),
+			time.Duration(config.AppConfig.CatalogSyncIntervalHours)*time.Hour,
+			time.Duration(config.AppConfig.CatalogFullSyncDays)*24*time.Hour,
+		)
+	}
+
 	// Initialize Gin router
 	router := gin.Default()
 
@@ -54,6 +72,14 @@ func main() {
 	transcribeHandler := handlers.NewTranscribeHandler()
 	questionsHandler := handlers.NewQuestionsHandler()
 	settingsHandler := handlers.NewSettingsHandler()
+	listsHandler := handlers.NewListsHandler()
+	importHandler := handlers.NewImportHandler()
+	customQuestionsHandler := handlers.NewCustomQuestionsHandler()
+	teamsHandler := handlers.NewTeamsHandler()
+	decksHandler := handlers.NewDecksHandler()
+	sessionsHandler := handlers.NewStudySessionsHandler()
+	vacationsHandler := handlers.NewVacationsHandler()
+	cardsHandler := handlers.NewCardsHandler()
 
 	// Public routes
 	router.GET("/health", healthHandler.HealthCheck)
@@ -69,14 +95,70 @@ func main() {
 		api.GET("/card/next", reviewHandler.GetNextCard)
 		api.POST("/review/submit", reviewHandler.SubmitAnswer)
 		api.POST("/review/skip", reviewHandler.SkipCard)
+		api.POST("/review/undo", reviewHandler.UndoLast)
+		api.POST("/review/:history_id/rate", reviewHandler.RateAnswer)
+		api.POST("/review/rebalance", reviewHandler.RebalanceBacklog)
 		api.GET("/review/solution/:questionId", reviewHandler.GetSolutionBreakdown)
+		api.GET("/review/solution/:questionId/implementation", reviewHandler.GetReferenceImplementation)
 
 		// Questions
 		api.GET("/questions/:id", questionsHandler.GetQuestionDetail)
+		api.GET("/questions/:id/revisions", questionsHandler.GetQuestionRevisions)
+		api.GET("/questions/:id/related", questionsHandler.GetRelatedQuestions)
+		api.GET("/questions/:id/prerequisites", questionsHandler.GetQuestionPrerequisites)
+		api.POST("/questions/:id/suspend", questionsHandler.SuspendCard)
+		api.POST("/questions/:id/unsuspend", questionsHandler.UnsuspendCard)
+
+		// User-authored questions, private or shared with a team
+		api.GET("/custom-questions", customQuestionsHandler.ListCustomQuestions)
+		api.POST("/custom-questions", customQuestionsHandler.CreateCustomQuestion)
+		api.PUT("/custom-questions/:id", customQuestionsHandler.UpdateCustomQuestion)
+		api.DELETE("/custom-questions/:id", customQuestionsHandler.DeleteCustomQuestion)
+
+		// Teams
+		api.GET("/teams", teamsHandler.GetTeams)
+		api.POST("/teams", teamsHandler.CreateTeam)
+		api.POST("/teams/:id/members", teamsHandler.AddMember)
+		api.DELETE("/teams/:id/members/:userId", teamsHandler.RemoveMember)
+
+		// Decks
+		api.GET("/decks", decksHandler.GetDecks)
+		api.POST("/decks", decksHandler.CreateDeck)
+		api.PUT("/decks/:id", decksHandler.UpdateDeck)
+		api.DELETE("/decks/:id", decksHandler.DeleteDeck)
+
+		// Filtered study sessions
+		api.GET("/sessions", sessionsHandler.GetSessions)
+		api.POST("/sessions", sessionsHandler.CreateSession)
+		api.GET("/sessions/:id", sessionsHandler.GetSession)
+		api.GET("/sessions/:id/next", sessionsHandler.GetNextCard)
+		api.POST("/sessions/:id/finish", sessionsHandler.FinishSession)
+
+		// Card browser operations
+		api.POST("/cards/reset", cardsHandler.ResetCards)
+		api.POST("/cards/forget", cardsHandler.ForgetCards)
+		api.POST("/cards/reposition", cardsHandler.RepositionCards)
+		api.POST("/cards/set-due", cardsHandler.SetDueDate)
+
+		// Vacations pause due dates
+		api.GET("/vacations", vacationsHandler.GetVacations)
+		api.POST("/vacations", vacationsHandler.CreateVacation)
+		api.POST("/vacations/:id/end", vacationsHandler.EndVacation)
+
+		// Curated problem lists
+		api.GET("/lists", listsHandler.GetLists)
+		api.GET("/lists/:slug", listsHandler.GetList)
+		api.POST("/lists/:slug/subscribe", listsHandler.Subscribe)
+		api.DELETE("/lists/:slug/subscribe", listsHandler.Unsubscribe)
+
+		// Import solved problems from a LeetCode export
+		api.POST("/import/solved", importHandler.ImportSolved)
 
 		// History
 		api.GET("/history", historyHandler.GetHistory)
 		api.GET("/history/:question_id", historyHandler.GetQuestionHistory)
+		api.GET("/history/:question_id/progress", historyHandler.GetQuestionProgress)
+		api.GET("/review-log", historyHandler.GetReviewLog)
 
 		// Voice transcription
 		api.POST("/transcribe", transcribeHandler.TranscribeAudio)
@@ -84,9 +166,21 @@ func main() {
 		// Admin endpoints
 		api.POST("/admin/refresh-problems", adminHandler.RefreshProblems)
 		api.GET("/admin/problem-stats", adminHandler.GetProblemStats)
+		api.POST("/admin/pregen-solutions", adminHandler.StartSolutionPregen)
+		api.GET("/admin/pregen-solutions", adminHandler.GetSolutionPregenStatus)
+		api.POST("/admin/catalog/sync", adminHandler.StartCatalogSync)
+		api.GET("/admin/catalog", adminHandler.GetCatalogStatus)
+		api.GET("/admin/refill-runs", adminHandler.GetRefillRuns)
+		api.GET("/admin/grader-calibration", adminHandler.GetGraderCalibration)
 
 		// Settings
 		api.POST("/settings/limit", settingsHandler.UpdateDailyLimit)
+		api.POST("/settings/language", settingsHandler.UpdatePreferredLanguage)
+		api.POST("/settings/transcription-language", settingsHandler.UpdateTranscriptionLanguage)
+		api.POST("/settings/bury-siblings", settingsHandler.UpdateBurySiblings)
+		api.POST("/settings/queue", settingsHandler.UpdateQueueSettings)
+		api.POST("/settings/new-quotas", settingsHandler.UpdateNewQuotas)
+		api.POST("/settings/prerequisites", settingsHandler.UpdateEnforcePrerequisites)
 	}
 
 	port := config.AppConfig.ServerPort
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"leetcode-anki/backend/config"
+	"leetcode-anki/backend/internal/database"
+	"leetcode-anki/backend/internal/models"
+	"leetcode-anki/backend/internal/services"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+)
+
+const usage = `Usage:
+  catalog sync [-full]        Sync the local problem catalog from LeetCode
+  catalog lookup <id|slug>    Show one catalog entry
+  catalog status              Print the catalog size and recent syncs`
+
+func main() {
+	// Initialize structured logger
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	slog.SetDefault(logger)
+
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	// Load configuration
+	if err := config.Load(); err != nil {
+		logger.Error("Failed to load config", "error", err)
+		os.Exit(1)
+	}
+
+	// Connect to database
+	if err := database.Connect(); err != nil {
+		logger.Error("Failed to connect to database", "error", err)
+		os.Exit(1)
+	}
+	defer database.Close()
+
+	var err error
+	switch os.Args[1] {
+	case "sync":
+		err = runSync(logger, os.Args[2:])
+	case "lookup":
+		err = runLookup(os.Args[2:])
+	case "status":
+		err = runStatus()
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		logger.Error("❌ Command failed", "command", os.Args[1], "error", err)
+		database.Close()
+		os.Exit(1)
+	}
+}
+
+// runSync runs one incremental or full sync
+func runSync(logger *slog.Logger, args []string) error {
+	flags := flag.NewFlagSet("sync", flag.ExitOnError)
+	full := flags.Bool("full", false, "walk the whole problem list instead of stopping at known problems")
+	_ = flags.Parse(args)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	run, err := services.NewLeetCodeService().SyncCatalog(ctx, *full)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("📚 Catalog synced",
+		"full", run.Full,
+		"fetched", run.Fetched,
+		"added", run.Added,
+		"updated", run.Updated,
+		"remote_total", run.RemoteTotal,
+	)
+	return nil
+}
+
+// runLookup prints the catalog entry for a frontend ID or slug
+func runLookup(args []string) error {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var entry *models.CatalogProblem
+	var err error
+	if id, convErr := strconv.Atoi(args[0]); convErr == nil {
+		entry, err = database.GetCatalogProblemByID(id)
+	} else {
+		entry, err = database.GetCatalogProblemBySlug(args[0])
+	}
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		return fmt.Errorf("%s is not in the catalog", args[0])
+	}
+
+	fmt.Printf("%d %s %q %s paid=%v %s %v\n",
+		entry.FrontendID, entry.Slug, entry.Title, entry.Difficulty, entry.PaidOnly, entry.Category, entry.Topics)
+	return nil
+}
+
+// runStatus prints the catalog size and the latest sync runs
+func runStatus() error {
+	count, err := database.GetCatalogCount()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d problems in catalog\n", count)
+
+	syncs, err := database.GetRecentCatalogSyncs(10)
+	if err != nil {
+		return err
+	}
+	for _, run := range syncs {
+		kind := "incremental"
+		if run.Full {
+			kind = "full"
+		}
+		status := "ok"
+		if run.Error != nil {
+			status = *run.Error
+		} else if run.FinishedAt == nil {
+			status = "running"
+		}
+		fmt.Printf("%s  %-11s fetched %4d  added %4d  updated %4d  %s\n",
+			run.StartedAt.Format("2006-01-02 15:04"), kind, run.Fetched, run.Added, run.Updated, status)
+	}
+	return nil
+}
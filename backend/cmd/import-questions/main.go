@@ -0,0 +1,96 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"leetcode-anki/backend/config"
+	"leetcode-anki/backend/internal/database"
+	"leetcode-anki/backend/internal/services"
+	"log/slog"
+	"os"
+)
+
+func main() {
+	// Initialize structured logger
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	slog.SetDefault(logger)
+
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: import-questions [-fixtures] <path>...")
+		fmt.Fprintln(os.Stderr, "Imports JSON/YAML question files into the questions table.")
+		flag.PrintDefaults()
+	}
+	leetcodeFormat := flag.Bool("fixtures", false, "paths are fixture directories in the LeetCode GraphQL shape instead of our question format")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Load configuration
+	if err := config.Load(); err != nil {
+		logger.Error("Failed to load config", "error", err)
+		os.Exit(1)
+	}
+
+	// Connect to database
+	if err := database.Connect(); err != nil {
+		logger.Error("Failed to connect to database", "error", err)
+		os.Exit(1)
+	}
+	defer database.Close()
+
+	var total services.ImportResult
+	for _, path := range flag.Args() {
+		result, err := importPath(path, *leetcodeFormat)
+		if err != nil {
+			logger.Error("Failed to load questions", "path", path, "error", err)
+			os.Exit(1)
+		}
+
+		logger.Info("📥 Imported questions",
+			"path", path,
+			"inserted", result.Inserted,
+			"updated", result.Updated,
+			"unchanged", result.Unchanged,
+			"failed", result.Failed,
+		)
+		for _, importErr := range result.Errors {
+			logger.Error("❌ Failed to import question", "error", importErr)
+		}
+
+		total.Inserted += result.Inserted
+		total.Updated += result.Updated
+		total.Unchanged += result.Unchanged
+		total.Failed += result.Failed
+	}
+
+	logger.Info("🎉 Import Complete!",
+		"inserted", total.Inserted,
+		"updated", total.Updated,
+		"unchanged", total.Unchanged,
+		"failed", total.Failed,
+	)
+
+	if total.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// importPath loads one file or directory in the requested format and upserts it
+func importPath(path string, leetcodeFormat bool) (services.ImportResult, error) {
+	if leetcodeFormat {
+		source, err := services.NewFixtureSource(path)
+		if err != nil {
+			return services.ImportResult{}, err
+		}
+		return services.ImportProblems(source.Problems()), nil
+	}
+
+	questions, err := services.LoadQuestionFiles(path)
+	if err != nil {
+		return services.ImportResult{}, err
+	}
+	return services.ImportQuestions(questions), nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"leetcode-anki/backend/config"
+	"leetcode-anki/backend/internal/database"
+	"leetcode-anki/backend/internal/services"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func main() {
+	// Initialize structured logger
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	slog.SetDefault(logger)
+
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: import-solved -user <id> [-dry-run] [-fetch] <export-file>")
+		fmt.Fprintln(os.Stderr, "Creates review cards for problems solved on LeetCode, from a submissions export (JSON/CSV) or saved profile response.")
+		flag.PrintDefaults()
+	}
+	userID := flag.String("user", "", "user ID to create cards for")
+	dryRun := flag.Bool("dry-run", false, "print the plan without creating cards")
+	fetch := flag.Bool("fetch", false, "fetch solved problems missing from the question pool via the configured problem source")
+	flag.Parse()
+
+	if *userID == "" || flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	path := flag.Arg(0)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		logger.Error("Failed to read export", "path", path, "error", err)
+		os.Exit(1)
+	}
+	solved, err := services.ParseSolvedExport(path, data)
+	if err != nil {
+		logger.Error("Failed to parse export", "error", err)
+		os.Exit(1)
+	}
+
+	// Load configuration
+	if err := config.Load(); err != nil {
+		logger.Error("Failed to load config", "error", err)
+		os.Exit(1)
+	}
+
+	// Connect to database
+	if err := database.Connect(); err != nil {
+		logger.Error("Failed to connect to database", "error", err)
+		os.Exit(1)
+	}
+	defer database.Close()
+
+	srs := services.NewSM2Algorithm()
+	plan, err := services.PlanSolvedImport(srs, *userID, solved)
+	if err != nil {
+		logger.Error("Failed to plan import", "error", err)
+		os.Exit(1)
+	}
+
+	if *fetch && plan.Unmatched > 0 {
+		problemSource, err := services.NewProblemSource()
+		if err != nil {
+			logger.Error("Failed to initialize problem source", "error", err)
+			os.Exit(1)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		result := services.FetchUnmatchedSolved(ctx, problemSource, plan)
+		stop()
+		logger.Info("📥 Fetched unmatched problems",
+			"source", problemSource.Name(),
+			"inserted", result.Inserted,
+			"failed", result.Failed,
+		)
+
+		// Plan again so the fetched problems get cards too
+		if plan, err = services.PlanSolvedImport(srs, *userID, solved); err != nil {
+			logger.Error("Failed to plan import", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	for _, item := range plan.Items {
+		fmt.Printf("%-9s %-60s solved %2dx  interval %3dd\n", item.Status, item.Slug, item.AcceptedCount, item.IntervalDays)
+	}
+
+	if !*dryRun {
+		if err := plan.Apply(); err != nil {
+			logger.Error("Failed to create cards", "error", err)
+			os.Exit(1)
+		}
+		if err := database.RefreshUserStats(*userID); err != nil {
+			logger.Warn("Failed to refresh user stats", "error", err)
+		}
+	}
+
+	logger.Info("🎉 Solved import complete",
+		"dry_run", plan.DryRun,
+		"solved", plan.Solved,
+		"create", plan.Create,
+		"created", plan.Created,
+		"existing", plan.Existing,
+		"unmatched", plan.Unmatched,
+	)
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"leetcode-anki/backend/config"
+	"leetcode-anki/backend/internal/database"
+	"leetcode-anki/backend/internal/services"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+const usage = `Usage:
+  lists import [-fetch] [file...]   Import the built-in lists, or custom list files (JSON/YAML)
+  lists show                        Print the built-in lists and their sizes`
+
+func main() {
+	// Initialize structured logger
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	slog.SetDefault(logger)
+
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "import":
+		runImport(logger, os.Args[2:])
+	case "show":
+		runShow(logger)
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+// runImport saves list files and optionally fetches their missing problems
+func runImport(logger *slog.Logger, args []string) {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	fetch := flags.Bool("fetch", false, "fetch problems missing from the question pool via the configured problem source")
+	_ = flags.Parse(args)
+
+	// Load configuration
+	if err := config.Load(); err != nil {
+		logger.Error("Failed to load config", "error", err)
+		os.Exit(1)
+	}
+
+	// Parse everything first so a bad file doesn't leave a half-imported set
+	source := "builtin"
+	var files []*services.ProblemListFile
+	if flags.NArg() == 0 {
+		builtin, err := services.BuiltinProblemLists()
+		if err != nil {
+			logger.Error("Failed to load built-in lists", "error", err)
+			os.Exit(1)
+		}
+		files = builtin
+	} else {
+		source = "custom"
+		for _, path := range flags.Args() {
+			file, err := services.LoadProblemListFile(path)
+			if err != nil {
+				logger.Error("Failed to load list file", "path", path, "error", err)
+				os.Exit(1)
+			}
+			files = append(files, file)
+		}
+	}
+
+	// Connect to database
+	if err := database.Connect(); err != nil {
+		logger.Error("Failed to connect to database", "error", err)
+		os.Exit(1)
+	}
+	defer database.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var problemSource services.ProblemSource
+	if *fetch {
+		var err error
+		problemSource, err = services.NewProblemSource()
+		if err != nil {
+			logger.Error("Failed to initialize problem source", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	failed := 0
+	for _, file := range files {
+		list, err := services.ImportProblemList(file, source)
+		if err != nil {
+			logger.Error("❌ Failed to import list", "slug", file.Slug, "error", err)
+			failed++
+			continue
+		}
+		logger.Info("📋 Imported list", "slug", list.Slug, "name", list.Name, "items", list.ItemCount)
+
+		if problemSource == nil {
+			continue
+		}
+
+		result, err := services.FetchMissingListProblems(ctx, problemSource, list.ID)
+		if err != nil {
+			logger.Error("❌ Failed to fetch missing problems", "slug", list.Slug, "error", err)
+			failed++
+			continue
+		}
+		for _, importErr := range result.Errors {
+			logger.Error("❌ Failed to import problem", "slug", list.Slug, "error", importErr)
+		}
+		logger.Info("📥 Fetched missing problems",
+			"slug", list.Slug,
+			"source", problemSource.Name(),
+			"inserted", result.Inserted,
+			"failed", result.Failed,
+		)
+		if result.Failed > 0 {
+			failed++
+		}
+	}
+
+	logger.Info("🎉 List import complete!", "lists", len(files), "failed", failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// runShow prints the embedded lists without touching the database
+func runShow(logger *slog.Logger) {
+	lists, err := services.BuiltinProblemLists()
+	if err != nil {
+		logger.Error("Failed to load built-in lists", "error", err)
+		os.Exit(1)
+	}
+
+	for _, list := range lists {
+		fmt.Printf("%-16s %-20s %3d problems, %2d sections\n", list.Slug, list.Name, len(list.ProblemIDs()), len(list.Sections))
+	}
+}
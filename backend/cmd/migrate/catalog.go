@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"leetcode-anki/backend/internal/database"
+	"log/slog"
+)
+
+// migrateProblemCatalog adds the local LeetCode problem index and its sync history
+func migrateProblemCatalog() error {
+	sql := `
+		CREATE TABLE IF NOT EXISTS problem_catalog (
+			frontend_id INTEGER PRIMARY KEY,
+			slug TEXT NOT NULL UNIQUE,
+			title TEXT NOT NULL,
+			difficulty TEXT NOT NULL,
+			paid_only BOOLEAN NOT NULL DEFAULT false,
+			category TEXT NOT NULL DEFAULT '',
+			topics TEXT[] NOT NULL DEFAULT '{}',
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+
+		-- Random picks only ever look at free problems
+		CREATE INDEX IF NOT EXISTS idx_problem_catalog_free ON problem_catalog(difficulty, category) WHERE NOT paid_only;
+
+		CREATE TABLE IF NOT EXISTS problem_catalog_syncs (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			full_sync BOOLEAN NOT NULL DEFAULT false,
+			fetched INTEGER NOT NULL DEFAULT 0,
+			added INTEGER NOT NULL DEFAULT 0,
+			updated INTEGER NOT NULL DEFAULT 0,
+			remote_total INTEGER NOT NULL DEFAULT 0,
+			error TEXT,
+			started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			finished_at TIMESTAMPTZ
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_problem_catalog_syncs_started ON problem_catalog_syncs(started_at DESC);
+	`
+
+	if _, err := database.DB.Exec(sql); err != nil {
+		return fmt.Errorf("failed to add problem catalog: %w", err)
+	}
+
+	slog.Info("✓ Added problem_catalog and problem_catalog_syncs tables")
+	return nil
+}
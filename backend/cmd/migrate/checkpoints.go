@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"leetcode-anki/backend/internal/database"
+	"log/slog"
+)
+
+// migrateFetchCheckpoints adds per-job progress so long batch fetches can resume
+func migrateFetchCheckpoints() error {
+	sql := `
+		CREATE TABLE IF NOT EXISTS fetch_checkpoints (
+			job TEXT PRIMARY KEY,
+			completed_ids INTEGER[] NOT NULL DEFAULT '{}',
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+	`
+
+	if _, err := database.DB.Exec(sql); err != nil {
+		return fmt.Errorf("failed to add fetch checkpoints: %w", err)
+	}
+
+	slog.Info("✓ Added fetch_checkpoints table")
+	return nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"leetcode-anki/backend/internal/database"
+	"log/slog"
+)
+
+// migrateCustomQuestions adds teams and question ownership so users can author their own questions
+func migrateCustomQuestions() error {
+	sql := `
+		CREATE TABLE IF NOT EXISTS teams (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			name TEXT NOT NULL,
+			created_by UUID NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+
+		CREATE TABLE IF NOT EXISTS team_members (
+			team_id UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+			user_id UUID NOT NULL,
+			role TEXT NOT NULL DEFAULT 'member' CHECK (role IN ('owner', 'member')),
+			joined_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			PRIMARY KEY (team_id, user_id)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_team_members_user ON team_members(user_id);
+
+		-- owner_id NULL is the shared LeetCode pool; custom questions belong to their author
+		-- and are visible to them only ('private') or to a team ('team')
+		ALTER TABLE questions ADD COLUMN IF NOT EXISTS owner_id UUID;
+		ALTER TABLE questions ADD COLUMN IF NOT EXISTS team_id UUID REFERENCES teams(id) ON DELETE SET NULL;
+		ALTER TABLE questions ADD COLUMN IF NOT EXISTS visibility TEXT NOT NULL DEFAULT 'public'
+			CHECK (visibility IN ('public', 'private', 'team'));
+
+		CREATE INDEX IF NOT EXISTS idx_questions_owner ON questions(owner_id) WHERE owner_id IS NOT NULL;
+		CREATE INDEX IF NOT EXISTS idx_questions_team ON questions(team_id) WHERE team_id IS NOT NULL;
+
+		-- Custom questions still need a leetcode_id; this range is far above any real LeetCode number
+		CREATE SEQUENCE IF NOT EXISTS custom_question_ids START WITH 9000001;
+	`
+
+	if _, err := database.DB.Exec(sql); err != nil {
+		return fmt.Errorf("failed to add custom questions: %w", err)
+	}
+
+	slog.Info("✓ Added teams, team_members and question ownership columns")
+	return nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"leetcode-anki/backend/internal/database"
+	"log/slog"
+)
+
+// migrateDecks adds decks: named subsets of the collection with their own daily limits
+func migrateDecks() error {
+	sql := `
+		CREATE TABLE IF NOT EXISTS decks (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			user_id UUID NOT NULL,
+			name TEXT NOT NULL,
+			kind TEXT NOT NULL CHECK (kind IN ('list', 'topics', 'difficulty', 'filter')),
+			list_id UUID REFERENCES problem_lists(id) ON DELETE CASCADE,
+			topics TEXT[] NOT NULL DEFAULT '{}',
+			difficulties TEXT[] NOT NULL DEFAULT '{}',
+			filter JSONB,
+			-- NULL limits fall back to the user's global settings
+			new_per_day INTEGER CHECK (new_per_day >= 0),
+			reviews_per_day INTEGER CHECK (reviews_per_day >= 0),
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			UNIQUE (user_id, name)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_decks_user ON decks(user_id);
+	`
+
+	if _, err := database.DB.Exec(sql); err != nil {
+		return fmt.Errorf("failed to create decks: %w", err)
+	}
+
+	slog.Info("✓ Added table: decks")
+	return nil
+}
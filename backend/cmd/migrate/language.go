@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+	"leetcode-anki/backend/internal/database"
+	"log/slog"
+)
+
+// migratePreferredLanguage stores which language reference implementations are generated in
+func migratePreferredLanguage() error {
+	sql := `
+		ALTER TABLE user_stats ADD COLUMN IF NOT EXISTS preferred_language TEXT NOT NULL DEFAULT 'python';
+	`
+
+	if _, err := database.DB.Exec(sql); err != nil {
+		return fmt.Errorf("failed to add preferred_language: %w", err)
+	}
+
+	slog.Info("✓ Added column: user_stats.preferred_language")
+	return nil
+}
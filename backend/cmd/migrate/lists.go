@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"leetcode-anki/backend/internal/database"
+	"log/slog"
+)
+
+// migrateProblemLists adds curated problem lists and per-user subscriptions
+func migrateProblemLists() error {
+	sql := `
+		CREATE TABLE IF NOT EXISTS problem_lists (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			slug TEXT NOT NULL UNIQUE,
+			name TEXT NOT NULL,
+			description TEXT NOT NULL DEFAULT '',
+			url TEXT NOT NULL DEFAULT '',
+			source TEXT NOT NULL DEFAULT 'builtin',
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+
+		-- Items point at LeetCode IDs, not question rows, so lists can be imported before their problems
+		CREATE TABLE IF NOT EXISTS list_items (
+			list_id UUID NOT NULL REFERENCES problem_lists(id) ON DELETE CASCADE,
+			position INTEGER NOT NULL,
+			section TEXT NOT NULL DEFAULT '',
+			leetcode_id INTEGER NOT NULL,
+			PRIMARY KEY (list_id, position),
+			UNIQUE (list_id, leetcode_id)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_list_items_leetcode ON list_items(leetcode_id);
+
+		CREATE TABLE IF NOT EXISTS user_list_subscriptions (
+			user_id UUID NOT NULL,
+			list_id UUID NOT NULL REFERENCES problem_lists(id) ON DELETE CASCADE,
+			subscribed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			PRIMARY KEY (user_id, list_id)
+		);
+	`
+
+	if _, err := database.DB.Exec(sql); err != nil {
+		return fmt.Errorf("failed to create problem lists: %w", err)
+	}
+
+	slog.Info("✓ Added tables: problem_lists, list_items, user_list_subscriptions")
+	return nil
+}
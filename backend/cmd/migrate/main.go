@@ -13,7 +13,7 @@ func main() {
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
 	slog.SetDefault(logger)
 
-	logger.Info("🔄 Starting database migration for SRS sub-day intervals...")
+	logger.Info("🔄 Starting database migrations...")
 
 	// Load configuration
 	if err := config.Load(); err != nil {
@@ -28,15 +28,47 @@ func main() {
 	}
 	defer database.Close()
 
-	// Run migration
-	if err := runMigration(); err != nil {
-		logger.Error("Migration failed", "error", err)
-		os.Exit(1)
+	// Run migrations in order. Every step is idempotent, so re-running is safe.
+	for _, m := range migrations {
+		logger.Info("▶️ Running migration", "name", m.name)
+		if err := m.run(); err != nil {
+			logger.Error("Migration failed", "name", m.name, "error", err)
+			os.Exit(1)
+		}
 	}
 
 	logger.Info("✅ Migration completed successfully!")
 }
 
+// migrations lists every schema step in the order it must be applied
+var migrations = []struct {
+	name string
+	run  func() error
+}{
+	{"srs sub-day intervals", runMigration},
+	{"preferred language", migratePreferredLanguage},
+	{"transcriptions", migrateTranscriptions},
+	{"oral grading", migrateOralGrading},
+	{"problem lists", migrateProblemLists},
+	{"question revisions", migrateQuestionRevisions},
+	{"question metadata", migrateQuestionMetadata},
+	{"question relations", migrateQuestionRelations},
+	{"fetch checkpoints", migrateFetchCheckpoints},
+	{"problem catalog", migrateProblemCatalog},
+	{"refill runs", migrateRefillRuns},
+	{"custom questions", migrateCustomQuestions},
+	{"decks", migrateDecks},
+	{"study sessions", migrateStudySessions},
+	{"queue settings", migrateQueueSettings},
+	{"prerequisites", migratePrerequisites},
+	{"undo", migrateUndo},
+	{"review log", migrateReviewLog},
+	{"self rating", migrateSelfRating},
+	{"vacations", migrateVacations},
+	{"new card positions", migrateNewPositions},
+	{"new card quotas", migrateNewQuotas},
+}
+
 func runMigration() error {
 	// Add new columns
 	addColumnsSQL := `
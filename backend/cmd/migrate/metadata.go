@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"leetcode-anki/backend/internal/database"
+	"log/slog"
+)
+
+// migrateQuestionMetadata stores hints, parsed examples/constraints, starter code,
+// similar questions and community stats imported from LeetCode
+func migrateQuestionMetadata() error {
+	sql := `
+		ALTER TABLE questions ADD COLUMN IF NOT EXISTS hints TEXT[] NOT NULL DEFAULT '{}';
+		ALTER TABLE questions ADD COLUMN IF NOT EXISTS examples JSONB;
+		ALTER TABLE questions ADD COLUMN IF NOT EXISTS constraints TEXT[] NOT NULL DEFAULT '{}';
+		ALTER TABLE questions ADD COLUMN IF NOT EXISTS example_testcases TEXT NOT NULL DEFAULT '';
+
+		-- NULL until imported from a source that provides them
+		ALTER TABLE questions ADD COLUMN IF NOT EXISTS likes INTEGER;
+		ALTER TABLE questions ADD COLUMN IF NOT EXISTS dislikes INTEGER;
+		ALTER TABLE questions ADD COLUMN IF NOT EXISTS acceptance_rate DOUBLE PRECISION;
+		ALTER TABLE questions ADD COLUMN IF NOT EXISTS total_accepted BIGINT;
+		ALTER TABLE questions ADD COLUMN IF NOT EXISTS total_submissions BIGINT;
+
+		CREATE TABLE IF NOT EXISTS question_code_snippets (
+			question_id UUID NOT NULL REFERENCES questions(id) ON DELETE CASCADE,
+			lang_slug TEXT NOT NULL,
+			lang TEXT NOT NULL,
+			code TEXT NOT NULL,
+			PRIMARY KEY (question_id, lang_slug)
+		);
+
+		-- Similar problems are kept by slug since they may not be in the pool yet
+		CREATE TABLE IF NOT EXISTS question_similar (
+			question_id UUID NOT NULL REFERENCES questions(id) ON DELETE CASCADE,
+			position INTEGER NOT NULL,
+			similar_slug TEXT NOT NULL,
+			title TEXT NOT NULL,
+			difficulty TEXT NOT NULL,
+			PRIMARY KEY (question_id, similar_slug)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_question_similar_slug ON question_similar(similar_slug);
+	`
+
+	if _, err := database.DB.Exec(sql); err != nil {
+		return fmt.Errorf("failed to add question metadata: %w", err)
+	}
+
+	slog.Info("✓ Added question metadata columns, question_code_snippets and question_similar tables")
+	return nil
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"fmt"
+	"leetcode-anki/backend/internal/database"
+	"log/slog"
+)
+
+// migrateNewPositions lets users reorder their queue of new cards
+func migrateNewPositions() error {
+	sql := `
+		-- Explicit place in the new-card queue; cards without one follow, oldest first
+		ALTER TABLE reviews ADD COLUMN IF NOT EXISTS new_position INTEGER;
+	`
+
+	if _, err := database.DB.Exec(sql); err != nil {
+		return fmt.Errorf("failed to add reviews.new_position: %w", err)
+	}
+
+	slog.Info("✓ Added reviews.new_position")
+	return nil
+}
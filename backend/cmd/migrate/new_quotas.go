@@ -0,0 +1,22 @@
+package main
+
+import (
+	"fmt"
+	"leetcode-anki/backend/internal/database"
+	"log/slog"
+)
+
+// migrateNewQuotas adds per-difficulty daily quotas for new cards
+func migrateNewQuotas() error {
+	sql := `
+		-- e.g. {"Easy": 3, "Medium": 2, "Hard": 1}; a difficulty left out is only bound by new_cards_limit
+		ALTER TABLE user_stats ADD COLUMN IF NOT EXISTS new_difficulty_quotas JSONB;
+	`
+
+	if _, err := database.DB.Exec(sql); err != nil {
+		return fmt.Errorf("failed to add new_difficulty_quotas: %w", err)
+	}
+
+	slog.Info("✓ Added user_stats.new_difficulty_quotas")
+	return nil
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+	"leetcode-anki/backend/internal/database"
+	"log/slog"
+)
+
+// migrateOralGrading stores speech timing metrics and communication grading for oral answers
+func migrateOralGrading() error {
+	sql := `
+		ALTER TABLE transcriptions ADD COLUMN IF NOT EXISTS speech_metrics JSONB;
+
+		ALTER TABLE history ADD COLUMN IF NOT EXISTS communication_scores JSONB;
+		ALTER TABLE history ADD COLUMN IF NOT EXISTS delivery_feedback TEXT NOT NULL DEFAULT '';
+	`
+
+	if _, err := database.DB.Exec(sql); err != nil {
+		return fmt.Errorf("failed to add oral grading columns: %w", err)
+	}
+
+	slog.Info("✓ Added transcriptions.speech_metrics, history.communication_scores, history.delivery_feedback")
+	return nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"leetcode-anki/backend/internal/database"
+	"log/slog"
+)
+
+// migratePrerequisites adds the prerequisite graph used to order new cards
+func migratePrerequisites() error {
+	sql := `
+		-- question_id shouldn't be introduced before prerequisite_id. A table rebuilt from list
+		-- sections and solution patterns by database.RebuildQuestionPrerequisites, which drops
+		-- edges that would close a cycle. Earlier versions kept the graph in a view.
+		DO $$
+		BEGIN
+			IF EXISTS (SELECT 1 FROM pg_views WHERE viewname = 'question_prerequisites') THEN
+				DROP VIEW question_prerequisites;
+			END IF;
+		END $$;
+
+		CREATE TABLE IF NOT EXISTS question_prerequisites (
+			question_id UUID NOT NULL REFERENCES questions(id) ON DELETE CASCADE,
+			prerequisite_id UUID NOT NULL REFERENCES questions(id) ON DELETE CASCADE,
+			source TEXT NOT NULL, -- 'list' or 'pattern'
+			PRIMARY KEY (question_id, prerequisite_id, source)
+		);
+
+		-- Off = introduce new cards regardless of prerequisites. Existing users keep today's behavior
+		-- and opt in; new users start with it on.
+		ALTER TABLE user_stats ADD COLUMN IF NOT EXISTS enforce_prerequisites BOOLEAN NOT NULL DEFAULT false;
+		ALTER TABLE user_stats ALTER COLUMN enforce_prerequisites SET DEFAULT true;
+	`
+
+	if _, err := database.DB.Exec(sql); err != nil {
+		return fmt.Errorf("failed to add prerequisites: %w", err)
+	}
+
+	if err := database.RebuildQuestionPrerequisites(); err != nil {
+		return fmt.Errorf("failed to build prerequisites: %w", err)
+	}
+
+	slog.Info("✓ Added question_prerequisites table, user_stats.enforce_prerequisites")
+	return nil
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"leetcode-anki/backend/internal/database"
+	"log/slog"
+)
+
+// migrateQueueSettings adds per-user queue ordering and the daily review cap
+func migrateQueueSettings() error {
+	sql := `
+		ALTER TABLE user_stats ADD COLUMN IF NOT EXISTS new_card_position TEXT NOT NULL DEFAULT 'before'
+			CHECK (new_card_position IN ('before', 'after', 'mixed'));
+		ALTER TABLE user_stats ADD COLUMN IF NOT EXISTS review_sort TEXT NOT NULL DEFAULT 'due'
+			CHECK (review_sort IN ('due', 'overdueness', 'retrievability', 'random', 'difficulty'));
+		ALTER TABLE user_stats ADD COLUMN IF NOT EXISTS new_gather_order TEXT NOT NULL DEFAULT 'list'
+			CHECK (new_gather_order IN ('list', 'random', 'difficulty'));
+
+		-- NULL = REVIEWS_PER_DAY from the server config
+		ALTER TABLE user_stats ADD COLUMN IF NOT EXISTS reviews_limit INTEGER CHECK (reviews_limit >= 0);
+	`
+
+	if _, err := database.DB.Exec(sql); err != nil {
+		return fmt.Errorf("failed to add queue settings: %w", err)
+	}
+
+	slog.Info("✓ Added user_stats.new_card_position, review_sort, new_gather_order, reviews_limit")
+	return nil
+}
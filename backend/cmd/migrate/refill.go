@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"leetcode-anki/backend/internal/database"
+	"log/slog"
+)
+
+// migrateRefillRuns adds the history of background question-pool refills
+func migrateRefillRuns() error {
+	sql := `
+		CREATE TABLE IF NOT EXISTS refill_runs (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			source TEXT NOT NULL,
+			users INTEGER NOT NULL DEFAULT 0,
+			requested JSONB NOT NULL DEFAULT '{}',
+			topics TEXT[] NOT NULL DEFAULT '{}',
+			fetched INTEGER NOT NULL DEFAULT 0,
+			inserted INTEGER NOT NULL DEFAULT 0,
+			updated INTEGER NOT NULL DEFAULT 0,
+			unchanged INTEGER NOT NULL DEFAULT 0,
+			skipped INTEGER NOT NULL DEFAULT 0,
+			failed INTEGER NOT NULL DEFAULT 0,
+			errors TEXT[] NOT NULL DEFAULT '{}',
+			started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			finished_at TIMESTAMPTZ
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_refill_runs_started ON refill_runs(started_at DESC);
+	`
+
+	if _, err := database.DB.Exec(sql); err != nil {
+		return fmt.Errorf("failed to add refill runs: %w", err)
+	}
+
+	slog.Info("✓ Added refill_runs table")
+	return nil
+}
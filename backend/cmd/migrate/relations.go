@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"leetcode-anki/backend/internal/database"
+	"log/slog"
+)
+
+// migrateQuestionRelations adds the related-question graph and sibling burying
+func migrateQuestionRelations() error {
+	sql := `
+		-- Edges in both directions: LeetCode's similarQuestions, plus questions whose
+		-- cached solution breakdown names the same pattern. A view, so it's never stale.
+		CREATE OR REPLACE VIEW question_relations AS
+			SELECT s.question_id, sq.id AS related_id, 'similar' AS kind
+			FROM question_similar s
+			JOIN questions sq ON sq.slug = s.similar_slug
+			WHERE sq.id <> s.question_id
+		UNION
+			SELECT sq.id AS question_id, s.question_id AS related_id, 'similar' AS kind
+			FROM question_similar s
+			JOIN questions sq ON sq.slug = s.similar_slug
+			WHERE sq.id <> s.question_id
+		UNION
+			SELECT q1.id AS question_id, q2.id AS related_id, 'pattern' AS kind
+			FROM questions q1
+			JOIN questions q2
+				ON lower(trim(q2.solution_breakdown->>'pattern')) = lower(trim(q1.solution_breakdown->>'pattern'))
+				AND q2.id <> q1.id
+			WHERE COALESCE(trim(q1.solution_breakdown->>'pattern'), '') <> '';
+
+		CREATE INDEX IF NOT EXISTS idx_questions_pattern
+			ON questions (lower(trim(solution_breakdown->>'pattern')));
+
+		-- Buried cards are skipped until this time (start of the next day)
+		ALTER TABLE reviews ADD COLUMN IF NOT EXISTS buried_until TIMESTAMPTZ;
+
+		ALTER TABLE user_stats ADD COLUMN IF NOT EXISTS bury_siblings BOOLEAN NOT NULL DEFAULT false;
+	`
+
+	if _, err := database.DB.Exec(sql); err != nil {
+		return fmt.Errorf("failed to add question relations: %w", err)
+	}
+
+	slog.Info("✓ Added question_relations view, reviews.buried_until, user_stats.bury_siblings")
+	return nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"leetcode-anki/backend/internal/database"
+	"log/slog"
+)
+
+// migrateReviewLog adds the append-only log of scheduling events, backfilled from graded history
+func migrateReviewLog() error {
+	sql := `
+		-- One row per change to a card's schedule: submit, skip, undo, reschedule, suspend,
+		-- unsuspend, import, ... Rows are never updated; an undo is a new row pointing at the one it undid.
+		CREATE TABLE IF NOT EXISTS review_log (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			user_id UUID NOT NULL,
+			question_id UUID NOT NULL,
+			event TEXT NOT NULL,
+			rating INTEGER,
+			state_before TEXT NOT NULL,
+			state_after TEXT NOT NULL,
+			interval_before INTEGER NOT NULL DEFAULT 0, -- minutes
+			interval_after INTEGER NOT NULL DEFAULT 0,
+			ease_before REAL,
+			ease_after REAL,
+			due_before TIMESTAMPTZ,
+			due_after TIMESTAMPTZ,
+			elapsed_days REAL, -- since the card's previous review; NULL on its first
+			history_id UUID,
+			undoes_id UUID REFERENCES review_log(id),
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+
+		-- Entries written in one transaction share created_at; seq orders them and is the export cursor
+		ALTER TABLE review_log ADD COLUMN IF NOT EXISTS seq BIGSERIAL;
+
+		CREATE INDEX IF NOT EXISTS idx_review_log_user ON review_log(user_id, created_at);
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_review_log_user_seq ON review_log(user_id, seq);
+		CREATE INDEX IF NOT EXISTS idx_review_log_question ON review_log(user_id, question_id, created_at);
+
+		ALTER TABLE undo_actions ADD COLUMN IF NOT EXISTS log_id UUID;
+
+		-- Backfill graded answers (first run only); ease wasn't kept in history
+		INSERT INTO review_log (user_id, question_id, event, rating, state_before, state_after,
+		                        interval_before, interval_after, due_before, due_after, elapsed_days,
+		                        history_id, created_at)
+		SELECT h.user_id, h.question_id, 'submit', h.score,
+		       COALESCE(LAG(h.card_state) OVER w, 'new'), h.card_state,
+		       COALESCE(LAG(h.interval_minutes) OVER w, 0), h.interval_minutes,
+		       LAG(h.next_review_at) OVER w, h.next_review_at,
+		       EXTRACT(EPOCH FROM h.submitted_at - LAG(h.submitted_at) OVER w) / 86400,
+		       h.id, h.submitted_at
+		FROM history h
+		WHERE NOT h.practice
+		AND NOT EXISTS (SELECT 1 FROM review_log)
+		WINDOW w AS (PARTITION BY h.user_id, h.question_id ORDER BY h.submitted_at)
+		ORDER BY h.submitted_at;
+	`
+
+	if _, err := database.DB.Exec(sql); err != nil {
+		return fmt.Errorf("failed to create review_log: %w", err)
+	}
+
+	slog.Info("✓ Added review_log table (backfilled from history), review_log.seq, undo_actions.log_id")
+	return nil
+}
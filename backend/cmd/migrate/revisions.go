@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"leetcode-anki/backend/internal/database"
+	"log/slog"
+)
+
+// migrateQuestionRevisions adds content hashing and a revision history for imported questions
+func migrateQuestionRevisions() error {
+	sql := `
+		-- NULL for rows imported before hashing; the next import fills it in
+		ALTER TABLE questions ADD COLUMN IF NOT EXISTS content_hash TEXT;
+
+		CREATE TABLE IF NOT EXISTS question_revisions (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			question_id UUID NOT NULL REFERENCES questions(id) ON DELETE CASCADE,
+			content_hash TEXT NOT NULL,
+			title TEXT NOT NULL,
+			difficulty TEXT NOT NULL,
+			description_markdown TEXT NOT NULL,
+			topics TEXT[] NOT NULL DEFAULT '{}',
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_question_revisions_question ON question_revisions(question_id, created_at);
+	`
+
+	if _, err := database.DB.Exec(sql); err != nil {
+		return fmt.Errorf("failed to add question revisions: %w", err)
+	}
+
+	slog.Info("✓ Added questions.content_hash and question_revisions table")
+	return nil
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"leetcode-anki/backend/internal/database"
+	"log/slog"
+)
+
+// migrateSelfRating lets users replace the grader's score with their own rating after an answer
+func migrateSelfRating() error {
+	sql := `
+		-- pre_review: the card as it was before the answer, so a rating can re-run the scheduler from it
+		-- user_rating: the score the user picked; score keeps the grader's, for calibration
+		ALTER TABLE history ADD COLUMN IF NOT EXISTS pre_review JSONB;
+		ALTER TABLE history ADD COLUMN IF NOT EXISTS user_rating INTEGER CHECK (user_rating BETWEEN 0 AND 5);
+		ALTER TABLE history ADD COLUMN IF NOT EXISTS rated_at TIMESTAMPTZ;
+
+		CREATE INDEX IF NOT EXISTS idx_history_rated ON history(rated_at) WHERE rated_at IS NOT NULL;
+	`
+
+	if _, err := database.DB.Exec(sql); err != nil {
+		return fmt.Errorf("failed to add self-rating columns: %w", err)
+	}
+
+	slog.Info("✓ Added history.pre_review, user_rating, rated_at")
+	return nil
+}
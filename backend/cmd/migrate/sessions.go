@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"leetcode-anki/backend/internal/database"
+	"log/slog"
+)
+
+// migrateStudySessions adds filtered study sessions and tags history rows answered in one
+func migrateStudySessions() error {
+	sql := `
+		CREATE TABLE IF NOT EXISTS study_sessions (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			user_id UUID NOT NULL,
+			name TEXT NOT NULL,
+			query JSONB NOT NULL,
+			-- false = practice only: answers are graded and logged but cards keep their schedule
+			reschedule BOOLEAN NOT NULL DEFAULT FALSE,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			finished_at TIMESTAMPTZ
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_study_sessions_user ON study_sessions(user_id, created_at DESC);
+
+		-- The cards are picked once, when the session is built
+		CREATE TABLE IF NOT EXISTS study_session_cards (
+			session_id UUID NOT NULL REFERENCES study_sessions(id) ON DELETE CASCADE,
+			question_id UUID NOT NULL,
+			position INTEGER NOT NULL,
+			answered_at TIMESTAMPTZ,
+			score INTEGER,
+			PRIMARY KEY (session_id, question_id)
+		);
+
+		ALTER TABLE history ADD COLUMN IF NOT EXISTS session_id UUID REFERENCES study_sessions(id) ON DELETE SET NULL;
+		ALTER TABLE history ADD COLUMN IF NOT EXISTS practice BOOLEAN NOT NULL DEFAULT FALSE;
+	`
+
+	if _, err := database.DB.Exec(sql); err != nil {
+		return fmt.Errorf("failed to create study sessions: %w", err)
+	}
+
+	slog.Info("✓ Added study_sessions and study_session_cards tables, history.session_id, history.practice")
+	return nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"leetcode-anki/backend/internal/database"
+	"log/slog"
+)
+
+// migrateTranscriptions stores voice transcriptions so raw and enhanced text can be reviewed with the attempt
+func migrateTranscriptions() error {
+	sql := `
+		CREATE TABLE IF NOT EXISTS transcriptions (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			user_id UUID NOT NULL,
+			raw_text TEXT NOT NULL,
+			enhanced_text TEXT NOT NULL,
+			language TEXT NOT NULL DEFAULT '',
+			backend TEXT NOT NULL,
+			duration_seconds DOUBLE PRECISION NOT NULL DEFAULT 0,
+			size_bytes BIGINT NOT NULL DEFAULT 0,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_transcriptions_user ON transcriptions(user_id, created_at);
+
+		ALTER TABLE history ADD COLUMN IF NOT EXISTS transcription_id UUID REFERENCES transcriptions(id) ON DELETE SET NULL;
+
+		-- Per-user speech recognition language ('auto' lets the backend detect it)
+		ALTER TABLE user_stats ADD COLUMN IF NOT EXISTS transcription_language TEXT NOT NULL DEFAULT 'en';
+	`
+
+	if _, err := database.DB.Exec(sql); err != nil {
+		return fmt.Errorf("failed to create transcriptions: %w", err)
+	}
+
+	slog.Info("✓ Added transcriptions table, history.transcription_id, user_stats.transcription_language")
+	return nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"leetcode-anki/backend/internal/database"
+	"log/slog"
+)
+
+// migrateUndo adds the per-user undo stack for answers and skips
+func migrateUndo() error {
+	sql := `
+		-- State from just before each submit/skip, so it can be put back
+		CREATE TABLE IF NOT EXISTS undo_actions (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			user_id UUID NOT NULL,
+			question_id UUID NOT NULL,
+			action TEXT NOT NULL CHECK (action IN ('submit', 'skip')),
+			review_snapshot JSONB NOT NULL,
+			coins INTEGER NOT NULL,
+			current_streak INTEGER NOT NULL,
+			max_streak INTEGER NOT NULL,
+			last_streak_date TIMESTAMPTZ,
+			history_id UUID,
+			session_id UUID,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_undo_actions_user ON undo_actions(user_id, created_at DESC);
+
+		-- Siblings the action buried, unburied again on undo
+		ALTER TABLE undo_actions ADD COLUMN IF NOT EXISTS buried_ids UUID[] NOT NULL DEFAULT '{}';
+
+		-- Schedule the action left the card in, so undo can tell when the card has moved on since
+		ALTER TABLE undo_actions ADD COLUMN IF NOT EXISTS after_last_reviewed_at TIMESTAMPTZ;
+		ALTER TABLE undo_actions ADD COLUMN IF NOT EXISTS after_next_review_at TIMESTAMPTZ;
+	`
+
+	if _, err := database.DB.Exec(sql); err != nil {
+		return fmt.Errorf("failed to create undo_actions: %w", err)
+	}
+
+	slog.Info("✓ Added undo_actions table, undo_actions.buried_ids, undo_actions.after_*")
+	return nil
+}
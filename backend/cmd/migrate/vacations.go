@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"leetcode-anki/backend/internal/database"
+	"log/slog"
+)
+
+// migrateVacations adds vacation ranges that pause due dates
+func migrateVacations() error {
+	sql := `
+		-- While a vacation is on, no cards are shown; once it's over, scheduled cards due from
+		-- its first day on are pushed back by its length (applied_at marks that as done)
+		CREATE TABLE IF NOT EXISTS vacations (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			user_id UUID NOT NULL,
+			starts_on DATE NOT NULL,
+			ends_on DATE NOT NULL,
+			applied_at TIMESTAMPTZ,
+			cards_shifted INTEGER NOT NULL DEFAULT 0,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			CHECK (ends_on >= starts_on)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_vacations_user ON vacations(user_id, starts_on);
+	`
+
+	if _, err := database.DB.Exec(sql); err != nil {
+		return fmt.Errorf("failed to create vacations: %w", err)
+	}
+
+	slog.Info("✓ Added vacations table")
+	return nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"leetcode-anki/backend/config"
+	"leetcode-anki/backend/internal/database"
+	"leetcode-anki/backend/internal/services"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func main() {
+	// Initialize structured logger
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	slog.SetDefault(logger)
+
+	// Load configuration
+	if err := config.Load(); err != nil {
+		logger.Error("Failed to load config", "error", err)
+		os.Exit(1)
+	}
+
+	concurrency := flag.Int("concurrency", config.AppConfig.PregenConcurrency, "number of parallel LLM requests")
+	rpm := flag.Int("rpm", config.AppConfig.PregenRequestsPerMinute, "maximum LLM requests per minute")
+	limit := flag.Int("limit", 0, "maximum number of questions to process (0 = all)")
+	flag.Parse()
+
+	logger.Info("💡 Starting solution breakdown pre-generation...",
+		"concurrency", *concurrency,
+		"rpm", *rpm,
+		"limit", *limit,
+	)
+
+	// Connect to database
+	if err := database.Connect(); err != nil {
+		logger.Error("Failed to connect to database", "error", err)
+		os.Exit(1)
+	}
+	defer database.Close()
+
+	remaining, err := database.CountQuestionsWithoutSolution()
+	if err != nil {
+		logger.Error("Failed to count questions", "error", err)
+		os.Exit(1)
+	}
+	logger.Info("📋 Questions missing a solution breakdown", "count", remaining)
+
+	// Ctrl+C stops cleanly; already cached breakdowns are kept and the next run resumes
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	pregenerator := services.NewSolutionPregenerator(services.NewLLMService(), *concurrency, *rpm)
+	progress, err := pregenerator.Run(ctx, *limit)
+
+	remaining, _ = database.CountQuestionsWithoutSolution()
+	logger.Info("🎉 Pre-generation finished",
+		"total", progress.Total,
+		"generated", progress.Generated,
+		"skipped", progress.Skipped,
+		"failed", progress.Failed,
+		"remaining", remaining,
+	)
+
+	if err != nil {
+		logger.Warn("Run interrupted. Re-run to resume.", "error", err)
+		os.Exit(1)
+	}
+	if progress.Failed > 0 {
+		logger.Warn("Some breakdowns failed to generate. Re-run to retry them.", "last_error", progress.LastError)
+		os.Exit(1)
+	}
+}
@@ -2,15 +2,15 @@
 package main
 
 import (
-	"fmt"
+	"context"
+	"flag"
 	"leetcode-anki/backend/config"
 	"leetcode-anki/backend/internal/database"
 	"leetcode-anki/backend/internal/services"
 	"log/slog"
 	"os"
-	"strconv"
-
-	"github.com/lib/pq"
+	"os/signal"
+	"syscall"
 )
 
 func main() {
@@ -26,6 +26,13 @@ func main() {
 		os.Exit(1)
 	}
 
+	source := flag.String("source", config.AppConfig.ProblemSource, `problem source: "leetcode" or "fixtures"`)
+	fixturesDir := flag.String("fixtures", config.AppConfig.ProblemFixturesDir, "fixtures directory (with -source fixtures)")
+	flag.Parse()
+
+	config.AppConfig.ProblemSource = *source
+	config.AppConfig.ProblemFixturesDir = *fixturesDir
+
 	// Connect to database
 	if err := database.Connect(); err != nil {
 		logger.Error("Failed to connect to database", "error", err)
@@ -33,12 +40,20 @@ func main() {
 	}
 	defer database.Close()
 
-	// Initialize LeetCode service
-	leetcodeService := services.NewLeetCodeService()
+	// Initialize problem source
+	problemSource, err := services.NewProblemSource()
+	if err != nil {
+		logger.Error("Failed to initialize problem source", "error", err)
+		os.Exit(1)
+	}
+
+	logger.Info("📥 Fetching 5 random problems...", "source", problemSource.Name())
 
-	logger.Info("📥 Fetching 5 random problems from LeetCode API...")
+	// Ctrl-C cancels in-flight requests instead of waiting out retries
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	problems, err := leetcodeService.FetchRandomProblems(2, 2, 1) // 2 Easy, 2 Medium, 1 Hard
+	problems, err := problemSource.FetchRandomProblems(ctx, 2, 2, 1) // 2 Easy, 2 Medium, 1 Hard
 	if err != nil {
 		logger.Error("Failed to fetch problems", "error", err)
 		os.Exit(1)
@@ -46,80 +61,24 @@ func main() {
 
 	logger.Info("✅ Fetched problems", "count", len(problems))
 
-	inserted := 0
-	skipped := 0
-
 	for i, problem := range problems {
-		status := ""
-		err := insertProblem(problem)
-		if err != nil {
-			if isDuplicateError(err) {
-				skipped++
-				status = "⚠️ Skipped (duplicate)"
-			} else {
-				status = fmt.Sprintf("❌ Failed: %v", err)
-			}
-		} else {
-			inserted++
-			status = "✅ Inserted"
-		}
-
 		logger.Info("Processing problem",
 			"index", i+1,
 			"total", len(problems),
 			"difficulty", problem.Difficulty,
 			"title", problem.Title,
-			"status", status,
 		)
 	}
 
-	logger.Info("🎉 Seeding Complete!",
-		"inserted", inserted,
-		"skipped", skipped,
-	)
-}
-
-func insertProblem(problem *services.LeetCodeProblem) error {
-	topics := make([]string, len(problem.TopicTags))
-	for i, tag := range problem.TopicTags {
-		topics[i] = tag.Name
-	}
-
-	leetcodeID, err := strconv.Atoi(problem.QuestionID)
-	if err != nil {
-		return fmt.Errorf("invalid question ID: %s", problem.QuestionID)
+	result := services.ImportProblems(problems)
+	for _, importErr := range result.Errors {
+		logger.Error("❌ Failed to import problem", "error", importErr)
 	}
 
-	descriptionMarkdown := services.StripHTMLTags(problem.Content)
-
-	query := `
-		INSERT INTO questions 
-		(leetcode_id, title, slug, difficulty, description_markdown, topics)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		ON CONFLICT (leetcode_id) 
-		DO UPDATE SET 
-			description_markdown = EXCLUDED.description_markdown,
-			topics = EXCLUDED.topics
-		RETURNING id
-	`
-
-	var id string
-	err = database.DB.QueryRow(
-		query,
-		leetcodeID,
-		problem.Title,
-		problem.TitleSlug,
-		problem.Difficulty,
-		descriptionMarkdown,
-		pq.Array(topics),
-	).Scan(&id)
-
-	return err
-}
-
-func isDuplicateError(err error) bool {
-	if pqErr, ok := err.(*pq.Error); ok {
-		return pqErr.Code == "23505"
-	}
-	return false
+	logger.Info("🎉 Seeding Complete!",
+		"inserted", result.Inserted,
+		"updated", result.Updated,
+		"unchanged", result.Unchanged,
+		"failed", result.Failed,
+	)
 }
@@ -1,90 +1,20 @@
 package main
 
 import (
-	"fmt"
+	"context"
+	"errors"
+	"flag"
 	"leetcode-anki/backend/config"
 	"leetcode-anki/backend/internal/database"
 	"leetcode-anki/backend/internal/services"
 	"log/slog"
 	"os"
-	"strconv"
-	"time"
-
-	"github.com/lib/pq"
+	"os/signal"
+	"syscall"
 )
 
-// LeetCode 150 problem IDs (Top Interview Questions)
-var leetcode150IDs = []int{
-	// Array / String
-	88, 27, 26, 80, 169, 189, 121, 122, 55, 45, 274, 380, 238, 134, 135, 42,
-	13, 12, 58, 14, 151, 6, 28, 68,
-
-	// Two Pointers
-	125, 392, 167, 11, 15,
-
-	// Sliding Window
-	209, 3, 30, 76,
-
-	// Matrix
-	36, 54, 48, 73, 289,
-
-	// Hashmap
-	383, 205, 290, 242, 49, 1, 202, 219, 128,
-
-	// Intervals
-	228, 56, 57, 452,
-
-	// Stack
-	20, 71, 155, 150, 224,
-
-	// Linked List
-	141, 2, 21, 138, 92, 25, 19, 82, 61, 86, 146,
-
-	// Binary Tree General
-	104, 100, 226, 101, 105, 106, 117, 114, 112, 129, 124, 173, 222, 236,
-
-	// Binary Tree BFS
-	199, 637, 102, 103,
-
-	// Binary Search Tree
-	530, 230, 98,
-
-	// Graph General
-	200, 130, 133, 399, 207, 210,
-
-	// Graph BFS
-	909, 433, 127,
-
-	// Trie
-	208, 211, 212,
-
-	// Backtracking
-	17, 77, 46, 39, 52, 22, 79,
-
-	// Divide & Conquer
-	108, 148, 427, 23,
-
-	// Kadane's Algorithm
-	53, 918,
-
-	// Binary Search
-	35, 74, 162, 33, 34, 153, 4,
-
-	// Heap
-	215, 502, 373, 295,
-
-	// Bit Manipulation
-	67, 190, 191, 136, 137, 201,
-
-	// Math
-	9, 66, 172, 69, 50, 149,
-
-	// 1D DP
-	70, 198, 139, 322, 300,
-
-	// Multidimensional DP
-	120, 64, 63, 5, 97, 72, 123, 188, 221,
-}
+// seedJob names the checkpoint that lets an interrupted seed resume
+const seedJob = "seed:leetcode-150"
 
 func main() {
 	// Initialize structured logger
@@ -93,6 +23,9 @@ func main() {
 
 	logger.Info("🌱 Starting LeetCode 150 Database Seeder...")
 
+	restart := flag.Bool("restart", false, "ignore the saved checkpoint and fetch every problem again")
+	flag.Parse()
+
 	// Load configuration
 	if err := config.Load(); err != nil {
 		logger.Error("Failed to load config", "error", err)
@@ -106,142 +39,81 @@ func main() {
 	}
 	defer database.Close()
 
-	// Initialize LeetCode service
-	leetcodeService := services.NewLeetCodeService()
-
-	logger.Info("📥 Building problem ID to slug mapping...")
+	// Top Interview 150 IDs come from the embedded list file (saving it keeps the sections too)
+	list, err := services.BuiltinProblemList("leetcode-150")
+	if err != nil {
+		logger.Error("Failed to load LeetCode 150 list", "error", err)
+		os.Exit(1)
+	}
+	if _, err := services.ImportProblemList(list, "builtin"); err != nil {
+		logger.Warn("Failed to save LeetCode 150 list", "error", err)
+	}
+	leetcode150IDs := list.ProblemIDs()
 
-	// Build ID -> slug mapping first (more efficient than individual lookups)
-	mapping, err := leetcodeService.FetchAllProblems()
+	// Initialize problem source (fixtures only cover the IDs they contain)
+	problemSource, err := services.NewProblemSource()
 	if err != nil {
-		logger.Error("Failed to build problem mapping", "error", err)
+		logger.Error("Failed to initialize problem source", "error", err)
 		os.Exit(1)
 	}
 
-	logger.Info("✅ Mapping built successfully", "total_problems", len(mapping))
-	logger.Info("📥 Fetching LeetCode 150 problems...", "total", len(leetcode150IDs))
-
-	inserted := 0
-	skipped := 0
-	failed := 0
-
-	for i, problemID := range leetcode150IDs {
-		// Get slug from mapping
-		slug, ok := mapping[problemID]
-		if !ok {
-			logger.Error("Problem ID not found in mapping",
-				"index", i+1,
-				"problem_id", problemID,
-			)
-			failed++
-			continue
+	if *restart {
+		if err := database.ClearFetchCheckpoint(seedJob); err != nil {
+			logger.Error("Failed to clear checkpoint", "job", seedJob, "error", err)
+			os.Exit(1)
 		}
+	}
 
-		// Fetch problem details using slug
-		problem, err := leetcodeService.FetchProblemDetail(slug)
-		if err != nil {
-			logger.Error("Failed to fetch problem",
-				"index", i+1,
-				"problem_id", problemID,
-				"slug", slug,
-				"error", err,
-			)
-			failed++
-			continue
-		}
+	// Ctrl-C stops cleanly; everything imported so far stays checkpointed for the next run
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	logger.Info("📥 Fetching LeetCode 150 problems...", "total", len(leetcode150IDs), "source", problemSource.Name())
 
-		err = insertLeetCode150Problem(problem)
+	// The LeetCode source resolves IDs through the local problem catalog, syncing it on first use
+	done := 0
+	fetcher := services.NewProblemBatchFetcher(problemSource)
+	result, err := fetcher.FetchAndImport(ctx, seedJob, leetcode150IDs, func(problemID int, r services.ImportResult) {
+		done++
 		status := ""
-		if err != nil {
-			if isDuplicateLeetCode150Error(err) {
-				skipped++
-				status = "⚠️ Skipped (duplicate)"
-			} else {
-				failed++
-				status = fmt.Sprintf("❌ Failed: %v", err)
-				logger.Error("Failed to insert problem",
-					"problem_id", problemID,
-					"title", problem.Title,
-					"error", err,
-				)
-			}
-		} else {
-			inserted++
+		switch {
+		case r.Failed > 0:
+			status = "❌ Failed"
+			logger.Error("Failed to seed problem", "problem_id", problemID, "error", r.Errors[0])
+		case r.Updated > 0:
+			status = "🔄 Updated"
+		case r.Unchanged > 0:
+			status = "⏭️ Unchanged"
+		default:
 			status = "✅ Inserted"
 		}
 
 		logger.Info("Processing problem",
-			"index", i+1,
-			"total", len(leetcode150IDs),
+			"done", done,
 			"problem_id", problemID,
-			"slug", slug,
-			"difficulty", problem.Difficulty,
-			"title", problem.Title,
 			"status", status,
 		)
-
-		// Rate limiting between problem fetches
-		if i < len(leetcode150IDs)-1 {
-			time.Sleep(500 * time.Millisecond)
-		}
+	})
+	if errors.Is(err, context.Canceled) {
+		logger.Warn("Seeding interrupted; run again to resume from the checkpoint", "job", seedJob)
+		os.Exit(1)
+	}
+	if err != nil {
+		logger.Error("Seeding failed", "error", err)
+		os.Exit(1)
 	}
 
 	logger.Info("🎉 LeetCode 150 Seeding Complete!",
-		"inserted", inserted,
-		"skipped", skipped,
-		"failed", failed,
+		"inserted", result.Inserted,
+		"updated", result.Updated,
+		"unchanged", result.Unchanged,
+		"failed", result.Failed,
+		"resumed", result.Resumed,
 		"total", len(leetcode150IDs),
 	)
 
-	if failed > 0 {
-		logger.Warn("Some problems failed to seed. Check logs above for details.")
+	if result.Failed > 0 {
+		logger.Warn("Some problems failed to seed. Run again to retry just those.")
 		os.Exit(1)
 	}
 }
-
-func insertLeetCode150Problem(problem *services.LeetCodeProblem) error {
-	topics := make([]string, len(problem.TopicTags))
-	for i, tag := range problem.TopicTags {
-		topics[i] = tag.Name
-	}
-
-	leetcodeID, err := strconv.Atoi(problem.QuestionID)
-	if err != nil {
-		return fmt.Errorf("invalid question ID: %s", problem.QuestionID)
-	}
-
-	descriptionMarkdown := services.StripHTMLTags(problem.Content)
-
-	// Note: We are not inserting 'correct_approach' as it is not fetched from the public LeetCode API
-	query := `
-        INSERT INTO questions 
-        (leetcode_id, title, slug, difficulty, description_markdown, topics)
-        VALUES ($1, $2, $3, $4, $5, $6)
-        ON CONFLICT (leetcode_id) 
-        DO UPDATE SET 
-            description_markdown = EXCLUDED.description_markdown,
-            topics = EXCLUDED.topics
-        RETURNING id
-    `
-
-	var id string
-	err = database.DB.QueryRow(
-		query,
-		leetcodeID,
-		problem.Title,
-		problem.TitleSlug,
-		problem.Difficulty,
-		descriptionMarkdown,
-		pq.Array(topics),
-	).Scan(&id)
-
-	return err
-}
-
-func isDuplicateLeetCode150Error(err error) bool {
-	if pqErr, ok := err.(*pq.Error); ok {
-		// PostgreSQL unique constraint violation error code
-		return pqErr.Code == "23505"
-	}
-	return false
-}
@@ -22,6 +22,44 @@ type Config struct {
 	NewCardsPerDay int
 	ReviewsPerDay  int
 	LearnAheadMins int
+
+	// Solution breakdown pre-generation
+	PregenConcurrency       int
+	PregenRequestsPerMinute int
+
+	// Container sandbox for validating reference implementations
+	SandboxEnabled     bool
+	SandboxTimeoutSecs int
+	SandboxRuntime     string // Docker-compatible CLI: "docker" or "podman"
+	SandboxMemoryMB    int
+
+	// Speech-to-text backend for /api/transcribe
+	TranscriberBackend string // "openai", "local-server", "whisper-cpp"
+	TranscriberURL     string // Base URL of an OpenAI-compatible server (local-server)
+	TranscriberModel   string
+	WhisperCppBinary   string
+	WhisperCppModel    string
+	MaxAudioBytes      int64
+	MaxAudioSeconds    int
+
+	// Where seeding and refills get problems from
+	ProblemSource      string // "leetcode" or "fixtures"
+	ProblemFixturesDir string
+
+	// LeetCode GraphQL client throttling
+	LeetCodeRequestsPerMinute int
+	LeetCodeBurst             int
+	LeetCodeConcurrency       int
+	LeetCodeMaxRetries        int
+
+	// Local problem catalog refresh (only with the leetcode source)
+	CatalogSyncIntervalHours int
+	CatalogFullSyncDays      int
+
+	// Background question-pool refill
+	RefillThreshold          int // Refill once a user has this many unused questions or fewer
+	RefillBatchSize          int // Most problems fetched per run
+	RefillMinIntervalMinutes int // Runs are at least this far apart; requests in between are coalesced
 }
 
 var AppConfig *Config
@@ -44,6 +82,43 @@ func Load() error {
 		NewCardsPerDay: getEnvInt("NEW_CARDS_PER_DAY", 5),
 		ReviewsPerDay:  getEnvInt("REVIEWS_PER_DAY", 200),
 		LearnAheadMins: getEnvInt("LEARN_AHEAD_MINS", 20),
+
+		// Pre-generation throttling (keeps batch runs under OpenAI rate limits)
+		PregenConcurrency:       getEnvInt("PREGEN_CONCURRENCY", 3),
+		PregenRequestsPerMinute: getEnvInt("PREGEN_REQUESTS_PER_MINUTE", 30),
+
+		// Sandbox is opt-in since it needs a container runtime on the host
+		SandboxEnabled:     getEnvBool("SANDBOX_ENABLED", false),
+		SandboxTimeoutSecs: getEnvInt("SANDBOX_TIMEOUT_SECS", 20),
+		SandboxRuntime:     getEnv("SANDBOX_RUNTIME", "docker"),
+		SandboxMemoryMB:    getEnvInt("SANDBOX_MEMORY_MB", 512),
+
+		// Speech-to-text (defaults to OpenAI Whisper)
+		TranscriberBackend: getEnv("TRANSCRIBER_BACKEND", "openai"),
+		TranscriberURL:     getEnv("TRANSCRIBER_URL", ""),
+		TranscriberModel:   getEnv("TRANSCRIBER_MODEL", "whisper-1"),
+		WhisperCppBinary:   getEnv("WHISPER_CPP_BIN", "whisper-cli"),
+		WhisperCppModel:    getEnv("WHISPER_CPP_MODEL", ""),
+		MaxAudioBytes:      int64(getEnvInt("MAX_AUDIO_MB", 25)) * 1024 * 1024, // Whisper API upload limit
+		MaxAudioSeconds:    getEnvInt("MAX_AUDIO_SECONDS", 600),
+
+		// Fixtures allow offline development without hitting leetcode.com
+		ProblemSource:      getEnv("PROBLEM_SOURCE", "leetcode"),
+		ProblemFixturesDir: getEnv("PROBLEM_FIXTURES_DIR", "fixtures/problems"),
+
+		// Shared by every LeetCode request in the process; 429/5xx are retried with backoff
+		LeetCodeRequestsPerMinute: getEnvInt("LEETCODE_REQUESTS_PER_MINUTE", 60),
+		LeetCodeBurst:             getEnvInt("LEETCODE_BURST", 3),
+		LeetCodeConcurrency:       getEnvInt("LEETCODE_CONCURRENCY", 3),
+		LeetCodeMaxRetries:        getEnvInt("LEETCODE_MAX_RETRIES", 4),
+
+		// 0 disables the background catalog sync; lookups still sync on demand
+		CatalogSyncIntervalHours: getEnvInt("CATALOG_SYNC_INTERVAL_HOURS", 6),
+		CatalogFullSyncDays:      getEnvInt("CATALOG_FULL_SYNC_DAYS", 7),
+
+		RefillThreshold:          getEnvInt("REFILL_THRESHOLD", 20),
+		RefillBatchSize:          getEnvInt("REFILL_BATCH_SIZE", 20),
+		RefillMinIntervalMinutes: getEnvInt("REFILL_MIN_INTERVAL_MINUTES", 5),
 	}
 
 	// Validate required fields
@@ -78,3 +153,12 @@ func getEnvInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
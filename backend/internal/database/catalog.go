@@ -0,0 +1,185 @@
+package database
+
+import (
+	"database/sql"
+	"leetcode-anki/backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+const catalogColumns = `frontend_id, slug, title, difficulty, paid_only, category, topics, updated_at`
+
+// UpsertCatalogProblems saves a page of catalog entries
+// Returns how many were new and how many had changed; identical entries are left alone
+func UpsertCatalogProblems(problems []models.CatalogProblem) (added, updated int, err error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	for _, p := range problems {
+		// A slug that moved to another ID (renumbering is rare but happens) would trip the unique index
+		if _, err := tx.Exec(`DELETE FROM problem_catalog WHERE slug = $1 AND frontend_id <> $2`, p.Slug, p.FrontendID); err != nil {
+			return 0, 0, err
+		}
+
+		var inserted bool
+		err := tx.QueryRow(`
+			INSERT INTO problem_catalog (frontend_id, slug, title, difficulty, paid_only, category, topics)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			ON CONFLICT (frontend_id) DO UPDATE SET
+				slug = EXCLUDED.slug,
+				title = EXCLUDED.title,
+				difficulty = EXCLUDED.difficulty,
+				paid_only = EXCLUDED.paid_only,
+				category = EXCLUDED.category,
+				topics = EXCLUDED.topics,
+				updated_at = NOW()
+			WHERE (problem_catalog.slug, problem_catalog.title, problem_catalog.difficulty,
+			       problem_catalog.paid_only, problem_catalog.category, problem_catalog.topics)
+			      IS DISTINCT FROM
+			      (EXCLUDED.slug, EXCLUDED.title, EXCLUDED.difficulty,
+			       EXCLUDED.paid_only, EXCLUDED.category, EXCLUDED.topics)
+			RETURNING (xmax = 0)
+		`, p.FrontendID, p.Slug, p.Title, p.Difficulty, p.PaidOnly, p.Category, pq.Array(p.Topics)).Scan(&inserted)
+
+		switch {
+		case err == sql.ErrNoRows:
+			// Unchanged
+		case err != nil:
+			return 0, 0, err
+		case inserted:
+			added++
+		default:
+			updated++
+		}
+	}
+
+	return added, updated, tx.Commit()
+}
+
+// GetCatalogProblemByID looks up a problem by its frontend ID, or nil if the catalog doesn't have it
+func GetCatalogProblemByID(frontendID int) (*models.CatalogProblem, error) {
+	return scanCatalogProblem(DB.QueryRow(`SELECT `+catalogColumns+` FROM problem_catalog WHERE frontend_id = $1`, frontendID))
+}
+
+// GetCatalogProblemBySlug looks up a problem by slug, or nil if the catalog doesn't have it
+func GetCatalogProblemBySlug(slug string) (*models.CatalogProblem, error) {
+	return scanCatalogProblem(DB.QueryRow(`SELECT `+catalogColumns+` FROM problem_catalog WHERE slug = $1`, slug))
+}
+
+// GetRandomCatalogSlugs picks free algorithm problems of a difficulty that aren't in the question pool yet
+// Problems tagged with any of preferTopics come first; the rest fill up the remainder at random
+func GetRandomCatalogSlugs(difficulty string, limit int, preferTopics []string) ([]string, error) {
+	rows, err := DB.Query(`
+		SELECT pc.slug
+		FROM problem_catalog pc
+		WHERE pc.difficulty = $1
+		  AND NOT pc.paid_only
+		  AND pc.category = 'Algorithms'
+		  AND NOT EXISTS (SELECT 1 FROM questions q WHERE q.slug = pc.slug)
+		ORDER BY COALESCE(pc.topics && $3::text[], false) DESC, random()
+		LIMIT $2
+	`, difficulty, limit, pq.Array(preferTopics))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var slugs []string
+	for rows.Next() {
+		var slug string
+		if err := rows.Scan(&slug); err != nil {
+			return nil, err
+		}
+		slugs = append(slugs, slug)
+	}
+	return slugs, rows.Err()
+}
+
+// GetCatalogCount returns how many problems the catalog holds
+func GetCatalogCount() (int, error) {
+	var count int
+	err := DB.QueryRow(`SELECT COUNT(*) FROM problem_catalog`).Scan(&count)
+	return count, err
+}
+
+// StartCatalogSync records the start of a sync run and returns its ID
+func StartCatalogSync(full bool) (string, error) {
+	var id string
+	err := DB.QueryRow(`INSERT INTO problem_catalog_syncs (full_sync) VALUES ($1) RETURNING id`, full).Scan(&id)
+	return id, err
+}
+
+// FinishCatalogSync stores a sync run's counts and error (nil when it succeeded)
+func FinishCatalogSync(run models.CatalogSyncRun) error {
+	_, err := DB.Exec(`
+		UPDATE problem_catalog_syncs
+		SET fetched = $2, added = $3, updated = $4, remote_total = $5, error = $6, finished_at = NOW()
+		WHERE id = $1
+	`, run.ID, run.Fetched, run.Added, run.Updated, run.RemoteTotal, run.Error)
+	return err
+}
+
+// GetLastCatalogSync returns the most recent successful sync (full ones only if full is set), or nil
+func GetLastCatalogSync(full bool) (*models.CatalogSyncRun, error) {
+	rows, err := queryCatalogSyncs(`
+		WHERE finished_at IS NOT NULL AND error IS NULL AND (full_sync OR NOT $1)
+		ORDER BY started_at DESC
+		LIMIT 1
+	`, full)
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+	return &rows[0], nil
+}
+
+// GetRecentCatalogSyncs returns the latest sync runs, newest first
+func GetRecentCatalogSyncs(limit int) ([]models.CatalogSyncRun, error) {
+	return queryCatalogSyncs(`ORDER BY started_at DESC LIMIT $1`, limit)
+}
+
+func queryCatalogSyncs(where string, args ...interface{}) ([]models.CatalogSyncRun, error) {
+	rows, err := DB.Query(`
+		SELECT id, full_sync, fetched, added, updated, remote_total, error, started_at, finished_at
+		FROM problem_catalog_syncs
+	`+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := []models.CatalogSyncRun{}
+	for rows.Next() {
+		var run models.CatalogSyncRun
+		var errMsg sql.NullString
+		var finishedAt sql.NullTime
+		if err := rows.Scan(&run.ID, &run.Full, &run.Fetched, &run.Added, &run.Updated, &run.RemoteTotal,
+			&errMsg, &run.StartedAt, &finishedAt); err != nil {
+			return nil, err
+		}
+		if errMsg.Valid {
+			run.Error = &errMsg.String
+		}
+		if finishedAt.Valid {
+			run.FinishedAt = &finishedAt.Time
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+func scanCatalogProblem(row rowScanner) (*models.CatalogProblem, error) {
+	var p models.CatalogProblem
+	var topics pq.StringArray
+	err := row.Scan(&p.FrontendID, &p.Slug, &p.Title, &p.Difficulty, &p.PaidOnly, &p.Category, &topics, &p.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.Topics = topics
+	return &p, nil
+}
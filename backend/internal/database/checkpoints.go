@@ -0,0 +1,43 @@
+package database
+
+import (
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+// GetFetchCheckpoint returns the IDs a batch job has already completed (empty if it never ran)
+func GetFetchCheckpoint(job string) (map[int]bool, error) {
+	var ids pq.Int64Array
+	err := DB.QueryRow(`SELECT completed_ids FROM fetch_checkpoints WHERE job = $1`, job).Scan(&ids)
+	if err == sql.ErrNoRows {
+		return map[int]bool{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	completed := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		completed[int(id)] = true
+	}
+	return completed, nil
+}
+
+// AddFetchCheckpoint records one more completed ID for a batch job
+func AddFetchCheckpoint(job string, id int) error {
+	_, err := DB.Exec(`
+		INSERT INTO fetch_checkpoints (job, completed_ids, updated_at)
+		VALUES ($1, ARRAY[$2::integer], NOW())
+		ON CONFLICT (job) DO UPDATE SET
+			completed_ids = array_append(fetch_checkpoints.completed_ids, $2::integer),
+			updated_at = NOW()
+	`, job, id)
+	return err
+}
+
+// ClearFetchCheckpoint forgets a job's progress so the next run starts over
+func ClearFetchCheckpoint(job string) error {
+	_, err := DB.Exec(`DELETE FROM fetch_checkpoints WHERE job = $1`, job)
+	return err
+}
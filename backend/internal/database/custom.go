@@ -0,0 +1,274 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"leetcode-anki/backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// questionVisibleTo is a WHERE fragment limiting alias to questions the user may see ($param = user_id)
+// LeetCode questions are visible to everyone; custom ones to their owner, and to the team if shared
+func questionVisibleTo(alias string, param int) string {
+	return fmt.Sprintf(`(%[1]s.owner_id IS NULL OR %[1]s.owner_id = $%[2]d OR
+		(%[1]s.visibility = 'team' AND %[1]s.team_id IN (SELECT team_id FROM team_members WHERE user_id = $%[2]d)))`,
+		alias, param)
+}
+
+// CanAccessQuestion reports whether the question exists and the user may see it
+func CanAccessQuestion(userID, questionID string) (bool, error) {
+	var ok bool
+	err := DB.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM questions q WHERE q.id = $2 AND `+questionVisibleTo("q", 1)+`)
+	`, userID, questionID).Scan(&ok)
+	return ok, err
+}
+
+// CreateCustomQuestion inserts a user-authored question
+// q.Slug is a base slug; the allocated leetcode_id is appended so custom slugs never collide
+func CreateCustomQuestion(q *models.Question) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := tx.QueryRow(`SELECT nextval('custom_question_ids')`).Scan(&q.LeetcodeID); err != nil {
+		return err
+	}
+	q.Slug = fmt.Sprintf("%s-%d", q.Slug, q.LeetcodeID)
+
+	solutionJSON, err := jsonMarshal(q.SolutionBreakdown)
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO questions (leetcode_id, title, slug, difficulty, description_markdown, topics,
+		                       solution_breakdown, content_hash, owner_id, team_id, visibility)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, created_at
+	`, q.LeetcodeID, q.Title, q.Slug, q.Difficulty, q.DescriptionMarkdown, pq.Array(q.Topics),
+		nullableJSON(solutionJSON), QuestionContentHash(q), q.OwnerID, q.TeamID, q.Visibility,
+	).Scan(&q.ID, &q.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpdateCustomQuestion saves the owner's edits; returns false if the user doesn't own such a question
+// A nil SolutionBreakdown keeps the stored one (which may have been generated since)
+func UpdateCustomQuestion(q *models.Question) (bool, error) {
+	solutionJSON, err := jsonMarshal(q.SolutionBreakdown)
+	if err != nil {
+		return false, err
+	}
+
+	res, err := DB.Exec(`
+		UPDATE questions
+		SET title = $3, difficulty = $4, description_markdown = $5, topics = $6,
+		    solution_breakdown = COALESCE($7, solution_breakdown),
+		    team_id = $8, visibility = $9
+		WHERE id = $1 AND owner_id = $2
+	`, q.ID, q.OwnerID, q.Title, q.Difficulty, q.DescriptionMarkdown, pq.Array(q.Topics),
+		nullableJSON(solutionJSON), q.TeamID, q.Visibility)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// DeleteCustomQuestion removes a question the user owns, along with everyone's cards and attempts on it
+// Tables that reference questions without a foreign key are cleaned up here, so no undo, study
+// session or log entry is left pointing at a card that no longer exists
+func DeleteCustomQuestion(userID, questionID string) (bool, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var owned bool
+	err = tx.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM questions WHERE id = $1 AND owner_id = $2)
+	`, questionID, userID).Scan(&owned)
+	if err != nil || !owned {
+		return false, err
+	}
+
+	for _, query := range []string{
+		`DELETE FROM undo_actions WHERE question_id = $1`,
+		`DELETE FROM study_session_cards WHERE question_id = $1`,
+		`DELETE FROM review_log WHERE question_id = $1`,
+		`DELETE FROM history WHERE question_id = $1`,
+		`DELETE FROM reviews WHERE question_id = $1`,
+		`DELETE FROM questions WHERE id = $1`,
+	} {
+		if _, err := tx.Exec(query, questionID); err != nil {
+			return false, err
+		}
+	}
+
+	return true, tx.Commit()
+}
+
+// GetCustomQuestions lists the custom questions a user can see: their own and their teams'
+func GetCustomQuestions(userID string) ([]models.Question, error) {
+	rows, err := DB.Query(`
+		SELECT q.id, q.leetcode_id, q.title, q.slug, q.difficulty, q.description_markdown, q.topics,
+		       q.created_at, q.owner_id, q.team_id, q.visibility
+		FROM questions q
+		WHERE q.owner_id IS NOT NULL AND `+questionVisibleTo("q", 1)+`
+		ORDER BY q.created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	questions := []models.Question{}
+	for rows.Next() {
+		var q models.Question
+		var topics pq.StringArray
+		var ownerID, teamID sql.NullString
+		if err := rows.Scan(&q.ID, &q.LeetcodeID, &q.Title, &q.Slug, &q.Difficulty, &q.DescriptionMarkdown,
+			&topics, &q.CreatedAt, &ownerID, &teamID, &q.Visibility); err != nil {
+			return nil, err
+		}
+		q.Topics = topics
+		q.OwnerID = nullStringPtr(ownerID)
+		q.TeamID = nullStringPtr(teamID)
+		questions = append(questions, q)
+	}
+	return questions, rows.Err()
+}
+
+// nullableJSON maps a marshalled nil ("null") to SQL NULL
+func nullableJSON(data []byte) interface{} {
+	if string(data) == "null" {
+		return nil
+	}
+	return data
+}
+
+// ============================================
+// TEAMS
+// ============================================
+
+// CreateTeam creates a team with its creator as owner
+func CreateTeam(team *models.Team) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO teams (name, created_by) VALUES ($1, $2) RETURNING id, created_at
+	`, team.Name, team.CreatedBy).Scan(&team.ID, &team.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`
+		INSERT INTO team_members (team_id, user_id, role) VALUES ($1, $2, 'owner')
+	`, team.ID, team.CreatedBy); err != nil {
+		return err
+	}
+
+	team.Role = "owner"
+	team.MemberCount = 1
+	return tx.Commit()
+}
+
+// GetUserTeams lists the teams a user belongs to
+func GetUserTeams(userID string) ([]models.Team, error) {
+	rows, err := DB.Query(`
+		SELECT t.id, t.name, t.created_by, tm.role,
+		       (SELECT COUNT(*) FROM team_members m WHERE m.team_id = t.id),
+		       t.created_at
+		FROM teams t
+		JOIN team_members tm ON tm.team_id = t.id AND tm.user_id = $1
+		ORDER BY t.name
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	teams := []models.Team{}
+	for rows.Next() {
+		var t models.Team
+		if err := rows.Scan(&t.ID, &t.Name, &t.CreatedBy, &t.Role, &t.MemberCount, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		teams = append(teams, t)
+	}
+	return teams, rows.Err()
+}
+
+// GetTeamRole returns the user's role in the team, or "" if they aren't a member
+func GetTeamRole(teamID, userID string) (string, error) {
+	var role string
+	err := DB.QueryRow(`
+		SELECT role FROM team_members WHERE team_id = $1 AND user_id = $2
+	`, teamID, userID).Scan(&role)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return role, err
+}
+
+// AddTeamMember adds a user to a team (no-op if they're already in it)
+func AddTeamMember(teamID, userID string) error {
+	_, err := DB.Exec(`
+		INSERT INTO team_members (team_id, user_id) VALUES ($1, $2)
+		ON CONFLICT (team_id, user_id) DO NOTHING
+	`, teamID, userID)
+	return err
+}
+
+// ErrLastTeamOwner is returned when removing a member would leave the team without an owner
+var ErrLastTeamOwner = errors.New("a team needs at least one owner")
+
+// RemoveTeamMember removes a user from a team
+// The last owner can't be removed, since nobody could manage the team afterwards
+func RemoveTeamMember(teamID, userID string) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Concurrent removals of two owners take turns, so they can't both pass the check
+	if _, err := tx.Exec(`SELECT id FROM teams WHERE id = $1 FOR UPDATE`, teamID); err != nil {
+		return err
+	}
+
+	var lastOwner bool
+	err = tx.QueryRow(`
+		SELECT role = 'owner' AND (SELECT COUNT(*) FROM team_members WHERE team_id = $1 AND role = 'owner') = 1
+		FROM team_members
+		WHERE team_id = $1 AND user_id = $2
+	`, teamID, userID).Scan(&lastOwner)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if lastOwner {
+		return ErrLastTeamOwner
+	}
+
+	if _, err := tx.Exec(`DELETE FROM team_members WHERE team_id = $1 AND user_id = $2`, teamID, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,206 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"leetcode-anki/backend/internal/models"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// deckCondition returns an " AND ..." fragment limiting alias (a questions row) to the deck
+// Placeholders continue after args, which is returned with the deck's values appended.
+// A nil deck is the whole collection: no fragment, args unchanged.
+func deckCondition(deck *models.Deck, alias string, args []interface{}) (string, []interface{}) {
+	if deck == nil {
+		return "", args
+	}
+
+	var conds []string
+	next := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if deck.ListID != nil {
+		conds = append(conds, fmt.Sprintf(
+			`EXISTS (SELECT 1 FROM list_items li WHERE li.list_id = %s AND li.leetcode_id = %s.leetcode_id)`,
+			next(*deck.ListID), alias))
+	}
+	if len(deck.Topics) > 0 {
+		conds = append(conds, fmt.Sprintf(`%s.topics && %s::text[]`, alias, next(pq.Array(deck.Topics))))
+	}
+	if len(deck.Difficulties) > 0 {
+		conds = append(conds, fmt.Sprintf(`%s.difficulty = ANY(%s::text[])`, alias, next(pq.Array(deck.Difficulties))))
+	}
+	if f := deck.Filter; f != nil {
+		if f.Difficulty != "" {
+			conds = append(conds, fmt.Sprintf(`%s.difficulty = %s`, alias, next(f.Difficulty)))
+		}
+		if f.Topic != "" {
+			conds = append(conds, fmt.Sprintf(`%s = ANY(%s.topics)`, next(f.Topic), alias))
+		}
+	}
+
+	if len(conds) == 0 {
+		return "", args
+	}
+	return " AND " + strings.Join(conds, " AND "), args
+}
+
+// ErrDeckNameTaken is returned when the user already has a deck with that name
+var ErrDeckNameTaken = errors.New("a deck with this name already exists")
+
+// deckWriteError maps the (user_id, name) unique violation to ErrDeckNameTaken
+func deckWriteError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrDeckNameTaken
+	}
+	return err
+}
+
+const deckColumns = `
+	id, user_id, name, kind, list_id, topics, difficulties, filter,
+	new_per_day, reviews_per_day, created_at, updated_at
+`
+
+func scanDeck(row rowScanner) (*models.Deck, error) {
+	var d models.Deck
+	var listID sql.NullString
+	var topics, difficulties pq.StringArray
+	var filterJSON []byte
+	var newPerDay, reviewsPerDay sql.NullInt64
+
+	err := row.Scan(
+		&d.ID, &d.UserID, &d.Name, &d.Kind, &listID, &topics, &difficulties, &filterJSON,
+		&newPerDay, &reviewsPerDay, &d.CreatedAt, &d.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	d.ListID = nullStringPtr(listID)
+	d.Topics = topics
+	d.Difficulties = difficulties
+	if len(filterJSON) > 0 && string(filterJSON) != "null" {
+		d.Filter = &models.QuestionFilters{}
+		if err := jsonUnmarshal(filterJSON, d.Filter); err != nil {
+			return nil, err
+		}
+	}
+	if newPerDay.Valid {
+		n := int(newPerDay.Int64)
+		d.NewPerDay = &n
+	}
+	if reviewsPerDay.Valid {
+		n := int(reviewsPerDay.Int64)
+		d.ReviewsPerDay = &n
+	}
+
+	return &d, nil
+}
+
+// GetDeck returns one of the user's decks (nil if it doesn't exist or isn't theirs)
+func GetDeck(userID, deckID string) (*models.Deck, error) {
+	row := DB.QueryRow(`SELECT `+deckColumns+` FROM decks WHERE id = $1 AND user_id = $2`, deckID, userID)
+	deck, err := scanDeck(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return deck, err
+}
+
+// GetUserDecks returns the user's decks by name
+func GetUserDecks(userID string) ([]models.Deck, error) {
+	rows, err := DB.Query(`SELECT `+deckColumns+` FROM decks WHERE user_id = $1 ORDER BY name`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	decks := []models.Deck{}
+	for rows.Next() {
+		deck, err := scanDeck(rows)
+		if err != nil {
+			return nil, err
+		}
+		decks = append(decks, *deck)
+	}
+	return decks, rows.Err()
+}
+
+// CreateDeck inserts a deck, filling in its ID and timestamps
+func CreateDeck(d *models.Deck) error {
+	filterJSON, err := jsonMarshal(d.Filter)
+	if err != nil {
+		return err
+	}
+
+	err = DB.QueryRow(`
+		INSERT INTO decks (user_id, name, kind, list_id, topics, difficulties, filter, new_per_day, reviews_per_day)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at, updated_at
+	`, d.UserID, d.Name, d.Kind, d.ListID, pq.Array(d.Topics), pq.Array(d.Difficulties),
+		nullableJSON(filterJSON), d.NewPerDay, d.ReviewsPerDay,
+	).Scan(&d.ID, &d.CreatedAt, &d.UpdatedAt)
+	return deckWriteError(err)
+}
+
+// UpdateDeck saves changes to a deck; returns false if the user has no such deck
+func UpdateDeck(d *models.Deck) (bool, error) {
+	filterJSON, err := jsonMarshal(d.Filter)
+	if err != nil {
+		return false, err
+	}
+
+	err = DB.QueryRow(`
+		UPDATE decks
+		SET name = $3, kind = $4, list_id = $5, topics = $6, difficulties = $7, filter = $8,
+		    new_per_day = $9, reviews_per_day = $10, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2
+		RETURNING created_at, updated_at
+	`, d.ID, d.UserID, d.Name, d.Kind, d.ListID, pq.Array(d.Topics), pq.Array(d.Difficulties),
+		nullableJSON(filterJSON), d.NewPerDay, d.ReviewsPerDay,
+	).Scan(&d.CreatedAt, &d.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, deckWriteError(err)
+	}
+	return true, nil
+}
+
+// DeleteDeck removes a deck; its cards stay in the collection
+func DeleteDeck(userID, deckID string) (bool, error) {
+	res, err := DB.Exec(`DELETE FROM decks WHERE id = $1 AND user_id = $2`, deckID, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// CountReviewsDoneToday counts review-state cards answered or skipped today, within the deck if one is given
+// Read from the review log: new and learning cards don't count, nor do practice-only answers (never logged)
+// or answers that were undone
+func CountReviewsDoneToday(userID string, deck *models.Deck) (int, error) {
+	scope, args := deckCondition(deck, "q", []interface{}{userID})
+	query := `
+		SELECT COUNT(DISTINCT l.question_id)
+		FROM review_log l
+		JOIN questions q ON q.id = l.question_id
+		WHERE l.user_id = $1
+		AND l.created_at >= CURRENT_DATE
+		AND l.event IN ('submit', 'skip', 'rate')
+		AND l.state_before = 'review'
+		AND NOT EXISTS (SELECT 1 FROM review_log u WHERE u.undoes_id = l.id)
+	` + scope
+
+	var count int
+	err := DB.QueryRow(query, args...).Scan(&count)
+	return count, err
+}
@@ -21,7 +21,7 @@ func GetAllQuestionsWithProgress(userID string, filters models.QuestionFilters)
 			r.total_reviews, r.total_lapses, r.easiness_factor
 		FROM questions q
 		LEFT JOIN reviews r ON q.id = r.question_id AND r.user_id = $1
-		WHERE 1=1
+		WHERE ` + questionVisibleTo("q", 1) + `
 	`
 
 	args := []interface{}{userID}
@@ -126,10 +126,10 @@ func GetAllQuestionsWithProgress(userID string, filters models.QuestionFilters)
 }
 
 // GetTotalQuestionCount returns total count based on filters (for pagination)
-func GetTotalQuestionCount(filters models.QuestionFilters) (int, error) {
-	query := `SELECT COUNT(*) FROM questions WHERE 1=1`
-	args := []interface{}{}
-	argCount := 0
+func GetTotalQuestionCount(userID string, filters models.QuestionFilters) (int, error) {
+	query := `SELECT COUNT(*) FROM questions q WHERE ` + questionVisibleTo("q", 1)
+	args := []interface{}{userID}
+	argCount := 1
 
 	if filters.Difficulty != "" {
 		argCount++
@@ -148,15 +148,16 @@ func GetTotalQuestionCount(filters models.QuestionFilters) (int, error) {
 	return count, err
 }
 
-// GetAllTopics returns all unique topics from questions
-func GetAllTopics() ([]string, error) {
+// GetAllTopics returns all unique topics from questions the user can see
+func GetAllTopics(userID string) ([]string, error) {
 	query := `
-		SELECT DISTINCT unnest(topics) as topic
-		FROM questions
+		SELECT DISTINCT unnest(q.topics) as topic
+		FROM questions q
+		WHERE ` + questionVisibleTo("q", 1) + `
 		ORDER BY topic
 	`
 
-	rows, err := DB.Query(query)
+	rows, err := DB.Query(query, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -184,7 +185,8 @@ func SearchQuestions(userID, searchTerm string, limit int) ([]models.QuestionWit
 			r.total_reviews, r.total_lapses, r.easiness_factor
 		FROM questions q
 		LEFT JOIN reviews r ON q.id = r.question_id AND r.user_id = $1
-		WHERE q.title ILIKE $2 OR q.description_markdown ILIKE $2
+		WHERE (q.title ILIKE $2 OR q.description_markdown ILIKE $2)
+		  AND ` + questionVisibleTo("q", 1) + `
 		ORDER BY 
 			CASE 
 				WHEN q.title ILIKE $2 THEN 1
@@ -353,6 +355,7 @@ func GetUserProgressSummary(userID string) (*models.ProgressSummary, error) {
 			COUNT(*) FILTER (WHERE r.id IS NULL) as unseen
 		FROM questions q
 		LEFT JOIN reviews r ON q.id = r.question_id AND r.user_id = $1
+		WHERE ` + questionVisibleTo("q", 1) + `
 		GROUP BY q.difficulty
 	`
 
@@ -389,6 +392,7 @@ func GetUserProgressSummary(userID string) (*models.ProgressSummary, error) {
 		FROM (
 			SELECT q.id, unnest(q.topics) as topic
 			FROM questions q
+			WHERE ` + questionVisibleTo("q", 1) + `
 		) topics
 		LEFT JOIN reviews r ON topics.id = r.question_id AND r.user_id = $1
 		GROUP BY topic
@@ -0,0 +1,277 @@
+package database
+
+import (
+	"database/sql"
+	"leetcode-anki/backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// listSummaryColumns selects a problem list with counts and the user's subscription ($1 = user_id)
+const listSummaryColumns = `
+	pl.id, pl.slug, pl.name, pl.description, pl.url, pl.source,
+	(SELECT COUNT(*) FROM list_items li WHERE li.list_id = pl.id) AS item_count,
+	(SELECT COUNT(*) FROM list_items li
+	 JOIN questions q ON q.leetcode_id = li.leetcode_id
+	 WHERE li.list_id = pl.id) AS available_count,
+	(SELECT COUNT(*) FROM list_items li
+	 JOIN questions q ON q.leetcode_id = li.leetcode_id
+	 JOIN reviews r ON r.question_id = q.id AND r.user_id = $1
+	 WHERE li.list_id = pl.id) AS started_count,
+	s.subscribed_at, pl.created_at, pl.updated_at
+`
+
+// UpsertProblemList creates or replaces a list by slug, rewriting its items in order
+func UpsertProblemList(list *models.ProblemList, items []models.ProblemListItem) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO problem_lists (slug, name, description, url, source)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (slug)
+		DO UPDATE SET
+			name = EXCLUDED.name,
+			description = EXCLUDED.description,
+			url = EXCLUDED.url,
+			source = EXCLUDED.source,
+			updated_at = NOW()
+		RETURNING id, created_at, updated_at
+	`, list.Slug, list.Name, list.Description, list.URL, list.Source).Scan(&list.ID, &list.CreatedAt, &list.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	// Subscriptions reference the list, not its items, so replacing items keeps them intact
+	if _, err := tx.Exec(`DELETE FROM list_items WHERE list_id = $1`, list.ID); err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO list_items (list_id, position, section, leetcode_id)
+		VALUES ($1, $2, $3, $4)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, item := range items {
+		if _, err := stmt.Exec(list.ID, item.Position, item.Section, item.LeetcodeID); err != nil {
+			return err
+		}
+	}
+
+	list.ItemCount = len(items)
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	// List edges follow section order
+	return RebuildQuestionPrerequisites()
+}
+
+// GetProblemLists returns every list with the user's progress and subscription
+func GetProblemLists(userID string) ([]models.ProblemList, error) {
+	query := `
+		SELECT ` + listSummaryColumns + `
+		FROM problem_lists pl
+		LEFT JOIN user_list_subscriptions s ON s.list_id = pl.id AND s.user_id = $1
+		ORDER BY pl.source, pl.name
+	`
+
+	rows, err := DB.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lists := []models.ProblemList{}
+	for rows.Next() {
+		list, err := scanProblemList(rows)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, *list)
+	}
+
+	return lists, rows.Err()
+}
+
+// GetProblemListBySlug returns a list with its items in order, or nil if it doesn't exist
+func GetProblemListBySlug(userID, slug string) (*models.ProblemList, error) {
+	query := `
+		SELECT ` + listSummaryColumns + `
+		FROM problem_lists pl
+		LEFT JOIN user_list_subscriptions s ON s.list_id = pl.id AND s.user_id = $1
+		WHERE pl.slug = $2
+	`
+
+	list, err := scanProblemList(DB.QueryRow(query, userID, slug))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	itemsQuery := `
+		SELECT li.position, li.section, li.leetcode_id,
+		       q.id, q.title, q.slug, q.difficulty, r.card_state
+		FROM list_items li
+		LEFT JOIN questions q ON q.leetcode_id = li.leetcode_id
+		LEFT JOIN reviews r ON r.question_id = q.id AND r.user_id = $1
+		WHERE li.list_id = $2
+		ORDER BY li.position
+	`
+
+	rows, err := DB.Query(itemsQuery, userID, list.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item models.ProblemListItem
+		var questionID, title, questionSlug, difficulty, cardState sql.NullString
+
+		err := rows.Scan(
+			&item.Position, &item.Section, &item.LeetcodeID,
+			&questionID, &title, &questionSlug, &difficulty, &cardState,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		item.QuestionID = nullStringPtr(questionID)
+		item.Title = nullStringPtr(title)
+		item.Slug = nullStringPtr(questionSlug)
+		item.Difficulty = nullStringPtr(difficulty)
+		item.CardState = nullStringPtr(cardState)
+		list.Items = append(list.Items, item)
+	}
+
+	return list, rows.Err()
+}
+
+// GetMissingListProblemIDs returns LeetCode IDs in the list that aren't in the question pool yet
+func GetMissingListProblemIDs(listID string) ([]int, error) {
+	query := `
+		SELECT li.leetcode_id
+		FROM list_items li
+		WHERE li.list_id = $1
+		AND NOT EXISTS (SELECT 1 FROM questions q WHERE q.leetcode_id = li.leetcode_id)
+		ORDER BY li.position
+	`
+
+	rows, err := DB.Query(query, listID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// SubscribeToList adds a list to the user's new card sources (no-op if already subscribed)
+func SubscribeToList(userID, listID string) error {
+	query := `
+		INSERT INTO user_list_subscriptions (user_id, list_id)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id, list_id) DO NOTHING
+	`
+
+	_, err := DB.Exec(query, userID, listID)
+	return err
+}
+
+// UnsubscribeFromList stops drawing new cards from a list; existing cards are kept
+func UnsubscribeFromList(userID, listID string) error {
+	_, err := DB.Exec(`DELETE FROM user_list_subscriptions WHERE user_id = $1 AND list_id = $2`, userID, listID)
+	return err
+}
+
+// GetNextListCard returns the next unstarted question from the user's subscribed lists
+// In list order, lists are drawn in subscription order and items in list order; nil when nothing is left
+func GetNextListCard(userID string, opts NewCardOptions) (*models.Question, error) {
+	conds, args := newCardConditions(opts, nil, "q", []interface{}{userID})
+
+	query := `
+		SELECT q.id, q.leetcode_id, q.title, q.slug, q.difficulty,
+		       q.description_markdown, q.topics, q.created_at
+		FROM user_list_subscriptions s
+		JOIN list_items li ON li.list_id = s.list_id
+		JOIN questions q ON q.leetcode_id = li.leetcode_id
+		WHERE s.user_id = $1
+		AND NOT EXISTS (
+			SELECT 1 FROM reviews r
+			WHERE r.user_id = $1 AND r.question_id = q.id
+		)` + conds + `
+		ORDER BY ` + newGatherOrderBy(opts.Order, "s.subscribed_at, li.position") + `
+		LIMIT 1
+	`
+
+	var q models.Question
+	var topics pq.StringArray
+
+	err := DB.QueryRow(query, args...).Scan(
+		&q.ID, &q.LeetcodeID, &q.Title, &q.Slug, &q.Difficulty,
+		&q.DescriptionMarkdown, &topics, &q.CreatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	q.Topics = topics
+	return &q, nil
+}
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanProblemList(row rowScanner) (*models.ProblemList, error) {
+	var list models.ProblemList
+	var subscribedAt sql.NullTime
+
+	err := row.Scan(
+		&list.ID, &list.Slug, &list.Name, &list.Description, &list.URL, &list.Source,
+		&list.ItemCount, &list.AvailableCount, &list.StartedCount,
+		&subscribedAt, &list.CreatedAt, &list.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if subscribedAt.Valid {
+		list.Subscribed = true
+		t := subscribedAt.Time
+		list.SubscribedAt = &t
+	}
+
+	return &list, nil
+}
+
+func nullStringPtr(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
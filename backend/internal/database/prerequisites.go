@@ -0,0 +1,279 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"leetcode-anki/backend/internal/models"
+	"log"
+
+	"github.com/lib/pq"
+)
+
+// NewCardOptions controls how GetNewCard picks the next question
+type NewCardOptions struct {
+	Order                string   // New card gather order: "list", "random" or "difficulty"
+	RequirePrerequisites bool     // Only questions whose prerequisites the user has learned
+	SkipDifficulties     []string // Difficulties whose daily quota is used up
+}
+
+// newCardConditions returns the " AND ..." fragments opts adds to a new card search over alias
+func newCardConditions(opts NewCardOptions, deck *models.Deck, alias string, args []interface{}) (string, []interface{}) {
+	conds := ""
+	if opts.RequirePrerequisites {
+		var unlocked string
+		unlocked, args = prerequisitesMet(deck, alias, args)
+		conds += unlocked
+	}
+	if len(opts.SkipDifficulties) > 0 {
+		args = append(args, pq.Array(opts.SkipDifficulties))
+		conds += fmt.Sprintf(` AND %s.difficulty <> ALL($%d::text[])`, alias, len(args))
+	}
+	return conds, args
+}
+
+// prerequisiteLearned is a condition on the prerequisite question in col being learned by the user ($1):
+// its card is in review, it's suspended (and so can never get there), or it reached review once
+// before being reset or forgotten
+func prerequisiteLearned(col string) string {
+	return `(
+		EXISTS (
+			SELECT 1 FROM reviews pr
+			WHERE pr.user_id = $1 AND pr.question_id = ` + col + `
+			AND pr.card_state IN ('review', 'relearning', 'suspended')
+		)
+		OR EXISTS (
+			SELECT 1 FROM review_log pl
+			WHERE pl.user_id = $1 AND pl.question_id = ` + col + ` AND pl.state_after = 'review'
+		)
+	)`
+}
+
+// prerequisitesMet returns an " AND ..." fragment keeping questions (alias) whose prerequisites
+// the user ($1) has all learned. Within a deck only prerequisites in the deck count, so a deck
+// can't be blocked by problems it will never show.
+func prerequisitesMet(deck *models.Deck, alias string, args []interface{}) (string, []interface{}) {
+	scope, args := deckCondition(deck, "pre", args)
+	return `
+		AND NOT EXISTS (
+			SELECT 1
+			FROM question_prerequisites qp
+			JOIN questions pre ON pre.id = qp.prerequisite_id
+			WHERE qp.question_id = ` + alias + `.id` + scope + `
+			AND NOT ` + prerequisiteLearned("qp.prerequisite_id") + `
+		)`, args
+}
+
+// prerequisiteEdge says question shouldn't be introduced before prerequisite
+type prerequisiteEdge struct {
+	question     string
+	prerequisite string
+	source       string
+}
+
+// RebuildQuestionPrerequisites recomputes the prerequisite graph. Edges come from
+//   - "list":    the previous imported problem in the same section of a curated list, by position
+//   - "pattern": the entry problem of a solution pattern (easiest, then lowest number)
+//     for the harder problems using that pattern
+//
+// Edges are added in that priority (builtin lists first, then by list age) and an edge that would
+// close a cycle is dropped, so some problem is always unlocked. Called whenever lists, list
+// problems or patterns change.
+func RebuildQuestionPrerequisites() error {
+	rows, err := DB.Query(`
+		WITH ranked AS (
+			SELECT id, leetcode_id,
+			       CASE difficulty WHEN 'Easy' THEN 1 WHEN 'Medium' THEN 2 ELSE 3 END AS difficulty_rank,
+			       lower(trim(solution_breakdown->>'pattern')) AS pattern
+			FROM questions
+			WHERE owner_id IS NULL
+		),
+		sections AS (
+			SELECT l.source, l.created_at, l.id AS list_id, li.position, q.id,
+			       LAG(q.id) OVER (PARTITION BY li.list_id, li.section ORDER BY li.position) AS prev_id
+			FROM list_items li
+			JOIN problem_lists l ON l.id = li.list_id
+			JOIN ranked q ON q.leetcode_id = li.leetcode_id
+			WHERE li.section <> ''
+		),
+		entries AS (
+			SELECT DISTINCT ON (pattern) pattern, id, difficulty_rank
+			FROM ranked
+			WHERE COALESCE(pattern, '') <> ''
+			ORDER BY pattern, difficulty_rank, leetcode_id
+		)
+		SELECT question_id, prerequisite_id, source
+		FROM (
+			SELECT s.id AS question_id, s.prev_id AS prerequisite_id, 'list' AS source,
+			       1 AS priority, s.source = 'builtin' AS builtin, s.created_at, s.list_id::text AS tiebreak, s.position
+			FROM sections s
+			WHERE s.prev_id IS NOT NULL
+		UNION ALL
+			SELECT q.id, e.id, 'pattern',
+			       2, true, NULL, q.pattern, q.difficulty_rank * 100000 + q.leetcode_id
+			FROM ranked q
+			JOIN entries e ON e.pattern = q.pattern
+			WHERE e.difficulty_rank < q.difficulty_rank
+		) edges
+		ORDER BY priority, builtin DESC, created_at, tiebreak, position
+	`)
+	if err != nil {
+		return err
+	}
+	var candidates []prerequisiteEdge
+	for rows.Next() {
+		var e prerequisiteEdge
+		if err := rows.Scan(&e.question, &e.prerequisite, &e.source); err != nil {
+			rows.Close()
+			return err
+		}
+		candidates = append(candidates, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	edges := breakPrerequisiteCycles(candidates)
+
+	questionIDs := make([]string, len(edges))
+	prerequisiteIDs := make([]string, len(edges))
+	sources := make([]string, len(edges))
+	for i, e := range edges {
+		questionIDs[i], prerequisiteIDs[i], sources[i] = e.question, e.prerequisite, e.source
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Concurrent rebuilds take turns; readers keep seeing the previous graph until commit
+	if _, err := tx.Exec(`LOCK TABLE question_prerequisites IN EXCLUSIVE MODE`); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM question_prerequisites`); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		INSERT INTO question_prerequisites (question_id, prerequisite_id, source)
+		SELECT * FROM unnest($1::uuid[], $2::uuid[], $3::text[])
+		ON CONFLICT DO NOTHING
+	`, pq.Array(questionIDs), pq.Array(prerequisiteIDs), pq.Array(sources))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// rebuildPrerequisitesIfListed rebuilds the prerequisite graph when the problem is on a curated list
+// Failures are logged: the caller's write succeeded and the next rebuild catches up
+func rebuildPrerequisitesIfListed(leetcodeID int) {
+	var listed bool
+	err := DB.QueryRow(`SELECT EXISTS (SELECT 1 FROM list_items WHERE leetcode_id = $1)`, leetcodeID).Scan(&listed)
+	if err == nil && listed {
+		err = RebuildQuestionPrerequisites()
+	}
+	if err != nil {
+		log.Printf("⚠️ Failed to rebuild prerequisites after problem %d: %v", leetcodeID, err)
+	}
+}
+
+// breakPrerequisiteCycles keeps the candidate edges, in order, that don't close a cycle with the ones kept before
+func breakPrerequisiteCycles(candidates []prerequisiteEdge) []prerequisiteEdge {
+	unlocks := make(map[string][]string) // prerequisite -> questions it directly precedes
+	linked := make(map[[2]string]bool)
+
+	// reaches reports whether from (transitively) precedes to
+	reaches := func(from, to string) bool {
+		seen := map[string]bool{from: true}
+		stack := []string{from}
+		for len(stack) > 0 {
+			id := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if id == to {
+				return true
+			}
+			for _, next := range unlocks[id] {
+				if !seen[next] {
+					seen[next] = true
+					stack = append(stack, next)
+				}
+			}
+		}
+		return false
+	}
+
+	var kept []prerequisiteEdge
+	for _, e := range candidates {
+		pair := [2]string{e.prerequisite, e.question}
+		switch {
+		case e.question == e.prerequisite:
+			continue
+		case linked[pair]:
+			// Same order from another source
+		case reaches(e.question, e.prerequisite):
+			continue
+		default:
+			linked[pair] = true
+			unlocks[e.prerequisite] = append(unlocks[e.prerequisite], e.question)
+		}
+		kept = append(kept, e)
+	}
+	return kept
+}
+
+// GetQuestionPrerequisites returns what should be learned before questionID, with the user's progress on each
+func GetQuestionPrerequisites(userID, questionID string) ([]models.QuestionPrerequisite, error) {
+	query := `
+		SELECT q.id, q.leetcode_id, q.title, q.slug, q.difficulty,
+		       array_agg(DISTINCT qp.source ORDER BY qp.source),
+		       r.card_state, ` + prerequisiteLearned("q.id") + `
+		FROM question_prerequisites qp
+		JOIN questions q ON q.id = qp.prerequisite_id
+		LEFT JOIN reviews r ON r.question_id = q.id AND r.user_id = $1
+		WHERE qp.question_id = $2
+		GROUP BY q.id, r.card_state
+		ORDER BY q.leetcode_id
+	`
+
+	rows, err := DB.Query(query, userID, questionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prerequisites := []models.QuestionPrerequisite{}
+	for rows.Next() {
+		var p models.QuestionPrerequisite
+		var sources pq.StringArray
+		var cardState sql.NullString
+
+		err := rows.Scan(&p.QuestionID, &p.LeetcodeID, &p.Title, &p.Slug, &p.Difficulty, &sources, &cardState, &p.Met)
+		if err != nil {
+			return nil, err
+		}
+
+		p.Sources = sources
+		p.CardState = nullStringPtr(cardState)
+		prerequisites = append(prerequisites, p)
+	}
+
+	return prerequisites, rows.Err()
+}
+
+// UpdateUserEnforcePrerequisites toggles prerequisite-aware new card selection for the user
+func UpdateUserEnforcePrerequisites(userID string, enabled bool) error {
+	query := `
+		UPDATE user_stats
+		SET enforce_prerequisites = $2, updated_at = NOW()
+		WHERE user_id = $1
+	`
+	// Ensure stats exist first
+	if _, err := GetUserStats(userID); err != nil {
+		return err
+	}
+
+	_, err := DB.Exec(query, userID, enabled)
+	return err
+}
@@ -0,0 +1,132 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBreakPrerequisiteCycles(t *testing.T) {
+	edge := func(question, prerequisite, source string) prerequisiteEdge {
+		return prerequisiteEdge{question: question, prerequisite: prerequisite, source: source}
+	}
+
+	tests := []struct {
+		name       string
+		candidates []prerequisiteEdge
+		want       []prerequisiteEdge
+	}{
+		{
+			name: "chain",
+			candidates: []prerequisiteEdge{
+				edge("b", "a", "list"),
+				edge("c", "b", "list"),
+			},
+			want: []prerequisiteEdge{
+				edge("b", "a", "list"),
+				edge("c", "b", "list"),
+			},
+		},
+		{
+			name:       "self edge",
+			candidates: []prerequisiteEdge{edge("a", "a", "pattern")},
+			want:       nil,
+		},
+		{
+			name: "two lists disagree: the first one wins",
+			candidates: []prerequisiteEdge{
+				edge("b", "a", "list"),
+				edge("a", "b", "list"),
+			},
+			want: []prerequisiteEdge{edge("b", "a", "list")},
+		},
+		{
+			name: "longer cycle is broken at the closing edge",
+			candidates: []prerequisiteEdge{
+				edge("b", "a", "list"),
+				edge("c", "b", "list"),
+				edge("a", "c", "pattern"),
+				edge("d", "c", "pattern"),
+			},
+			want: []prerequisiteEdge{
+				edge("b", "a", "list"),
+				edge("c", "b", "list"),
+				edge("d", "c", "pattern"),
+			},
+		},
+		{
+			name: "same order from another source is kept",
+			candidates: []prerequisiteEdge{
+				edge("b", "a", "list"),
+				edge("b", "a", "pattern"),
+			},
+			want: []prerequisiteEdge{
+				edge("b", "a", "list"),
+				edge("b", "a", "pattern"),
+			},
+		},
+		{
+			name: "diamond isn't a cycle",
+			candidates: []prerequisiteEdge{
+				edge("b", "a", "list"),
+				edge("c", "a", "list"),
+				edge("d", "b", "pattern"),
+				edge("d", "c", "pattern"),
+			},
+			want: []prerequisiteEdge{
+				edge("b", "a", "list"),
+				edge("c", "a", "list"),
+				edge("d", "b", "pattern"),
+				edge("d", "c", "pattern"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := breakPrerequisiteCycles(tt.candidates)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("breakPrerequisiteCycles = %v, want %v", got, tt.want)
+			}
+			if cycle := findPrerequisiteCycle(got); cycle != "" {
+				t.Errorf("result still has a cycle through %s", cycle)
+			}
+		})
+	}
+}
+
+// findPrerequisiteCycle returns a question on a cycle of edges, or "" if there is none
+func findPrerequisiteCycle(edges []prerequisiteEdge) string {
+	unlocks := make(map[string][]string)
+	for _, e := range edges {
+		unlocks[e.prerequisite] = append(unlocks[e.prerequisite], e.question)
+	}
+
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int)
+	var visit func(id string) string
+	visit = func(id string) string {
+		switch state[id] {
+		case visiting:
+			return id
+		case done:
+			return ""
+		}
+		state[id] = visiting
+		for _, next := range unlocks[id] {
+			if cycle := visit(next); cycle != "" {
+				return cycle
+			}
+		}
+		state[id] = done
+		return ""
+	}
+
+	for _, e := range edges {
+		if cycle := visit(e.prerequisite); cycle != "" {
+			return cycle
+		}
+	}
+	return ""
+}
@@ -0,0 +1,366 @@
+package database
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"leetcode-anki/backend/internal/models"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// UpsertOutcome says what UpsertQuestion did with a question
+type UpsertOutcome string
+
+const (
+	UpsertInserted  UpsertOutcome = "inserted"
+	UpsertUpdated   UpsertOutcome = "updated"
+	UpsertUnchanged UpsertOutcome = "unchanged"
+)
+
+// UpsertQuestion inserts a question or updates it when its content changed
+// Every importer (GraphQL, fixtures, question files, lists, background refill) goes through here.
+// Changes are detected with a content hash and each new version is kept in question_revisions.
+// Metadata (hints, snippets, stats, ...) is refreshed on every import and doesn't affect the outcome.
+func UpsertQuestion(q *models.Question) (UpsertOutcome, error) {
+	hash := QuestionContentHash(q)
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	var existingHash sql.NullString
+	var existing models.Question
+	var existingTopics pq.StringArray
+
+	err = tx.QueryRow(`
+		SELECT id, title, slug, difficulty, description_markdown, topics, content_hash
+		FROM questions
+		WHERE leetcode_id = $1
+		FOR UPDATE
+	`, q.LeetcodeID).Scan(
+		&existing.ID, &existing.Title, &existing.Slug, &existing.Difficulty,
+		&existing.DescriptionMarkdown, &existingTopics, &existingHash,
+	)
+
+	outcome := UpsertUpdated
+	switch {
+	case err == sql.ErrNoRows:
+		err = tx.QueryRow(`
+			INSERT INTO questions
+			(leetcode_id, title, slug, difficulty, description_markdown, topics, content_hash)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			ON CONFLICT (leetcode_id) DO NOTHING
+			RETURNING id
+		`, q.LeetcodeID, q.Title, q.Slug, q.Difficulty, q.DescriptionMarkdown, pq.Array(q.Topics), hash).Scan(&q.ID)
+		if err == sql.ErrNoRows {
+			// A concurrent import inserted it first; treat its copy as current
+			return UpsertUnchanged, tx.QueryRow(`SELECT id FROM questions WHERE leetcode_id = $1`, q.LeetcodeID).Scan(&q.ID)
+		}
+		if err != nil {
+			return "", err
+		}
+		outcome = UpsertInserted
+
+	case err != nil:
+		return "", err
+
+	default:
+		q.ID = existing.ID
+		existing.LeetcodeID = q.LeetcodeID
+		existing.Topics = existingTopics
+
+		// Rows imported before hashing existed get their hash computed from stored content
+		storedHash := existingHash.String
+		if !existingHash.Valid {
+			storedHash = QuestionContentHash(&existing)
+		}
+
+		if storedHash == hash {
+			if !existingHash.Valid {
+				if _, err := tx.Exec(`UPDATE questions SET content_hash = $2 WHERE id = $1`, q.ID, hash); err != nil {
+					return "", err
+				}
+			}
+			if err := saveQuestionMetadata(tx, q); err != nil {
+				return "", err
+			}
+			return UpsertUnchanged, tx.Commit()
+		}
+
+		// Rows saved before hashing have no revision yet; keep the content being replaced as the first one
+		if !existingHash.Valid {
+			_, err = tx.Exec(`
+				INSERT INTO question_revisions
+				(question_id, content_hash, title, difficulty, description_markdown, topics, created_at)
+				SELECT id, $2, title, difficulty, description_markdown, topics, created_at
+				FROM questions
+				WHERE id = $1
+			`, q.ID, storedHash)
+			if err != nil {
+				return "", err
+			}
+		}
+
+		_, err = tx.Exec(`
+			UPDATE questions SET
+				title = $2,
+				slug = $3,
+				difficulty = $4,
+				description_markdown = $5,
+				topics = $6,
+				content_hash = $7
+			WHERE id = $1
+		`, q.ID, q.Title, q.Slug, q.Difficulty, q.DescriptionMarkdown, pq.Array(q.Topics), hash)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO question_revisions
+		(question_id, content_hash, title, difficulty, description_markdown, topics)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, q.ID, hash, q.Title, q.Difficulty, q.DescriptionMarkdown, pq.Array(q.Topics))
+	if err != nil {
+		return "", err
+	}
+
+	if err := saveQuestionMetadata(tx, q); err != nil {
+		return "", err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+	if outcome == UpsertInserted {
+		// A newly imported list problem links up its section
+		rebuildPrerequisitesIfListed(q.LeetcodeID)
+	}
+	return outcome, nil
+}
+
+// saveQuestionMetadata writes the question's metadata inside an upsert
+// Nil hints, snippets, similar questions or stats mean "not provided" and keep what's stored
+func saveQuestionMetadata(tx *sql.Tx, q *models.Question) error {
+	examplesJSON, err := jsonMarshal(q.Examples)
+	if err != nil {
+		return err
+	}
+
+	var likes, dislikes sql.NullInt64
+	var acceptanceRate sql.NullFloat64
+	var totalAccepted, totalSubmissions sql.NullInt64
+	if q.Stats != nil {
+		likes = sql.NullInt64{Int64: int64(q.Stats.Likes), Valid: true}
+		dislikes = sql.NullInt64{Int64: int64(q.Stats.Dislikes), Valid: true}
+		acceptanceRate = sql.NullFloat64{Float64: q.Stats.AcceptanceRate, Valid: true}
+		totalAccepted = sql.NullInt64{Int64: q.Stats.TotalAccepted, Valid: true}
+		totalSubmissions = sql.NullInt64{Int64: q.Stats.TotalSubmissions, Valid: true}
+	}
+
+	var hints interface{}
+	if q.Hints != nil {
+		hints = pq.Array(q.Hints)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE questions SET
+			hints = COALESCE($2, hints),
+			examples = $3,
+			constraints = $4,
+			example_testcases = CASE WHEN $5::text = '' THEN example_testcases ELSE $5::text END,
+			likes = COALESCE($6, likes),
+			dislikes = COALESCE($7, dislikes),
+			acceptance_rate = COALESCE($8, acceptance_rate),
+			total_accepted = COALESCE($9, total_accepted),
+			total_submissions = COALESCE($10, total_submissions)
+		WHERE id = $1
+	`, q.ID, hints, examplesJSON, pq.Array(nonNil(q.Constraints)), q.ExampleTestcases,
+		likes, dislikes, acceptanceRate, totalAccepted, totalSubmissions)
+	if err != nil {
+		return err
+	}
+
+	if q.CodeSnippets != nil {
+		if _, err := tx.Exec(`DELETE FROM question_code_snippets WHERE question_id = $1`, q.ID); err != nil {
+			return err
+		}
+		for _, snippet := range q.CodeSnippets {
+			_, err := tx.Exec(`
+				INSERT INTO question_code_snippets (question_id, lang_slug, lang, code)
+				VALUES ($1, $2, $3, $4)
+				ON CONFLICT (question_id, lang_slug) DO UPDATE SET lang = EXCLUDED.lang, code = EXCLUDED.code
+			`, q.ID, snippet.LangSlug, snippet.Lang, snippet.Code)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if q.SimilarQuestions != nil {
+		if _, err := tx.Exec(`DELETE FROM question_similar WHERE question_id = $1`, q.ID); err != nil {
+			return err
+		}
+		for i, similar := range q.SimilarQuestions {
+			_, err := tx.Exec(`
+				INSERT INTO question_similar (question_id, position, similar_slug, title, difficulty)
+				VALUES ($1, $2, $3, $4, $5)
+				ON CONFLICT (question_id, similar_slug) DO NOTHING
+			`, q.ID, i+1, similar.Slug, similar.Title, similar.Difficulty)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// LoadQuestionMetadata fills hints, examples, constraints, starter code, similar questions and stats
+func LoadQuestionMetadata(q *models.Question) error {
+	var hints, constraints pq.StringArray
+	var examplesJSON []byte
+	var likes, dislikes, totalAccepted, totalSubmissions sql.NullInt64
+	var acceptanceRate sql.NullFloat64
+
+	err := DB.QueryRow(`
+		SELECT hints, examples, constraints, example_testcases,
+		       likes, dislikes, acceptance_rate, total_accepted, total_submissions
+		FROM questions
+		WHERE id = $1
+	`, q.ID).Scan(
+		&hints, &examplesJSON, &constraints, &q.ExampleTestcases,
+		&likes, &dislikes, &acceptanceRate, &totalAccepted, &totalSubmissions,
+	)
+	if err != nil {
+		return err
+	}
+
+	q.Hints = hints
+	q.Constraints = constraints
+	if err := jsonUnmarshal(examplesJSON, &q.Examples); err != nil {
+		return err
+	}
+	if likes.Valid || acceptanceRate.Valid {
+		q.Stats = &models.LeetCodeStats{
+			Likes:            int(likes.Int64),
+			Dislikes:         int(dislikes.Int64),
+			AcceptanceRate:   acceptanceRate.Float64,
+			TotalAccepted:    totalAccepted.Int64,
+			TotalSubmissions: totalSubmissions.Int64,
+		}
+	}
+
+	rows, err := DB.Query(`
+		SELECT lang, lang_slug, code
+		FROM question_code_snippets
+		WHERE question_id = $1
+		ORDER BY lang
+	`, q.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	q.CodeSnippets = nil
+	for rows.Next() {
+		var snippet models.CodeSnippet
+		if err := rows.Scan(&snippet.Lang, &snippet.LangSlug, &snippet.Code); err != nil {
+			return err
+		}
+		q.CodeSnippets = append(q.CodeSnippets, snippet)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	similarRows, err := DB.Query(`
+		SELECT s.title, s.similar_slug, s.difficulty, sq.id
+		FROM question_similar s
+		LEFT JOIN questions sq ON sq.slug = s.similar_slug
+		WHERE s.question_id = $1
+		ORDER BY s.position
+	`, q.ID)
+	if err != nil {
+		return err
+	}
+	defer similarRows.Close()
+
+	q.SimilarQuestions = nil
+	for similarRows.Next() {
+		var similar models.SimilarQuestion
+		var questionID sql.NullString
+		if err := similarRows.Scan(&similar.Title, &similar.Slug, &similar.Difficulty, &questionID); err != nil {
+			return err
+		}
+		similar.QuestionID = nullStringPtr(questionID)
+		q.SimilarQuestions = append(q.SimilarQuestions, similar)
+	}
+
+	return similarRows.Err()
+}
+
+// nonNil turns a nil slice into an empty one so NOT NULL array columns accept it
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// QuestionContentHash fingerprints the imported content of a question
+// Whitespace around the description is ignored so re-rendered Markdown doesn't count as a change
+func QuestionContentHash(q *models.Question) string {
+	h := sha256.New()
+	for _, part := range []string{
+		q.Title,
+		q.Slug,
+		q.Difficulty,
+		strings.TrimSpace(q.DescriptionMarkdown),
+		strings.Join(q.Topics, "\x1f"),
+	} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// GetQuestionRevisions returns every recorded version of a question, newest first
+func GetQuestionRevisions(questionID string) ([]models.QuestionRevision, error) {
+	query := `
+		SELECT id, question_id, content_hash, title, difficulty,
+		       description_markdown, topics, created_at
+		FROM question_revisions
+		WHERE question_id = $1
+		ORDER BY created_at DESC
+	`
+
+	rows, err := DB.Query(query, questionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []models.QuestionRevision{}
+	for rows.Next() {
+		var r models.QuestionRevision
+		var topics pq.StringArray
+
+		err := rows.Scan(
+			&r.ID, &r.QuestionID, &r.ContentHash, &r.Title, &r.Difficulty,
+			&r.DescriptionMarkdown, &topics, &r.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		r.Topics = topics
+		revisions = append(revisions, r)
+	}
+
+	return revisions, rows.Err()
+}
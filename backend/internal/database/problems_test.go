@@ -0,0 +1,130 @@
+package database
+
+import (
+	"database/sql"
+	"leetcode-anki/backend/internal/models"
+	"os"
+	"testing"
+)
+
+func TestQuestionContentHash(t *testing.T) {
+	base := models.Question{
+		LeetcodeID:          1,
+		Title:               "Two Sum",
+		Slug:                "two-sum",
+		Difficulty:          "Easy",
+		DescriptionMarkdown: "Find two numbers that add up to target.",
+		Topics:              []string{"Array", "Hash Table"},
+		Hints:               []string{"Use a map"},
+	}
+
+	tests := []struct {
+		name    string
+		edit    func(q *models.Question)
+		changed bool
+	}{
+		{"identical", func(q *models.Question) {}, false},
+		{"surrounding whitespace", func(q *models.Question) { q.DescriptionMarkdown = "\n" + q.DescriptionMarkdown + "  \n" }, false},
+		{"metadata only", func(q *models.Question) { q.Hints = nil; q.ExampleTestcases = "[2,7]\n9" }, false},
+		{"title", func(q *models.Question) { q.Title = "Two Sum II" }, true},
+		{"slug", func(q *models.Question) { q.Slug = "two-sum-ii" }, true},
+		{"difficulty", func(q *models.Question) { q.Difficulty = "Medium" }, true},
+		{"description", func(q *models.Question) { q.DescriptionMarkdown += " Each input has one solution." }, true},
+		{"topic added", func(q *models.Question) { q.Topics = append(q.Topics, "Sorting") }, true},
+		{"topic order", func(q *models.Question) { q.Topics = []string{"Hash Table", "Array"} }, true},
+		{"fields don't run together", func(q *models.Question) { q.Title, q.Slug = "Two Sumtwo-sum", "" }, true},
+	}
+
+	want := QuestionContentHash(&base)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := base
+			q.Topics = append([]string(nil), base.Topics...)
+			tt.edit(&q)
+
+			if got := QuestionContentHash(&q) != want; got != tt.changed {
+				t.Errorf("hash changed = %v, want %v", got, tt.changed)
+			}
+		})
+	}
+}
+
+// TestUpsertQuestion runs against a migrated database named by TEST_DATABASE_URL
+func TestUpsertQuestion(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := DB
+	DB = db
+	t.Cleanup(func() {
+		DB = previous
+		db.Close()
+	})
+
+	const leetcodeID = 987654321
+	cleanup := func() {
+		if _, err := db.Exec(`DELETE FROM questions WHERE leetcode_id = $1`, leetcodeID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cleanup()
+	t.Cleanup(cleanup)
+
+	revisions := func(id string) int {
+		var n int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM question_revisions WHERE question_id = $1`, id).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	question := func(description string) *models.Question {
+		return &models.Question{
+			LeetcodeID:          leetcodeID,
+			Title:               "Upsert Test",
+			Slug:                "upsert-test",
+			Difficulty:          "Easy",
+			DescriptionMarkdown: description,
+			Topics:              []string{"Array"},
+		}
+	}
+
+	steps := []struct {
+		name          string
+		description   string
+		clearHash     bool // Simulates a row imported before content hashing
+		want          UpsertOutcome
+		wantRevisions int
+	}{
+		{"insert", "first", false, UpsertInserted, 1},
+		{"same content", "first", false, UpsertUnchanged, 1},
+		{"changed content", "second", false, UpsertUpdated, 2},
+		{"unhashed row, same content", "second", true, UpsertUnchanged, 2},
+		{"unhashed row, changed content", "third", true, UpsertUpdated, 4},
+	}
+	for _, step := range steps {
+		if step.clearHash {
+			if _, err := db.Exec(`UPDATE questions SET content_hash = NULL WHERE leetcode_id = $1`, leetcodeID); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		q := question(step.description)
+		got, err := UpsertQuestion(q)
+		if err != nil {
+			t.Fatalf("%s: UpsertQuestion: %v", step.name, err)
+		}
+		if got != step.want {
+			t.Errorf("%s: outcome = %s, want %s", step.name, got, step.want)
+		}
+		if n := revisions(q.ID); n != step.wantRevisions {
+			t.Errorf("%s: %d revisions, want %d", step.name, n, step.wantRevisions)
+		}
+	}
+}
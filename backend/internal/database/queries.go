@@ -2,6 +2,8 @@ package database
 
 import (
 	"database/sql"
+	"fmt"
+	"leetcode-anki/backend/config"
 	"leetcode-anki/backend/internal/models"
 	"log"
 	"strconv"
@@ -14,7 +16,8 @@ import (
 func GetQuestionByID(questionID string) (*models.Question, error) {
 	query := `
 		SELECT id, leetcode_id, title, slug, difficulty, 
-		       description_markdown, topics, solution_breakdown, created_at
+		       description_markdown, topics, solution_breakdown, created_at,
+		       owner_id, team_id, visibility
 		FROM questions
 		WHERE id = $1
 	`
@@ -22,10 +25,12 @@ func GetQuestionByID(questionID string) (*models.Question, error) {
 	var q models.Question
 	var topics pq.StringArray
 	var solutionBreakdownJSON []byte
+	var ownerID, teamID sql.NullString
 
 	err := DB.QueryRow(query, questionID).Scan(
 		&q.ID, &q.LeetcodeID, &q.Title, &q.Slug, &q.Difficulty,
 		&q.DescriptionMarkdown, &topics, &solutionBreakdownJSON, &q.CreatedAt,
+		&ownerID, &teamID, &q.Visibility,
 	)
 
 	if err != nil {
@@ -33,6 +38,8 @@ func GetQuestionByID(questionID string) (*models.Question, error) {
 	}
 
 	q.Topics = topics
+	q.OwnerID = nullStringPtr(ownerID)
+	q.TeamID = nullStringPtr(teamID)
 
 	// Unmarshal solution breakdown if it exists
 	if len(solutionBreakdownJSON) > 0 {
@@ -46,46 +53,83 @@ func GetQuestionByID(questionID string) (*models.Question, error) {
 }
 
 // UpdateQuestionSolution updates the cached solution breakdown for a question
+// A changed pattern rebuilds the prerequisite graph, whose pattern edges depend on it
 func UpdateQuestionSolution(questionID string, solution *models.SolutionBreakdown) error {
+	patternChanged, err := CacheQuestionSolution(questionID, solution)
+	if err != nil {
+		return err
+	}
+
+	if patternChanged {
+		if err := RebuildQuestionPrerequisites(); err != nil {
+			// The solution is saved; the graph catches up on the next rebuild
+			log.Printf("⚠️ Failed to rebuild prerequisites after question %s: %v", questionID, err)
+		}
+	}
+	return nil
+}
+
+// CacheQuestionSolution stores the solution breakdown without touching the prerequisite graph
+// Reports whether the pattern changed, so batch writers can rebuild the graph once at the end
+func CacheQuestionSolution(questionID string, solution *models.SolutionBreakdown) (bool, error) {
 	query := `
-		UPDATE questions
+		UPDATE questions q
 		SET solution_breakdown = $1
-		WHERE id = $2
+		FROM (SELECT id, solution_breakdown FROM questions WHERE id = $2 FOR UPDATE) old
+		WHERE q.id = old.id
+		RETURNING lower(trim(old.solution_breakdown->>'pattern')) IS DISTINCT FROM lower(trim(q.solution_breakdown->>'pattern'))
 	`
 
 	solutionJSON, err := jsonMarshal(solution)
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	_, err = DB.Exec(query, solutionJSON, questionID)
-	return err
+	var patternChanged bool
+	err = DB.QueryRow(query, solutionJSON, questionID).Scan(&patternChanged)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return patternChanged, err
 }
 
 // GetReview retrieves a review record (handles nullable fields properly)
 func GetReview(userID, questionID string) (*models.Review, error) {
-	query := `
-		SELECT id, user_id, question_id, card_state, quality, 
-		       easiness_factor, interval_days, interval_minutes, current_step, repetitions,
-		       next_review_at, last_reviewed_at, total_reviews, total_lapses, created_at
-		FROM reviews
-		WHERE user_id = $1 AND question_id = $2
-	`
+	return getReview(DB, userID, questionID)
+}
 
-	var r models.Review
-	var quality sql.NullInt32
-	var lastReviewedAt sql.NullTime
+// queryRower is satisfied by *sql.DB and *sql.Tx
+type queryRower interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
 
-	err := DB.QueryRow(query, userID, questionID).Scan(
-		&r.ID, &r.UserID, &r.QuestionID, &r.CardState, &quality,
-		&r.EasinessFactor, &r.IntervalDays, &r.IntervalMinutes, &r.CurrentStep, &r.Repetitions,
-		&r.NextReviewAt, &lastReviewedAt, &r.TotalReviews, &r.TotalLapses, &r.CreatedAt,
-	)
+func getReview(db queryRower, userID, questionID string) (*models.Review, error) {
+	query := `SELECT ` + reviewColumns + ` FROM reviews r WHERE r.user_id = $1 AND r.question_id = $2`
 
+	r, err := scanReview(db.QueryRow(query, userID, questionID))
 	if err == sql.ErrNoRows {
 		return nil, nil // No review found (new card)
 	}
+	return r, err
+}
+
+// reviewColumns selects a review r in the order scanReview reads them
+const reviewColumns = `
+	r.id, r.user_id, r.question_id, r.card_state, r.quality,
+	r.easiness_factor, r.interval_days, r.interval_minutes, r.current_step, r.repetitions,
+	r.next_review_at, r.last_reviewed_at, r.total_reviews, r.total_lapses, r.created_at, r.new_position
+`
 
+func scanReview(row rowScanner) (*models.Review, error) {
+	var r models.Review
+	var quality, newPosition sql.NullInt32
+	var lastReviewedAt sql.NullTime
+
+	err := row.Scan(
+		&r.ID, &r.UserID, &r.QuestionID, &r.CardState, &quality,
+		&r.EasinessFactor, &r.IntervalDays, &r.IntervalMinutes, &r.CurrentStep, &r.Repetitions,
+		&r.NextReviewAt, &lastReviewedAt, &r.TotalReviews, &r.TotalLapses, &r.CreatedAt, &newPosition,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -98,10 +142,44 @@ func GetReview(userID, questionID string) (*models.Review, error) {
 	if lastReviewedAt.Valid {
 		r.LastReviewedAt = &lastReviewedAt.Time
 	}
+	if newPosition.Valid {
+		p := int(newPosition.Int32)
+		r.NewPosition = &p
+	}
 
 	return &r, nil
 }
 
+// querier is satisfied by *sql.DB and *sql.Tx
+type querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// queryReviews returns reviews r (joined with their questions q) matching where, in orderBy order
+func queryReviews(db querier, where, orderBy string, args ...interface{}) ([]*models.Review, error) {
+	rows, err := db.Query(`
+		SELECT `+reviewColumns+`
+		FROM reviews r
+		JOIN questions q ON q.id = r.question_id
+		WHERE `+where+`
+		ORDER BY `+orderBy,
+		args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reviews []*models.Review
+	for rows.Next() {
+		r, err := scanReview(rows)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, r)
+	}
+	return reviews, rows.Err()
+}
+
 // CreateReview inserts a new review record
 func CreateReview(review *models.Review) error {
 	query := `
@@ -122,21 +200,30 @@ func CreateReview(review *models.Review) error {
 
 // UpdateReview updates an existing review record
 func UpdateReview(review *models.Review) error {
+	return updateReview(DB, review)
+}
+
+// execer is satisfied by *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+func updateReview(db execer, review *models.Review) error {
 	query := `
 		UPDATE reviews
 		SET card_state = $1, quality = $2, easiness_factor = $3,
 		    interval_days = $4, interval_minutes = $5, current_step = $6,
 		    repetitions = $7, next_review_at = $8,
-		    last_reviewed_at = $9, total_reviews = $10, total_lapses = $11
-		WHERE id = $12
+		    last_reviewed_at = $9, total_reviews = $10, total_lapses = $11, new_position = $12
+		WHERE id = $13
 	`
 
-	_, err := DB.Exec(
+	_, err := db.Exec(
 		query,
 		review.CardState, review.Quality, review.EasinessFactor,
 		review.IntervalDays, review.IntervalMinutes, review.CurrentStep,
 		review.Repetitions, review.NextReviewAt,
-		review.LastReviewedAt, review.TotalReviews, review.TotalLapses,
+		review.LastReviewedAt, review.TotalReviews, review.TotalLapses, review.NewPosition,
 		review.ID,
 	)
 
@@ -147,32 +234,23 @@ func UpdateReview(review *models.Review) error {
 // ANKI-STYLE PRIORITY QUERIES
 // ============================================
 
-// GetNextLearningCard retrieves the next learning/relearning card that's due
-// PRIORITY 1: These are cards with intervals < 1 day (short-term memory window)
-func GetNextLearningCard(userID string) (*models.Card, error) {
-	query := `
-		SELECT 
-			r.id, r.user_id, r.question_id, r.card_state, r.quality,
-			r.easiness_factor, r.interval_days, r.interval_minutes,
-			r.current_step, r.repetitions, r.next_review_at,
-			r.last_reviewed_at, r.total_reviews, r.total_lapses, r.created_at,
-			q.id, q.leetcode_id, q.title, q.slug, q.difficulty,
-			q.description_markdown, q.topics, q.created_at
-		FROM reviews r
-		JOIN questions q ON r.question_id = q.id
-		WHERE r.user_id = $1
-		AND r.card_state IN ('learning', 'relearning', 'new')
-		AND r.next_review_at <= NOW()
-		ORDER BY r.next_review_at ASC
-		LIMIT 1
-	`
-
+// cardColumns selects a review joined with its question, in the order scanCard reads them
+const cardColumns = `
+	r.id, r.user_id, r.question_id, r.card_state, r.quality,
+	r.easiness_factor, r.interval_days, r.interval_minutes,
+	r.current_step, r.repetitions, r.next_review_at,
+	r.last_reviewed_at, r.total_reviews, r.total_lapses, r.created_at,
+	q.id, q.leetcode_id, q.title, q.slug, q.difficulty,
+	q.description_markdown, q.topics, q.created_at
+`
+
+func scanCard(row rowScanner) (*models.Card, error) {
 	var card models.Card
 	var topics pq.StringArray
 	var quality sql.NullInt32
 	var lastReviewedAt sql.NullTime
 
-	err := DB.QueryRow(query, userID).Scan(
+	err := row.Scan(
 		&card.Review.ID, &card.Review.UserID, &card.Review.QuestionID,
 		&card.Review.CardState, &quality, &card.Review.EasinessFactor,
 		&card.Review.IntervalDays, &card.Review.IntervalMinutes,
@@ -184,10 +262,6 @@ func GetNextLearningCard(userID string) (*models.Card, error) {
 		&card.Question.DescriptionMarkdown, &topics,
 		&card.Question.CreatedAt,
 	)
-
-	if err == sql.ErrNoRows {
-		return nil, nil
-	}
 	if err != nil {
 		return nil, err
 	}
@@ -204,173 +278,148 @@ func GetNextLearningCard(userID string) (*models.Card, error) {
 	return &card, nil
 }
 
-// GetNextReviewCard retrieves the next review card that's due today
-// PRIORITY 2: These are graduated cards (intervals >= 1 day)
-func GetNextReviewCard(userID string) (*models.Card, error) {
+// getFirstCard returns the first unburied card of the user (and deck) matching where, or nil
+// Cards whose question the user can no longer see (made private, or the user left its team) are skipped
+func getFirstCard(userID string, deck *models.Deck, where, orderBy string) (*models.Card, error) {
+	scope, args := deckCondition(deck, "q", []interface{}{userID})
 	query := `
-		SELECT 
-			r.id, r.user_id, r.question_id, r.card_state, r.quality,
-			r.easiness_factor, r.interval_days, r.interval_minutes,
-			r.current_step, r.repetitions, r.next_review_at,
-			r.last_reviewed_at, r.total_reviews, r.total_lapses, r.created_at,
-			q.id, q.leetcode_id, q.title, q.slug, q.difficulty,
-			q.description_markdown, q.topics, q.created_at
+		SELECT ` + cardColumns + `
 		FROM reviews r
 		JOIN questions q ON r.question_id = q.id
 		WHERE r.user_id = $1
-		AND r.card_state = 'review'
-		AND r.next_review_at <= NOW()
-		ORDER BY r.next_review_at ASC
+		AND ` + where + `
+		AND (r.buried_until IS NULL OR r.buried_until <= NOW())
+		AND ` + questionVisibleTo("q", 1) + scope + `
+		ORDER BY ` + orderBy + `
 		LIMIT 1
 	`
 
-	var card models.Card
-	var topics pq.StringArray
-	var quality sql.NullInt32
-	var lastReviewedAt sql.NullTime
-
-	err := DB.QueryRow(query, userID).Scan(
-		&card.Review.ID, &card.Review.UserID, &card.Review.QuestionID,
-		&card.Review.CardState, &quality, &card.Review.EasinessFactor,
-		&card.Review.IntervalDays, &card.Review.IntervalMinutes,
-		&card.Review.CurrentStep, &card.Review.Repetitions,
-		&card.Review.NextReviewAt, &lastReviewedAt,
-		&card.Review.TotalReviews, &card.Review.TotalLapses, &card.Review.CreatedAt,
-		&card.Question.ID, &card.Question.LeetcodeID, &card.Question.Title,
-		&card.Question.Slug, &card.Question.Difficulty,
-		&card.Question.DescriptionMarkdown, &topics,
-		&card.Question.CreatedAt,
-	)
-
+	card, err := scanCard(DB.QueryRow(query, args...))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
-	if err != nil {
-		return nil, err
-	}
+	return card, err
+}
 
-	if quality.Valid {
-		q := int(quality.Int32)
-		card.Review.Quality = &q
-	}
-	if lastReviewedAt.Valid {
-		card.Review.LastReviewedAt = &lastReviewedAt.Time
+// GetNextLearningCard retrieves the next learning/relearning card that's due
+// PRIORITY 1: These are cards with intervals < 1 day (short-term memory window)
+// A non-nil deck limits the search to that deck's questions (likewise for the queries below)
+func GetNextLearningCard(userID string, deck *models.Deck) (*models.Card, error) {
+	return getFirstCard(userID, deck,
+		`r.card_state IN ('learning', 'relearning') AND r.next_review_at <= NOW()`,
+		`r.next_review_at ASC`)
+}
+
+// retrievabilitySQL estimates the chance of recalling a card now: 90% at the end of its interval,
+// decaying exponentially with elapsed time
+const retrievabilitySQL = `POWER(0.9, EXTRACT(EPOCH FROM NOW() - COALESCE(r.last_reviewed_at, r.created_at)) / 86400.0 / GREATEST(r.interval_days, 1))`
+
+// reviewOrderBy maps a review sort setting to an ORDER BY clause over reviews r
+func reviewOrderBy(sort string) string {
+	switch sort {
+	case "overdueness":
+		return `EXTRACT(EPOCH FROM NOW() - r.next_review_at) / GREATEST(r.interval_days, 1) DESC`
+	case "retrievability":
+		return retrievabilitySQL + ` ASC`
+	case "random":
+		return `RANDOM()`
+	case "difficulty":
+		return `r.easiness_factor ASC, r.next_review_at ASC`
+	default:
+		return `r.next_review_at ASC`
 	}
-	card.Question.Topics = topics
+}
 
-	return &card, nil
+// GetNextReviewCard retrieves the next review card that's due today, in the user's review sort order
+// PRIORITY 2: These are graduated cards (intervals >= 1 day)
+func GetNextReviewCard(userID string, deck *models.Deck, sort string) (*models.Card, error) {
+	return getFirstCard(userID, deck,
+		`r.card_state = 'review' AND r.next_review_at <= NOW()`,
+		reviewOrderBy(sort))
 }
 
 // GetNextNewCardReview retrieves an existing card in 'new' state
-// Used to prioritize new cards that have been created but not yet studied
-func GetNextNewCardReview(userID string) (*models.Card, error) {
+// Used to prioritize new cards that have been created but not yet studied; repositioned cards go first
+func GetNextNewCardReview(userID string, deck *models.Deck) (*models.Card, error) {
+	return getFirstCard(userID, deck, `r.card_state = 'new'`, `r.new_position ASC NULLS LAST, r.created_at ASC`)
+}
+
+// countCards counts the user's cards (within the deck, if any) matching where
+// Like getFirstCard, it leaves out cards whose question the user can no longer see
+func countCards(userID string, deck *models.Deck, where string) (int, error) {
+	scope, args := deckCondition(deck, "q", []interface{}{userID})
 	query := `
-		SELECT 
-			r.id, r.user_id, r.question_id, r.card_state, r.quality,
-			r.easiness_factor, r.interval_days, r.interval_minutes,
-			r.current_step, r.repetitions, r.next_review_at,
-			r.last_reviewed_at, r.total_reviews, r.total_lapses, r.created_at,
-			q.id, q.leetcode_id, q.title, q.slug, q.difficulty,
-			q.description_markdown, q.topics, q.created_at
+		SELECT COUNT(*)
 		FROM reviews r
 		JOIN questions q ON r.question_id = q.id
 		WHERE r.user_id = $1
-		AND r.card_state = 'new'
-		ORDER BY r.created_at ASC
-		LIMIT 1
-	`
+		AND ` + where + `
+		AND ` + questionVisibleTo("q", 1) + scope
 
-	var card models.Card
-	var topics pq.StringArray
-	var quality sql.NullInt32
-	var lastReviewedAt sql.NullTime
-
-	err := DB.QueryRow(query, userID).Scan(
-		&card.Review.ID, &card.Review.UserID, &card.Review.QuestionID,
-		&card.Review.CardState, &quality, &card.Review.EasinessFactor,
-		&card.Review.IntervalDays, &card.Review.IntervalMinutes,
-		&card.Review.CurrentStep, &card.Review.Repetitions,
-		&card.Review.NextReviewAt, &lastReviewedAt,
-		&card.Review.TotalReviews, &card.Review.TotalLapses, &card.Review.CreatedAt,
-		&card.Question.ID, &card.Question.LeetcodeID, &card.Question.Title,
-		&card.Question.Slug, &card.Question.Difficulty,
-		&card.Question.DescriptionMarkdown, &topics,
-		&card.Question.CreatedAt,
-	)
-
-	if err == sql.ErrNoRows {
-		return nil, nil
-	}
-	if err != nil {
-		return nil, err
-	}
-
-	if quality.Valid {
-		q := int(quality.Int32)
-		card.Review.Quality = &q
-	}
-	if lastReviewedAt.Valid {
-		card.Review.LastReviewedAt = &lastReviewedAt.Time
-	}
-	card.Question.Topics = topics
-
-	return &card, nil
+	var count int
+	err := DB.QueryRow(query, args...).Scan(&count)
+	return count, err
 }
 
 // CountNewStateCards counts cards in "new" state
-func CountNewStateCards(userID string) (int, error) {
-	query := `
-		SELECT COUNT(*)
-		FROM reviews
-		WHERE user_id = $1 AND card_state = 'new'
-	`
-	var count int
-	err := DB.QueryRow(query, userID).Scan(&count)
-	return count, err
+func CountNewStateCards(userID string, deck *models.Deck) (int, error) {
+	return countCards(userID, deck, `r.card_state = 'new'`)
 }
 
 // CountReviewsCreatedToday counts how many reviews were created today
 // Used to enforce daily new card limits regardless of queue state
-func CountReviewsCreatedToday(userID string) (int, error) {
-	query := `
-		SELECT COUNT(*)
-		FROM reviews
-		WHERE user_id = $1
-		AND DATE(created_at) = CURRENT_DATE
-	`
-	var count int
-	err := DB.QueryRow(query, userID).Scan(&count)
-	return count, err
+func CountReviewsCreatedToday(userID string, deck *models.Deck) (int, error) {
+	return countCards(userID, deck, `DATE(r.created_at) = CURRENT_DATE`)
 }
 
-// GetNewCardsStudiedToday counts how many new cards the user has studied today
-func GetNewCardsStudiedToday(userID string) (int, error) {
-	query := `
-		SELECT COUNT(*)
-		FROM reviews
-		WHERE user_id = $1
-		AND card_state != 'new'
-		AND DATE(created_at) = CURRENT_DATE
-	`
+// CountReviewsCreatedTodayByDifficulty counts today's new cards per question difficulty
+// Like CountReviewsCreatedToday, this is what per-difficulty quotas are charged against
+func CountReviewsCreatedTodayByDifficulty(userID string) (map[string]int, error) {
+	rows, err := DB.Query(`
+		SELECT q.difficulty, COUNT(*)
+		FROM reviews r
+		JOIN questions q ON r.question_id = q.id
+		WHERE r.user_id = $1 AND DATE(r.created_at) = CURRENT_DATE
+		GROUP BY q.difficulty
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	var count int
-	err := DB.QueryRow(query, userID).Scan(&count)
-	return count, err
+	counts := make(map[string]int)
+	for rows.Next() {
+		var difficulty string
+		var count int
+		if err := rows.Scan(&difficulty, &count); err != nil {
+			return nil, err
+		}
+		counts[difficulty] = count
+	}
+	return counts, rows.Err()
+}
+
+// GetNewCardsStudiedToday counts how many new cards the user has studied today
+func GetNewCardsStudiedToday(userID string, deck *models.Deck) (int, error) {
+	return countCards(userID, deck, `r.card_state != 'new' AND DATE(r.created_at) = CURRENT_DATE`)
 }
 
 // GetNextDueCardTime returns when the next card will be due
-func GetNextDueCardTime(userID string) (*time.Time, error) {
+func GetNextDueCardTime(userID string, deck *models.Deck) (*time.Time, error) {
+	scope, args := deckCondition(deck, "q", []interface{}{userID})
 	query := `
-		SELECT next_review_at
-		FROM reviews
-		WHERE user_id = $1
-		AND next_review_at > NOW()
-		ORDER BY next_review_at ASC
+		SELECT GREATEST(r.next_review_at, COALESCE(r.buried_until, r.next_review_at)) AS due_at
+		FROM reviews r
+		JOIN questions q ON r.question_id = q.id
+		WHERE r.user_id = $1
+		AND GREATEST(r.next_review_at, COALESCE(r.buried_until, r.next_review_at)) > NOW()
+		AND ` + questionVisibleTo("q", 1) + scope + `
+		ORDER BY due_at ASC
 		LIMIT 1
 	`
 
 	var nextTime time.Time
-	err := DB.QueryRow(query, userID).Scan(&nextTime)
+	err := DB.QueryRow(query, args...).Scan(&nextTime)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -382,53 +431,79 @@ func GetNextDueCardTime(userID string) (*time.Time, error) {
 }
 
 // GetDueCountsByType returns counts for learning, review, and new cards
-func GetDueCountsByType(userID string) (*models.DueCounts, error) {
+// ReviewsDue is capped at what's left of today's review allowance (the user's, and the deck's if it has one)
+func GetDueCountsByType(userID string, deck *models.Deck) (*models.DueCounts, error) {
 	counts := &models.DueCounts{}
+	var err error
 
 	// Learning cards due now
-	err := DB.QueryRow(`
-		SELECT COUNT(*)
-		FROM reviews
-		WHERE user_id = $1
-		AND card_state IN ('learning', 'relearning', 'new')
-		AND next_review_at <= NOW()
-	`, userID).Scan(&counts.LearningDue)
+	counts.LearningDue, err = countCards(userID, deck, `
+		r.card_state IN ('learning', 'relearning')
+		AND r.next_review_at <= NOW()
+		AND (r.buried_until IS NULL OR r.buried_until <= NOW())`)
 	if err != nil {
 		return nil, err
 	}
 
 	// Review cards due today
-	err = DB.QueryRow(`
-		SELECT COUNT(*)
-		FROM reviews
-		WHERE user_id = $1
-		AND card_state = 'review'
-		AND next_review_at <= NOW()
-	`, userID).Scan(&counts.ReviewsDue)
+	counts.ReviewsDue, err = countCards(userID, deck, `
+		r.card_state = 'review'
+		AND r.next_review_at <= NOW()
+		AND (r.buried_until IS NULL OR r.buried_until <= NOW())`)
 	if err != nil {
 		return nil, err
 	}
 
 	// New cards available (cards in "new" state)
-	err = DB.QueryRow(`
-		SELECT COUNT(*)
-		FROM reviews
-		WHERE user_id = $1 AND card_state = 'new'
-	`, userID).Scan(&counts.NewAvailable)
+	counts.NewAvailable, err = countCards(userID, deck, `
+		r.card_state = 'new'
+		AND (r.buried_until IS NULL OR r.buried_until <= NOW())`)
 	if err != nil {
 		return nil, err
 	}
 
 	// New cards studied today
-	newToday, err := GetNewCardsStudiedToday(userID)
+	counts.NewStudiedToday, err = GetNewCardsStudiedToday(userID, deck)
 	if err != nil {
 		return nil, err
 	}
-	counts.NewStudiedToday = newToday
+
+	left, err := ReviewsLeftToday(userID, deck)
+	if err != nil {
+		return nil, err
+	}
+	if counts.ReviewsDue > left {
+		counts.ReviewsDue = left
+		counts.ReviewLimitReached = true
+	}
 
 	return counts, nil
 }
 
+// ReviewsLeftToday is how many more review cards may be shown today under the user's daily cap
+// and, with a deck, the deck's own cap
+func ReviewsLeftToday(userID string, deck *models.Deck) (int, error) {
+	stats, err := GetUserStats(userID)
+	if err != nil {
+		return 0, err
+	}
+	done, err := CountReviewsDoneToday(userID, nil)
+	if err != nil {
+		return 0, err
+	}
+	left := stats.ReviewsLimit - done
+
+	if deck != nil && deck.ReviewsPerDay != nil {
+		deckDone, err := CountReviewsDoneToday(userID, deck)
+		if err != nil {
+			return 0, err
+		}
+		left = min(left, *deck.ReviewsPerDay-deckDone)
+	}
+
+	return max(left, 0), nil
+}
+
 // GetTodayStats returns today's study statistics
 func GetTodayStats(userID string) (*models.TodayStats, error) {
 	stats := &models.TodayStats{}
@@ -445,7 +520,7 @@ func GetTodayStats(userID string) (*models.TodayStats, error) {
 	}
 
 	// New cards studied today
-	newToday, err := GetNewCardsStudiedToday(userID)
+	newToday, err := GetNewCardsStudiedToday(userID, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -530,8 +605,46 @@ func GetNextCard(userID string) (*models.Card, error) {
 	return &card, nil
 }
 
-// GetNewCard retrieves a random new question not yet reviewed by the user
-func GetNewCard(userID string) (*models.Question, error) {
+// newGatherOrderBy maps a new card gather order to an ORDER BY clause over questions q
+// position is the item's place in its list ("" outside of lists)
+func newGatherOrderBy(order, position string) string {
+	difficultyRank := `CASE q.difficulty WHEN 'Easy' THEN 1 WHEN 'Medium' THEN 2 ELSE 3 END`
+	switch {
+	case order == "random" || (order == "list" && position == ""):
+		return `RANDOM()`
+	case order == "difficulty" && position == "":
+		return difficultyRank + `, RANDOM()`
+	case order == "difficulty":
+		return difficultyRank + `, ` + position
+	default:
+		return position
+	}
+}
+
+// GetNewCard retrieves the next new question not yet reviewed by the user, in the user's gather order
+// Subscribed lists are drawn first; once they're exhausted (or with none), the rest of the pool.
+// A list deck draws from its own list; other decks from their questions only.
+func GetNewCard(userID string, deck *models.Deck, opts NewCardOptions) (*models.Question, error) {
+	if deck == nil {
+		listCard, err := GetNextListCard(userID, opts)
+		if err != nil {
+			return nil, err
+		}
+		if listCard != nil {
+			return listCard, nil
+		}
+	}
+
+	scope, args := deckCondition(deck, "q", []interface{}{userID})
+	conds, args := newCardConditions(opts, deck, "q", args)
+	scope += conds
+
+	position := ""
+	if deck != nil && deck.ListID != nil {
+		args = append(args, *deck.ListID)
+		position = fmt.Sprintf(`(SELECT MIN(li.position) FROM list_items li WHERE li.list_id = $%d AND li.leetcode_id = q.leetcode_id)`, len(args))
+	}
+
 	query := `
 		SELECT q.id, q.leetcode_id, q.title, q.slug, q.difficulty,
 		       q.description_markdown, q.topics, q.created_at
@@ -540,14 +653,15 @@ func GetNewCard(userID string) (*models.Question, error) {
 			SELECT 1 FROM reviews r 
 			WHERE r.user_id = $1 AND r.question_id = q.id
 		)
-		ORDER BY RANDOM()
+		AND ` + questionVisibleTo("q", 1) + scope + `
+		ORDER BY ` + newGatherOrderBy(opts.Order, position) + `
 		LIMIT 1
 	`
 
 	var q models.Question
 	var topics pq.StringArray
 
-	err := DB.QueryRow(query, userID).Scan(
+	err := DB.QueryRow(query, args...).Scan(
 		&q.ID, &q.LeetcodeID, &q.Title, &q.Slug, &q.Difficulty,
 		&q.DescriptionMarkdown, &topics, &q.CreatedAt,
 	)
@@ -582,23 +696,36 @@ func GetUserStats(userID string) (*models.UserStats, error) {
 	query := `
 		SELECT user_id, total_cards, new_cards, learning_cards, 
 		       review_cards, mature_cards, new_cards_limit, coins,
-		       current_streak, max_streak, last_streak_date, updated_at
+		       current_streak, max_streak, last_streak_date, preferred_language, transcription_language, bury_siblings,
+		       new_card_position, review_sort, new_gather_order, reviews_limit, enforce_prerequisites, new_difficulty_quotas, updated_at
 		FROM user_stats
 		WHERE user_id = $1
 	`
 
 	var stats models.UserStats
 	var lastStreakDate sql.NullTime
+	var reviewsLimit sql.NullInt64
+	var quotasJSON []byte
 	err := DB.QueryRow(query, userID).Scan(
 		&stats.UserID, &stats.TotalCards, &stats.NewCards,
 		&stats.LearningCards, &stats.ReviewCards, &stats.MatureCards,
 		&stats.NewCardsLimit, &stats.Coins,
-		&stats.CurrentStreak, &stats.MaxStreak, &lastStreakDate, &stats.UpdatedAt,
+		&stats.CurrentStreak, &stats.MaxStreak, &lastStreakDate, &stats.PreferredLanguage, &stats.TranscriptionLanguage, &stats.BurySiblings,
+		&stats.NewCardPosition, &stats.ReviewSort, &stats.NewGatherOrder, &reviewsLimit, &stats.EnforcePrerequisites, &quotasJSON, &stats.UpdatedAt,
 	)
 
 	if lastStreakDate.Valid {
 		stats.LastStreakDate = &lastStreakDate.Time
 	}
+	stats.ReviewsLimit = config.AppConfig.ReviewsPerDay
+	if reviewsLimit.Valid {
+		stats.ReviewsLimit = int(reviewsLimit.Int64)
+	}
+	if len(quotasJSON) > 0 {
+		if err := jsonUnmarshal(quotasJSON, &stats.NewDifficultyQuotas); err != nil {
+			return nil, err
+		}
+	}
 
 	if err == sql.ErrNoRows {
 		// Create initial stats
@@ -617,21 +744,34 @@ func CreateUserStats(userID string) (*models.UserStats, error) {
 	query := `
 		INSERT INTO user_stats (user_id, total_cards, new_cards, learning_cards, review_cards, mature_cards, new_cards_limit, coins, current_streak, max_streak)
 		VALUES ($1, 0, 0, 0, 0, 0, 5, 0, 0, 0)
-		RETURNING user_id, total_cards, new_cards, learning_cards, review_cards, mature_cards, new_cards_limit, coins, current_streak, max_streak, last_streak_date, updated_at
+		RETURNING user_id, total_cards, new_cards, learning_cards, review_cards, mature_cards, new_cards_limit, coins, current_streak, max_streak, last_streak_date, preferred_language, transcription_language, bury_siblings,
+		       new_card_position, review_sort, new_gather_order, reviews_limit, enforce_prerequisites, new_difficulty_quotas, updated_at
 	`
 
 	var stats models.UserStats
 	var lastStreakDate sql.NullTime
+	var reviewsLimit sql.NullInt64
+	var quotasJSON []byte
 	err := DB.QueryRow(query, userID).Scan(
 		&stats.UserID, &stats.TotalCards, &stats.NewCards,
 		&stats.LearningCards, &stats.ReviewCards, &stats.MatureCards,
 		&stats.NewCardsLimit, &stats.Coins,
-		&stats.CurrentStreak, &stats.MaxStreak, &lastStreakDate, &stats.UpdatedAt,
+		&stats.CurrentStreak, &stats.MaxStreak, &lastStreakDate, &stats.PreferredLanguage, &stats.TranscriptionLanguage, &stats.BurySiblings,
+		&stats.NewCardPosition, &stats.ReviewSort, &stats.NewGatherOrder, &reviewsLimit, &stats.EnforcePrerequisites, &quotasJSON, &stats.UpdatedAt,
 	)
 
 	if lastStreakDate.Valid {
 		stats.LastStreakDate = &lastStreakDate.Time
 	}
+	stats.ReviewsLimit = config.AppConfig.ReviewsPerDay
+	if reviewsLimit.Valid {
+		stats.ReviewsLimit = int(reviewsLimit.Int64)
+	}
+	if len(quotasJSON) > 0 {
+		if err := jsonUnmarshal(quotasJSON, &stats.NewDifficultyQuotas); err != nil {
+			return nil, err
+		}
+	}
 
 	return &stats, err
 }
@@ -708,12 +848,90 @@ func UpdateUserLimit(userID string, limit int) error {
 	return err
 }
 
+// UpdateUserLanguage updates the language used for reference implementations
+func UpdateUserLanguage(userID, language string) error {
+	query := `
+		UPDATE user_stats
+		SET preferred_language = $2, updated_at = NOW()
+		WHERE user_id = $1
+	`
+	// Ensure stats exist first
+	if _, err := GetUserStats(userID); err != nil {
+		return err
+	}
+
+	_, err := DB.Exec(query, userID, language)
+	return err
+}
+
+// UpdateUserTranscriptionLanguage updates the speech recognition language for voice answers
+func UpdateUserTranscriptionLanguage(userID, language string) error {
+	query := `
+		UPDATE user_stats
+		SET transcription_language = $2, updated_at = NOW()
+		WHERE user_id = $1
+	`
+	// Ensure stats exist first
+	if _, err := GetUserStats(userID); err != nil {
+		return err
+	}
+
+	_, err := DB.Exec(query, userID, language)
+	return err
+}
+
+// UpdateUserNewDifficultyQuotas replaces the user's per-difficulty new card quotas (nil or empty removes them)
+func UpdateUserNewDifficultyQuotas(userID string, quotas map[string]int) error {
+	if len(quotas) == 0 {
+		quotas = nil // Stored as NULL
+	}
+	quotasJSON, err := jsonMarshal(quotas)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE user_stats
+		SET new_difficulty_quotas = $2, updated_at = NOW()
+		WHERE user_id = $1
+	`
+	// Ensure stats exist first
+	if _, err := GetUserStats(userID); err != nil {
+		return err
+	}
+
+	_, err = DB.Exec(query, userID, nullableJSON(quotasJSON))
+	return err
+}
+
+// UpdateUserQueueSettings changes the user's queue ordering and review cap; nil arguments are left as they are
+// resetReviewsLimit clears the user's cap so config.ReviewsPerDay applies again
+func UpdateUserQueueSettings(userID string, newCardPosition, reviewSort, newGatherOrder *string, reviewsLimit *int, resetReviewsLimit bool) error {
+	query := `
+		UPDATE user_stats
+		SET new_card_position = COALESCE($2, new_card_position),
+		    review_sort = COALESCE($3, review_sort),
+		    new_gather_order = COALESCE($4, new_gather_order),
+		    reviews_limit = CASE WHEN $6 THEN NULL ELSE COALESCE($5, reviews_limit) END,
+		    updated_at = NOW()
+		WHERE user_id = $1
+	`
+	// Ensure stats exist first
+	if _, err := GetUserStats(userID); err != nil {
+		return err
+	}
+
+	_, err := DB.Exec(query, userID, newCardPosition, reviewSort, newGatherOrder, reviewsLimit, resetReviewsLimit)
+	return err
+}
+
 // GetUnusedProblemCount counts problems not yet reviewed by user
 func GetUnusedProblemCount(userID string) (int, error) {
 	query := `
 		SELECT COUNT(*)
 		FROM questions q
-		WHERE NOT EXISTS (
+		WHERE q.owner_id IS NULL -- Custom questions don't stand in for the LeetCode pool
+		AND NOT EXISTS (
 			SELECT 1 FROM reviews r
 			WHERE r.user_id = $1 AND r.question_id = q.id
 		)
@@ -763,9 +981,10 @@ func CreateHistory(history *models.History) error {
 			user_id, question_id, user_answer, submitted_at,
 			score, feedback, correct_approach,
 			sub_scores, solution_breakdown,
-			next_review_at, card_state, interval_minutes, interval_days, time_spent_seconds
+			next_review_at, card_state, interval_minutes, interval_days, time_spent_seconds,
+			transcription_id, communication_scores, delivery_feedback, session_id, practice, pre_review
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
 		RETURNING id, created_at
 	`
 
@@ -780,6 +999,21 @@ func CreateHistory(history *models.History) error {
 		return err
 	}
 
+	communicationScoresJSON, err := jsonMarshal(history.CommunicationScores)
+	if err != nil {
+		return err
+	}
+
+	preReviewJSON, err := jsonMarshal(history.PreReview)
+	if err != nil {
+		return err
+	}
+
+	var transcriptionID sql.NullString
+	if history.Transcription != nil {
+		transcriptionID = sql.NullString{String: history.Transcription.ID, Valid: true}
+	}
+
 	return DB.QueryRow(
 		query,
 		history.UserID,
@@ -796,6 +1030,12 @@ func CreateHistory(history *models.History) error {
 		history.IntervalMinutes,
 		history.IntervalDays,
 		history.TimeSpentSeconds,
+		transcriptionID,
+		communicationScoresJSON,
+		history.DeliveryFeedback,
+		history.SessionID,
+		history.Practice,
+		nullableJSON(preReviewJSON),
 	).Scan(&history.ID, &history.CreatedAt)
 }
 
@@ -809,9 +1049,12 @@ func GetHistoryByUser(userID string, limit, offset int, difficulties []string, m
 			h.sub_scores, h.solution_breakdown,
 			h.next_review_at, h.card_state, h.interval_minutes, h.interval_days,
 			h.time_spent_seconds, h.created_at,
-			q.title, q.leetcode_id, q.difficulty
+			q.title, q.leetcode_id, q.difficulty,
+			t.id, t.raw_text, t.enhanced_text, t.language, t.backend, t.duration_seconds, t.speech_metrics,
+			h.communication_scores, h.delivery_feedback, h.user_rating, h.rated_at
 		FROM history h
 		JOIN questions q ON h.question_id = q.id
+		LEFT JOIN transcriptions t ON h.transcription_id = t.id
 		WHERE h.user_id = $1
 	`
 
@@ -862,7 +1105,10 @@ func GetHistoryByUser(userID string, limit, offset int, difficulties []string, m
 	var histories []models.History
 	for rows.Next() {
 		var h models.History
-		var subScoresJSON, solutionBreakdownJSON []byte
+		var subScoresJSON, solutionBreakdownJSON, communicationScoresJSON []byte
+		var t nullableTranscription
+		var userRating sql.NullInt32
+		var ratedAt sql.NullTime
 
 		err := rows.Scan(
 			&h.ID, &h.UserID, &h.QuestionID, &h.UserAnswer, &h.SubmittedAt,
@@ -871,6 +1117,8 @@ func GetHistoryByUser(userID string, limit, offset int, difficulties []string, m
 			&h.NextReviewAt, &h.CardState, &h.IntervalMinutes, &h.IntervalDays,
 			&h.TimeSpentSeconds, &h.CreatedAt,
 			&h.QuestionTitle, &h.QuestionLeetcodeID, &h.QuestionDifficulty,
+			&t.ID, &t.RawText, &t.EnhancedText, &t.Language, &t.Backend, &t.DurationSeconds, &t.MetricsJSON,
+			&communicationScoresJSON, &h.DeliveryFeedback, &userRating, &ratedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -883,6 +1131,11 @@ func GetHistoryByUser(userID string, limit, offset int, difficulties []string, m
 		if err := jsonUnmarshal(solutionBreakdownJSON, &h.SolutionBreakdown); err != nil {
 			return nil, err
 		}
+		if err := jsonUnmarshal(communicationScoresJSON, &h.CommunicationScores); err != nil {
+			return nil, err
+		}
+		h.Transcription = t.toModel(h.UserID)
+		h.UserRating, h.RatedAt = nullRating(userRating, ratedAt)
 
 		histories = append(histories, h)
 	}
@@ -899,9 +1152,12 @@ func GetHistoryByQuestion(userID, questionID string) ([]models.History, error) {
 			h.sub_scores, h.solution_breakdown,
 			h.next_review_at, h.card_state, h.interval_minutes, h.interval_days,
 			h.time_spent_seconds, h.created_at,
-			q.title, q.leetcode_id, q.difficulty
+			q.title, q.leetcode_id, q.difficulty,
+			t.id, t.raw_text, t.enhanced_text, t.language, t.backend, t.duration_seconds, t.speech_metrics,
+			h.communication_scores, h.delivery_feedback, h.user_rating, h.rated_at
 		FROM history h
 		JOIN questions q ON h.question_id = q.id
+		LEFT JOIN transcriptions t ON h.transcription_id = t.id
 		WHERE h.user_id = $1 AND h.question_id = $2
 		ORDER BY h.submitted_at DESC
 	`
@@ -915,7 +1171,10 @@ func GetHistoryByQuestion(userID, questionID string) ([]models.History, error) {
 	var histories []models.History
 	for rows.Next() {
 		var h models.History
-		var subScoresJSON, solutionBreakdownJSON []byte
+		var subScoresJSON, solutionBreakdownJSON, communicationScoresJSON []byte
+		var t nullableTranscription
+		var userRating sql.NullInt32
+		var ratedAt sql.NullTime
 
 		err := rows.Scan(
 			&h.ID, &h.UserID, &h.QuestionID, &h.UserAnswer, &h.SubmittedAt,
@@ -924,6 +1183,8 @@ func GetHistoryByQuestion(userID, questionID string) ([]models.History, error) {
 			&h.NextReviewAt, &h.CardState, &h.IntervalMinutes, &h.IntervalDays,
 			&h.TimeSpentSeconds, &h.CreatedAt,
 			&h.QuestionTitle, &h.QuestionLeetcodeID, &h.QuestionDifficulty,
+			&t.ID, &t.RawText, &t.EnhancedText, &t.Language, &t.Backend, &t.DurationSeconds, &t.MetricsJSON,
+			&communicationScoresJSON, &h.DeliveryFeedback, &userRating, &ratedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -936,6 +1197,11 @@ func GetHistoryByQuestion(userID, questionID string) ([]models.History, error) {
 		if err := jsonUnmarshal(solutionBreakdownJSON, &h.SolutionBreakdown); err != nil {
 			return nil, err
 		}
+		if err := jsonUnmarshal(communicationScoresJSON, &h.CommunicationScores); err != nil {
+			return nil, err
+		}
+		h.Transcription = t.toModel(h.UserID)
+		h.UserRating, h.RatedAt = nullRating(userRating, ratedAt)
 
 		histories = append(histories, h)
 	}
@@ -952,16 +1218,22 @@ func GetLatestAttempt(userID, questionID string) (*models.History, error) {
 			h.sub_scores, h.solution_breakdown,
 			h.next_review_at, h.card_state, h.interval_minutes, h.interval_days,
 			h.time_spent_seconds, h.created_at,
-			q.title, q.leetcode_id, q.difficulty
+			q.title, q.leetcode_id, q.difficulty,
+			t.id, t.raw_text, t.enhanced_text, t.language, t.backend, t.duration_seconds, t.speech_metrics,
+			h.communication_scores, h.delivery_feedback, h.user_rating, h.rated_at
 		FROM history h
 		JOIN questions q ON h.question_id = q.id
+		LEFT JOIN transcriptions t ON h.transcription_id = t.id
 		WHERE h.user_id = $1 AND h.question_id = $2
 		ORDER BY h.submitted_at DESC
 		LIMIT 1
 	`
 
 	var h models.History
-	var subScoresJSON, solutionBreakdownJSON []byte
+	var subScoresJSON, solutionBreakdownJSON, communicationScoresJSON []byte
+	var t nullableTranscription
+	var userRating sql.NullInt32
+	var ratedAt sql.NullTime
 
 	err := DB.QueryRow(query, userID, questionID).Scan(
 		&h.ID, &h.UserID, &h.QuestionID, &h.UserAnswer, &h.SubmittedAt,
@@ -970,6 +1242,8 @@ func GetLatestAttempt(userID, questionID string) (*models.History, error) {
 		&h.NextReviewAt, &h.CardState, &h.IntervalMinutes, &h.IntervalDays,
 		&h.TimeSpentSeconds, &h.CreatedAt,
 		&h.QuestionTitle, &h.QuestionLeetcodeID, &h.QuestionDifficulty,
+		&t.ID, &t.RawText, &t.EnhancedText, &t.Language, &t.Backend, &t.DurationSeconds, &t.MetricsJSON,
+		&communicationScoresJSON, &h.DeliveryFeedback, &userRating, &ratedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -986,6 +1260,11 @@ func GetLatestAttempt(userID, questionID string) (*models.History, error) {
 	if err := jsonUnmarshal(solutionBreakdownJSON, &h.SolutionBreakdown); err != nil {
 		return nil, err
 	}
+	if err := jsonUnmarshal(communicationScoresJSON, &h.CommunicationScores); err != nil {
+		return nil, err
+	}
+	h.Transcription = t.toModel(h.UserID)
+	h.UserRating, h.RatedAt = nullRating(userRating, ratedAt)
 
 	return &h, nil
 }
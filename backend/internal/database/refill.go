@@ -0,0 +1,136 @@
+package database
+
+import (
+	"database/sql"
+	"leetcode-anki/backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// GetUnusedCountsByDifficulty counts questions the user has no card for, per difficulty
+func GetUnusedCountsByDifficulty(userID string) (map[string]int, error) {
+	rows, err := DB.Query(`
+		SELECT q.difficulty, COUNT(*)
+		FROM questions q
+		WHERE q.owner_id IS NULL
+		AND NOT EXISTS (
+			SELECT 1 FROM reviews r
+			WHERE r.user_id = $1 AND r.question_id = q.id
+		)
+		GROUP BY q.difficulty
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[string]int{}
+	for rows.Next() {
+		var difficulty string
+		var count int
+		if err := rows.Scan(&difficulty, &count); err != nil {
+			return nil, err
+		}
+		counts[difficulty] = count
+	}
+	return counts, rows.Err()
+}
+
+// GetUnderCoveredTopics returns the common catalog topics with the fewest unused questions across the users
+// Only topics with at least 20 free algorithm problems count, so niche tags don't crowd out the core ones
+func GetUnderCoveredTopics(userIDs []string, limit int) ([]string, error) {
+	rows, err := DB.Query(`
+		WITH common AS (
+			SELECT t.topic, COUNT(*) AS available
+			FROM problem_catalog pc
+			CROSS JOIN LATERAL unnest(pc.topics) AS t(topic)
+			WHERE NOT pc.paid_only AND pc.category = 'Algorithms'
+			GROUP BY t.topic
+			HAVING COUNT(*) >= 20
+		),
+		unused AS (
+			SELECT t.topic, COUNT(*) AS unused
+			FROM unnest($1::uuid[]) AS u(user_id)
+			CROSS JOIN questions q
+			CROSS JOIN LATERAL unnest(q.topics) AS t(topic)
+			WHERE q.owner_id IS NULL
+			AND NOT EXISTS (
+				SELECT 1 FROM reviews r
+				WHERE r.user_id = u.user_id AND r.question_id = q.id
+			)
+			GROUP BY t.topic
+		)
+		SELECT c.topic
+		FROM common c
+		LEFT JOIN unused un ON un.topic = c.topic
+		ORDER BY COALESCE(un.unused, 0), c.available DESC
+		LIMIT $2
+	`, pq.Array(userIDs), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	topics := []string{}
+	for rows.Next() {
+		var topic string
+		if err := rows.Scan(&topic); err != nil {
+			return nil, err
+		}
+		topics = append(topics, topic)
+	}
+	return topics, rows.Err()
+}
+
+// SaveRefillRun stores a finished refill run
+func SaveRefillRun(run *models.RefillRun) error {
+	requested, err := jsonMarshal(run.Requested)
+	if err != nil {
+		return err
+	}
+
+	return DB.QueryRow(`
+		INSERT INTO refill_runs (source, users, requested, topics, fetched, inserted, updated, unchanged,
+		                         skipped, failed, errors, started_at, finished_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id
+	`, run.Source, run.Users, requested, pq.Array(run.Topics), run.Fetched, run.Inserted, run.Updated, run.Unchanged,
+		run.Skipped, run.Failed, pq.Array(run.Errors), run.StartedAt, run.FinishedAt).Scan(&run.ID)
+}
+
+// GetRecentRefillRuns returns the latest refill runs, newest first
+func GetRecentRefillRuns(limit int) ([]models.RefillRun, error) {
+	rows, err := DB.Query(`
+		SELECT id, source, users, requested, topics, fetched, inserted, updated, unchanged,
+		       skipped, failed, errors, started_at, finished_at
+		FROM refill_runs
+		ORDER BY started_at DESC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := []models.RefillRun{}
+	for rows.Next() {
+		var run models.RefillRun
+		var requested []byte
+		var topics, errs pq.StringArray
+		var finishedAt sql.NullTime
+		if err := rows.Scan(&run.ID, &run.Source, &run.Users, &requested, &topics, &run.Fetched, &run.Inserted,
+			&run.Updated, &run.Unchanged, &run.Skipped, &run.Failed, &errs, &run.StartedAt, &finishedAt); err != nil {
+			return nil, err
+		}
+		if err := jsonUnmarshal(requested, &run.Requested); err != nil {
+			return nil, err
+		}
+		run.Topics = topics
+		run.Errors = errs
+		if finishedAt.Valid {
+			run.FinishedAt = &finishedAt.Time
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
@@ -0,0 +1,132 @@
+package database
+
+import (
+	"database/sql"
+	"leetcode-anki/backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// GetRelatedQuestions returns questions linked to questionID by similarity or shared pattern
+// Each question appears once with every kind of relation it has
+func GetRelatedQuestions(userID, questionID string) ([]models.RelatedQuestion, error) {
+	query := `
+		SELECT q.id, q.leetcode_id, q.title, q.slug, q.difficulty,
+		       q.solution_breakdown->>'pattern',
+		       array_agg(DISTINCT rel.kind ORDER BY rel.kind),
+		       r.card_state
+		FROM question_relations rel
+		JOIN questions q ON q.id = rel.related_id
+		LEFT JOIN reviews r ON r.question_id = q.id AND r.user_id = $1
+		WHERE rel.question_id = $2 AND ` + questionVisibleTo("q", 1) + `
+		GROUP BY q.id, r.card_state
+		ORDER BY COUNT(DISTINCT rel.kind) DESC, q.leetcode_id
+	`
+
+	rows, err := DB.Query(query, userID, questionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	related := []models.RelatedQuestion{}
+	for rows.Next() {
+		var rq models.RelatedQuestion
+		var pattern, cardState sql.NullString
+		var kinds pq.StringArray
+
+		err := rows.Scan(
+			&rq.QuestionID, &rq.LeetcodeID, &rq.Title, &rq.Slug, &rq.Difficulty,
+			&pattern, &kinds, &cardState,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		rq.Pattern = pattern.String
+		rq.Relations = kinds
+		rq.CardState = nullStringPtr(cardState)
+		related = append(related, rq)
+	}
+
+	return related, rows.Err()
+}
+
+// maxBuriedSiblings caps how many cards one answer can bury
+const maxBuriedSiblings = 5
+
+// BuryRelatedCards hides the user's new and review cards similar to questionID until tomorrow
+// Only LeetCode's similar-question links count: a broad shared pattern ("Dynamic Programming")
+// would bury whole swaths of the deck. At most maxBuriedSiblings cards are buried, soonest due first.
+// Learning cards and cards already buried are left alone; returns the question IDs it buried
+func BuryRelatedCards(userID, questionID string) ([]string, error) {
+	query := `
+		UPDATE reviews
+		SET buried_until = (CURRENT_DATE + 1)::timestamptz
+		WHERE user_id = $1
+		AND question_id IN (
+			SELECT r.question_id
+			FROM reviews r
+			JOIN question_relations rel ON rel.related_id = r.question_id
+			WHERE r.user_id = $1
+			AND rel.question_id = $2 AND rel.kind = 'similar'
+			AND r.card_state IN ('new', 'review')
+			AND (r.buried_until IS NULL OR r.buried_until <= NOW())
+			ORDER BY r.next_review_at, r.question_id
+			LIMIT $3
+		)
+		RETURNING question_id
+	`
+
+	rows, err := DB.Query(query, userID, questionID, maxBuriedSiblings)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var buried []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		buried = append(buried, id)
+	}
+	return buried, rows.Err()
+}
+
+// BuryIfSiblingStudiedToday buries a freshly drawn card when a similar question was already answered today
+func BuryIfSiblingStudiedToday(userID, questionID string) error {
+	query := `
+		UPDATE reviews
+		SET buried_until = (CURRENT_DATE + 1)::timestamptz
+		WHERE user_id = $1
+		AND question_id = $2
+		AND EXISTS (
+			SELECT 1
+			FROM question_relations rel
+			JOIN reviews sibling ON sibling.question_id = rel.related_id AND sibling.user_id = $1
+			WHERE rel.question_id = $2 AND rel.kind = 'similar'
+			AND DATE(sibling.last_reviewed_at) = CURRENT_DATE
+		)
+	`
+
+	_, err := DB.Exec(query, userID, questionID)
+	return err
+}
+
+// UpdateUserBurySiblings toggles sibling burying for the user
+func UpdateUserBurySiblings(userID string, enabled bool) error {
+	query := `
+		UPDATE user_stats
+		SET bury_siblings = $2, updated_at = NOW()
+		WHERE user_id = $1
+	`
+	// Ensure stats exist first
+	if _, err := GetUserStats(userID); err != nil {
+		return err
+	}
+
+	_, err := DB.Exec(query, userID, enabled)
+	return err
+}
@@ -0,0 +1,136 @@
+package database
+
+import (
+	"leetcode-anki/backend/internal/models"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// MaxRebalanceDays bounds how far ahead a backlog can be spread
+const MaxRebalanceDays = 30
+
+// RebalanceBacklog spreads the user's overdue review cards evenly over today and the next days-1 days
+// Cards with the lowest predicted recall stay due today; the rest move to the start of later days,
+// each logged as a "rebalance" event. Limited to the deck if one is given.
+func RebalanceBacklog(userID string, deck *models.Deck, days int) (*models.RebalanceResult, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	scope, args := deckCondition(deck, "q", []interface{}{userID})
+	overdue, err := queryReviews(tx,
+		`r.user_id = $1 AND r.card_state = 'review' AND r.next_review_at <= NOW()`+scope,
+		retrievabilitySQL+` ASC, r.next_review_at`, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.RebalanceResult{Overdue: len(overdue), Days: days, PerDay: make([]int, days)}
+	if len(overdue) == 0 {
+		return result, nil
+	}
+
+	dayStarts, err := upcomingDayStarts(tx, days)
+	if err != nil {
+		return nil, err
+	}
+
+	result.PerDay = spreadBacklog(len(overdue), days)
+	next := result.PerDay[0]
+	for day := 1; day < days; day++ {
+		for _, review := range overdue[next : next+result.PerDay[day]] {
+			before := *review
+			review.NextReviewAt = dayStarts[day]
+			if err := updateReview(tx, review); err != nil {
+				return nil, err
+			}
+			if err := logReviewEvent(tx, NewReviewLogEntry("rebalance", &before, review)); err != nil {
+				return nil, err
+			}
+			result.Rescheduled++
+		}
+		next += result.PerDay[day]
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// upcomingDayStarts returns the start of today and the following days-1 days
+// by the database clock, which due dates and burying are measured against
+func upcomingDayStarts(db querier, days int) ([]time.Time, error) {
+	rows, err := db.Query(`SELECT (CURRENT_DATE + d)::timestamptz FROM generate_series(0, $1 - 1) d ORDER BY d`, days)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	starts := make([]time.Time, 0, days)
+	for rows.Next() {
+		var start time.Time
+		if err := rows.Scan(&start); err != nil {
+			return nil, err
+		}
+		starts = append(starts, start)
+	}
+	return starts, rows.Err()
+}
+
+// spreadBacklog splits count cards over days as evenly as possible, earlier days taking the remainder
+func spreadBacklog(count, days int) []int {
+	perDay := make([]int, days)
+	for day := range perDay {
+		perDay[day] = count / days
+		if day < count%days {
+			perDay[day]++
+		}
+	}
+	return perDay
+}
+
+// MaxBulkCards bounds how many cards one card browser operation can select by ID
+const MaxBulkCards = 500
+
+// GetReviewsByQuestionIDs returns the user's cards for the given questions; questions without one are left out
+func GetReviewsByQuestionIDs(userID string, questionIDs []string) ([]*models.Review, error) {
+	return queryReviews(DB, `r.user_id = $1 AND r.question_id = ANY($2::uuid[])`, `r.created_at`,
+		userID, pq.Array(questionIDs))
+}
+
+// GetStudiedReviews returns the user's cards past "new" within the selection, a deck-shaped filter
+// (list, topics, difficulties) that doesn't need to be saved as a deck
+func GetStudiedReviews(userID string, selection *models.Deck) ([]*models.Review, error) {
+	scope, args := deckCondition(selection, "q", []interface{}{userID})
+	return queryReviews(DB, `r.user_id = $1 AND r.card_state <> 'new'`+scope, `r.created_at`, args...)
+}
+
+// GetNewQueue returns the user's cards in "new" state, in the order they'll be shown
+func GetNewQueue(userID string) ([]*models.Review, error) {
+	return queryReviews(DB, `r.user_id = $1 AND r.card_state = 'new'`, `r.new_position ASC NULLS LAST, r.created_at ASC`, userID)
+}
+
+// SaveCardChanges saves changed cards in one transaction, logging each as event
+// before[i] is the state of after[i] before the change
+func SaveCardChanges(event string, before, after []*models.Review) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for i, review := range after {
+		if err := updateReview(tx, review); err != nil {
+			return err
+		}
+		if err := logReviewEvent(tx, NewReviewLogEntry(event, before[i], review)); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,38 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSpreadBacklog(t *testing.T) {
+	tests := []struct {
+		name        string
+		count, days int
+		want        []int
+	}{
+		{"one day keeps everything today", 7, 1, []int{7}},
+		{"divides evenly", 12, 3, []int{4, 4, 4}},
+		{"remainder goes to the earliest days", 10, 4, []int{3, 3, 2, 2}},
+		{"fewer cards than days", 2, 5, []int{1, 1, 0, 0, 0}},
+		{"nothing overdue", 0, 3, []int{0, 0, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := spreadBacklog(tt.count, tt.days)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("spreadBacklog(%d, %d) = %v, want %v", tt.count, tt.days, got, tt.want)
+			}
+
+			// No day may take more than one card over another
+			total, lo, hi := 0, got[0], got[0]
+			for _, n := range got {
+				total += n
+				lo, hi = min(lo, n), max(hi, n)
+			}
+			if total != tt.count || hi-lo > 1 {
+				t.Errorf("spreadBacklog(%d, %d) = %v isn't an even split", tt.count, tt.days, got)
+			}
+		})
+	}
+}
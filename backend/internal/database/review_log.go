@@ -0,0 +1,122 @@
+package database
+
+import (
+	"database/sql"
+	"leetcode-anki/backend/internal/models"
+	"time"
+)
+
+// MaxReviewLogPage bounds how many log entries one export request returns
+const MaxReviewLogPage = 5000
+
+// NewReviewLogEntry describes a change of the card from before to after
+// A nil before is a card that didn't exist yet (state "new"). Rating, HistoryID and UndoesID are left to the caller.
+func NewReviewLogEntry(event string, before, after *models.Review) *models.ReviewLogEntry {
+	e := &models.ReviewLogEntry{
+		UserID:        after.UserID,
+		QuestionID:    after.QuestionID,
+		Event:         event,
+		StateBefore:   "new",
+		StateAfter:    after.CardState,
+		IntervalAfter: after.IntervalMinutes,
+	}
+
+	easeAfter := after.EasinessFactor
+	dueAfter := after.NextReviewAt
+	e.EaseAfter = &easeAfter
+	e.DueAfter = &dueAfter
+
+	if before != nil {
+		easeBefore := before.EasinessFactor
+		dueBefore := before.NextReviewAt
+		e.StateBefore = before.CardState
+		e.IntervalBefore = before.IntervalMinutes
+		e.EaseBefore = &easeBefore
+		e.DueBefore = &dueBefore
+		if before.LastReviewedAt != nil {
+			elapsed := time.Since(*before.LastReviewedAt).Hours() / 24
+			e.ElapsedDays = &elapsed
+		}
+	}
+
+	return e
+}
+
+// LogReviewEvent appends an entry to the review log, filling in its ID, Seq and CreatedAt
+func LogReviewEvent(e *models.ReviewLogEntry) error {
+	return logReviewEvent(DB, e)
+}
+
+func logReviewEvent(db queryRower, e *models.ReviewLogEntry) error {
+	return db.QueryRow(`
+		INSERT INTO review_log (user_id, question_id, event, rating, state_before, state_after,
+		                        interval_before, interval_after, ease_before, ease_after,
+		                        due_before, due_after, elapsed_days, history_id, undoes_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		RETURNING id, seq, created_at
+	`, e.UserID, e.QuestionID, e.Event, e.Rating, e.StateBefore, e.StateAfter,
+		e.IntervalBefore, e.IntervalAfter, e.EaseBefore, e.EaseAfter,
+		e.DueBefore, e.DueAfter, e.ElapsedDays, e.HistoryID, e.UndoesID,
+	).Scan(&e.ID, &e.Seq, &e.CreatedAt)
+}
+
+// GetReviewLog returns the user's log entries with Seq above after, created after since (if not nil),
+// in the order they were written
+func GetReviewLog(userID string, since *time.Time, after int64, limit int) ([]models.ReviewLogEntry, error) {
+	rows, err := DB.Query(`
+		SELECT id, seq, user_id, question_id, event, rating, state_before, state_after,
+		       interval_before, interval_after, ease_before, ease_after,
+		       due_before, due_after, elapsed_days, history_id, undoes_id, created_at
+		FROM review_log
+		WHERE user_id = $1 AND ($2::timestamptz IS NULL OR created_at > $2) AND seq > $3
+		ORDER BY seq
+		LIMIT $4
+	`, userID, since, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.ReviewLogEntry{}
+	for rows.Next() {
+		var e models.ReviewLogEntry
+		var rating sql.NullInt32
+		var easeBefore, easeAfter, elapsedDays sql.NullFloat64
+		var dueBefore, dueAfter sql.NullTime
+		var historyID, undoesID sql.NullString
+
+		err := rows.Scan(
+			&e.ID, &e.Seq, &e.UserID, &e.QuestionID, &e.Event, &rating, &e.StateBefore, &e.StateAfter,
+			&e.IntervalBefore, &e.IntervalAfter, &easeBefore, &easeAfter,
+			&dueBefore, &dueAfter, &elapsedDays, &historyID, &undoesID, &e.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if rating.Valid {
+			r := int(rating.Int32)
+			e.Rating = &r
+		}
+		if easeBefore.Valid {
+			e.EaseBefore = &easeBefore.Float64
+		}
+		if easeAfter.Valid {
+			e.EaseAfter = &easeAfter.Float64
+		}
+		if elapsedDays.Valid {
+			e.ElapsedDays = &elapsedDays.Float64
+		}
+		if dueBefore.Valid {
+			e.DueBefore = &dueBefore.Time
+		}
+		if dueAfter.Valid {
+			e.DueAfter = &dueAfter.Time
+		}
+		e.HistoryID = nullStringPtr(historyID)
+		e.UndoesID = nullStringPtr(undoesID)
+
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
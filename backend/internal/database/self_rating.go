@@ -0,0 +1,188 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"leetcode-anki/backend/internal/models"
+	"time"
+)
+
+// ErrRatingStale is returned when the card was rescheduled again after the answer being rated
+var ErrRatingStale = errors.New("card has been rescheduled since this answer")
+
+// nullRating converts the nullable self-rating columns of a history row
+func nullRating(rating sql.NullInt32, ratedAt sql.NullTime) (*int, *time.Time) {
+	if !rating.Valid {
+		return nil, nil
+	}
+	r := int(rating.Int32)
+	var at *time.Time
+	if ratedAt.Valid {
+		at = &ratedAt.Time
+	}
+	return &r, at
+}
+
+// GetHistoryForRating returns one of the user's attempts with the card as it was before it (nil if not found)
+// PreReview is nil for practice answers and attempts saved before self-rating existed
+func GetHistoryForRating(userID, historyID string) (*models.History, error) {
+	var h models.History
+	var preReviewJSON []byte
+	var userRating sql.NullInt32
+	var ratedAt sql.NullTime
+
+	err := DB.QueryRow(`
+		SELECT id, user_id, question_id, submitted_at, score, practice,
+		       next_review_at, card_state, interval_minutes, interval_days,
+		       pre_review, user_rating, rated_at
+		FROM history
+		WHERE id = $1 AND user_id = $2
+	`, historyID, userID).Scan(
+		&h.ID, &h.UserID, &h.QuestionID, &h.SubmittedAt, &h.Score, &h.Practice,
+		&h.NextReviewAt, &h.CardState, &h.IntervalMinutes, &h.IntervalDays,
+		&preReviewJSON, &userRating, &ratedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(preReviewJSON) > 0 {
+		h.PreReview = &models.Review{}
+		if err := jsonUnmarshal(preReviewJSON, h.PreReview); err != nil {
+			return nil, err
+		}
+	}
+	h.UserRating, h.RatedAt = nullRating(userRating, ratedAt)
+
+	return &h, nil
+}
+
+// ApplySelfRating saves the card as rescheduled from the attempt's pre-answer state with the user's rating
+// The card must still be where the attempt left it (ErrRatingStale otherwise). The attempt keeps the
+// grader's score next to the rating, the review log gets a "rate" entry replacing the earlier one,
+// and coinsDelta settles the coins the new rating earns (never below zero).
+func ApplySelfRating(h *models.History, rescheduled *models.Review, rating, coinsDelta int) (*models.ReviewLogEntry, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	current, err := getReview(tx, h.UserID, h.QuestionID)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil || current.CardState != h.CardState || !current.NextReviewAt.Equal(h.NextReviewAt) {
+		return nil, ErrRatingStale
+	}
+
+	if err := updateReview(tx, rescheduled); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE history
+		SET user_rating = $2, rated_at = NOW(),
+		    next_review_at = $3, card_state = $4, interval_minutes = $5, interval_days = $6
+		WHERE id = $1
+	`, h.ID, rating, rescheduled.NextReviewAt, rescheduled.CardState, rescheduled.IntervalMinutes, rescheduled.IntervalDays)
+	if err != nil {
+		return nil, err
+	}
+
+	if coinsDelta != 0 {
+		_, err = tx.Exec(`
+			UPDATE user_stats SET coins = GREATEST(coins + $2, 0), updated_at = NOW()
+			WHERE user_id = $1
+		`, h.UserID, coinsDelta)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var replaced sql.NullString
+	err = tx.QueryRow(`
+		SELECT l.id FROM review_log l
+		WHERE l.history_id = $1 AND l.event IN ('submit', 'rate')
+		AND NOT EXISTS (SELECT 1 FROM review_log u WHERE u.undoes_id = l.id)
+		ORDER BY l.seq DESC
+		LIMIT 1
+	`, h.ID).Scan(&replaced)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	entry := NewReviewLogEntry("rate", h.PreReview, rescheduled)
+	entry.Rating = &rating
+	entry.HistoryID = &h.ID
+	entry.UndoesID = nullStringPtr(replaced)
+	if err := logReviewEvent(tx, entry); err != nil {
+		return nil, err
+	}
+
+	// Undoing the answer now reverts the rated schedule
+	_, err = tx.Exec(`
+		UPDATE undo_actions SET log_id = $2, after_last_reviewed_at = $3, after_next_review_at = $4
+		WHERE history_id = $1
+	`, h.ID, entry.ID, rescheduled.LastReviewedAt, rescheduled.NextReviewAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// GetGraderCalibration summarizes how users' self-ratings differ from the grader's scores
+func GetGraderCalibration() (*models.GraderCalibration, error) {
+	// A grader score below 3 already maps to "again" (1), so rating it "again" isn't an override
+	rows, err := DB.Query(`
+		SELECT score, COUNT(*),
+		       COUNT(*) FILTER (WHERE user_rating <> graded),
+		       COUNT(*) FILTER (WHERE user_rating > graded),
+		       COUNT(*) FILTER (WHERE user_rating < graded),
+		       SUM(user_rating - graded),
+		       AVG(user_rating)
+		FROM (
+			SELECT score, user_rating, CASE WHEN score < 3 THEN 1 ELSE score END AS graded
+			FROM history
+			WHERE user_rating IS NOT NULL
+		) rated
+		GROUP BY score
+		ORDER BY score
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cal := &models.GraderCalibration{ByScore: []models.CalibrationBucket{}}
+	totalDelta := 0
+	for rows.Next() {
+		var b models.CalibrationBucket
+		var raised, lowered, delta int
+		if err := rows.Scan(&b.Score, &b.Rated, &b.Overridden, &raised, &lowered, &delta, &b.MeanRating); err != nil {
+			return nil, err
+		}
+		cal.ByScore = append(cal.ByScore, b)
+		cal.Rated += b.Rated
+		cal.Overridden += b.Overridden
+		cal.Raised += raised
+		cal.Lowered += lowered
+		totalDelta += delta
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if cal.Rated > 0 {
+		cal.OverrideRate = float64(cal.Overridden) / float64(cal.Rated)
+		cal.MeanDelta = float64(totalDelta) / float64(cal.Rated)
+	}
+	return cal, nil
+}
@@ -0,0 +1,273 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"leetcode-anki/backend/internal/models"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// MaxStudySessionCards bounds how many cards one filtered session can pull in
+const MaxStudySessionCards = 200
+
+// selectStudySessionCards returns the IDs of the user's cards matching the query, in session order
+func selectStudySessionCards(tx *sql.Tx, userID string, query models.StudySessionQuery) ([]string, error) {
+	args := []interface{}{userID}
+	next := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	conds := []string{
+		`r.user_id = $1`,
+		// Suspended cards stay out of every session: rescheduling one would unsuspend it
+		`r.card_state <> 'suspended'`,
+		`(r.buried_until IS NULL OR r.buried_until <= NOW())`,
+		questionVisibleTo("q", 1),
+	}
+	if len(query.Topics) > 0 {
+		conds = append(conds, `q.topics && `+next(pq.Array(query.Topics))+`::text[]`)
+	}
+	if len(query.Difficulties) > 0 {
+		conds = append(conds, `q.difficulty = ANY(`+next(pq.Array(query.Difficulties))+`::text[])`)
+	}
+	if len(query.States) > 0 {
+		conds = append(conds, `r.card_state = ANY(`+next(pq.Array(query.States))+`::text[])`)
+	}
+	if query.MinLapses > 0 {
+		conds = append(conds, `r.total_lapses >= `+next(query.MinLapses))
+	}
+	if query.MaxLastScore != nil {
+		conds = append(conds, `last.score <= `+next(*query.MaxLastScore))
+	}
+	if query.FailedWithinDays > 0 {
+		conds = append(conds, `EXISTS (
+			SELECT 1 FROM history h
+			WHERE h.user_id = r.user_id AND h.question_id = r.question_id
+			AND h.score < 3
+			AND h.submitted_at >= NOW() - make_interval(days => `+next(query.FailedWithinDays)+`)
+		)`)
+	}
+	if query.DueWithinDays != nil {
+		conds = append(conds, `r.next_review_at < CURRENT_DATE + 1 + `+next(*query.DueWithinDays)+`::int`)
+	}
+
+	var orderBy string
+	switch query.Order {
+	case "random":
+		orderBy = `RANDOM()`
+	case "lapses":
+		orderBy = `r.total_lapses DESC, r.next_review_at`
+	case "score":
+		orderBy = `last.score ASC NULLS LAST, r.next_review_at`
+	default:
+		orderBy = `r.next_review_at`
+	}
+
+	limit := query.Limit
+	if limit <= 0 || limit > MaxStudySessionCards {
+		limit = MaxStudySessionCards
+	}
+
+	rows, err := tx.Query(`
+		SELECT r.question_id
+		FROM reviews r
+		JOIN questions q ON q.id = r.question_id
+		LEFT JOIN LATERAL (
+			SELECT h.score FROM history h
+			WHERE h.user_id = r.user_id AND h.question_id = r.question_id
+			ORDER BY h.submitted_at DESC
+			LIMIT 1
+		) last ON true
+		WHERE `+strings.Join(conds, " AND ")+`
+		ORDER BY `+orderBy+`
+		LIMIT `+next(limit),
+		args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// CreateStudySession builds a session from its query, filling in ID, CardCount and CreatedAt
+// Nothing is saved when no card matches (CardCount stays 0)
+func CreateStudySession(s *models.StudySession) error {
+	queryJSON, err := jsonMarshal(s.Query)
+	if err != nil {
+		return err
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	ids, err := selectStudySessionCards(tx, s.UserID, s.Query)
+	if err != nil {
+		return err
+	}
+	s.CardCount = len(ids)
+	if s.CardCount == 0 {
+		return nil
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO study_sessions (user_id, name, query, reschedule)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`, s.UserID, s.Name, queryJSON, s.Reschedule).Scan(&s.ID, &s.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO study_session_cards (session_id, question_id, position)
+		SELECT $1, t.question_id, t.position
+		FROM unnest($2::uuid[]) WITH ORDINALITY AS t(question_id, position)
+	`, s.ID, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+const studySessionColumns = `
+	s.id, s.user_id, s.name, s.query, s.reschedule, s.created_at, s.finished_at,
+	(SELECT COUNT(*) FROM study_session_cards sc WHERE sc.session_id = s.id),
+	(SELECT COUNT(*) FROM study_session_cards sc WHERE sc.session_id = s.id AND sc.answered_at IS NOT NULL)
+`
+
+func scanStudySession(row rowScanner) (*models.StudySession, error) {
+	var s models.StudySession
+	var queryJSON []byte
+	var finishedAt sql.NullTime
+
+	err := row.Scan(&s.ID, &s.UserID, &s.Name, &queryJSON, &s.Reschedule, &s.CreatedAt, &finishedAt,
+		&s.CardCount, &s.Answered)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := jsonUnmarshal(queryJSON, &s.Query); err != nil {
+		return nil, err
+	}
+	if finishedAt.Valid {
+		s.FinishedAt = &finishedAt.Time
+	}
+	return &s, nil
+}
+
+// GetStudySession returns one of the user's sessions with its progress (nil if not found)
+func GetStudySession(userID, sessionID string) (*models.StudySession, error) {
+	row := DB.QueryRow(`SELECT `+studySessionColumns+` FROM study_sessions s WHERE s.id = $1 AND s.user_id = $2`,
+		sessionID, userID)
+	s, err := scanStudySession(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return s, err
+}
+
+// GetStudySessions returns the user's most recent sessions, unfinished ones first
+func GetStudySessions(userID string, limit int) ([]models.StudySession, error) {
+	rows, err := DB.Query(`
+		SELECT `+studySessionColumns+`
+		FROM study_sessions s
+		WHERE s.user_id = $1
+		ORDER BY s.finished_at IS NOT NULL, s.created_at DESC
+		LIMIT $2
+	`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []models.StudySession{}
+	for rows.Next() {
+		s, err := scanStudySession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *s)
+	}
+	return sessions, rows.Err()
+}
+
+// GetNextSessionCard returns the first unanswered card of the session, or nil when it's done
+// Cards whose question the user can no longer see are skipped
+func GetNextSessionCard(userID, sessionID string) (*models.Card, error) {
+	query := `
+		SELECT ` + cardColumns + `
+		FROM study_session_cards sc
+		JOIN reviews r ON r.question_id = sc.question_id AND r.user_id = $1
+		JOIN questions q ON q.id = sc.question_id
+		WHERE sc.session_id = $2 AND sc.answered_at IS NULL
+		AND r.card_state <> 'suspended'
+		AND ` + questionVisibleTo("q", 1) + `
+		ORDER BY sc.position
+		LIMIT 1
+	`
+
+	card, err := scanCard(DB.QueryRow(query, userID, sessionID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return card, err
+}
+
+// IsStudySessionCard reports whether the question is part of the session and not yet answered
+func IsStudySessionCard(sessionID, questionID string) (bool, error) {
+	var ok bool
+	err := DB.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM study_session_cards
+			WHERE session_id = $1 AND question_id = $2 AND answered_at IS NULL
+		)
+	`, sessionID, questionID).Scan(&ok)
+	return ok, err
+}
+
+// MarkStudySessionCardAnswered records the score of a session card; the session finishes with its last card
+func MarkStudySessionCardAnswered(sessionID, questionID string, score int) error {
+	_, err := DB.Exec(`
+		UPDATE study_session_cards SET answered_at = NOW(), score = $3
+		WHERE session_id = $1 AND question_id = $2
+	`, sessionID, questionID, score)
+	if err != nil {
+		return err
+	}
+
+	_, err = DB.Exec(`
+		UPDATE study_sessions SET finished_at = NOW()
+		WHERE id = $1 AND finished_at IS NULL
+		AND NOT EXISTS (SELECT 1 FROM study_session_cards WHERE session_id = $1 AND answered_at IS NULL)
+	`, sessionID)
+	return err
+}
+
+// FinishStudySession ends a session early; returns false if the user has no such unfinished session
+func FinishStudySession(userID, sessionID string) (bool, error) {
+	res, err := DB.Exec(`
+		UPDATE study_sessions SET finished_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND finished_at IS NULL
+	`, sessionID, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
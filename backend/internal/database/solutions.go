@@ -0,0 +1,90 @@
+package database
+
+import (
+	"database/sql"
+	"leetcode-anki/backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// GetQuestionsWithoutSolution returns up to limit (0 = all) shared questions that don't have a cached
+// solution breakdown yet. Custom questions are generated on demand for their owners, not in bulk.
+// Ordered by LeetCode ID so batch runs make predictable progress
+func GetQuestionsWithoutSolution(limit int) ([]models.Question, error) {
+	query := `
+		SELECT id, leetcode_id, title, slug, difficulty,
+		       description_markdown, topics, created_at
+		FROM questions
+		WHERE (solution_breakdown IS NULL OR solution_breakdown = 'null'::jsonb)
+		AND owner_id IS NULL
+		ORDER BY leetcode_id
+		LIMIT $1
+	`
+
+	// LIMIT NULL is no limit
+	var limitArg sql.NullInt64
+	if limit > 0 {
+		limitArg = sql.NullInt64{Int64: int64(limit), Valid: true}
+	}
+
+	rows, err := DB.Query(query, limitArg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var questions []models.Question
+	for rows.Next() {
+		var q models.Question
+		var topics pq.StringArray
+
+		err := rows.Scan(
+			&q.ID, &q.LeetcodeID, &q.Title, &q.Slug, &q.Difficulty,
+			&q.DescriptionMarkdown, &topics, &q.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		q.Topics = topics
+		questions = append(questions, q)
+	}
+
+	return questions, rows.Err()
+}
+
+// CountQuestionsWithoutSolution counts shared questions still missing a cached solution breakdown
+func CountQuestionsWithoutSolution() (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM questions
+		WHERE (solution_breakdown IS NULL OR solution_breakdown = 'null'::jsonb)
+		AND owner_id IS NULL
+	`
+
+	var count int
+	err := DB.QueryRow(query).Scan(&count)
+	return count, err
+}
+
+// UpdateQuestionImplementation caches a reference implementation inside the solution breakdown
+// Merges into the implementations object so concurrent languages don't overwrite each other
+func UpdateQuestionImplementation(questionID, language string, implementation *models.ReferenceImplementation) error {
+	query := `
+		UPDATE questions
+		SET solution_breakdown = solution_breakdown || jsonb_build_object(
+			'implementations',
+			COALESCE(solution_breakdown->'implementations', '{}'::jsonb) || jsonb_build_object($2::text, $3::jsonb)
+		)
+		WHERE id = $1
+		AND solution_breakdown IS NOT NULL AND solution_breakdown != 'null'::jsonb
+	`
+
+	implementationJSON, err := jsonMarshal(implementation)
+	if err != nil {
+		return err
+	}
+
+	_, err = DB.Exec(query, questionID, language, implementationJSON)
+	return err
+}
@@ -0,0 +1,99 @@
+package database
+
+import (
+	"leetcode-anki/backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// GetQuestionsBySlugs returns the pool questions matching the slugs, keyed by slug
+func GetQuestionsBySlugs(slugs []string) (map[string]models.Question, error) {
+	rows, err := DB.Query(`
+		SELECT id, leetcode_id, title, slug, difficulty
+		FROM questions
+		WHERE slug = ANY($1) AND owner_id IS NULL
+	`, pq.Array(slugs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	questions := make(map[string]models.Question)
+	for rows.Next() {
+		var q models.Question
+		if err := rows.Scan(&q.ID, &q.LeetcodeID, &q.Title, &q.Slug, &q.Difficulty); err != nil {
+			return nil, err
+		}
+		questions[q.Slug] = q
+	}
+	return questions, rows.Err()
+}
+
+// GetUserCardQuestionIDs returns which of the questions the user already has a card for
+func GetUserCardQuestionIDs(userID string, questionIDs []string) (map[string]bool, error) {
+	rows, err := DB.Query(`
+		SELECT question_id
+		FROM reviews
+		WHERE user_id = $1 AND question_id = ANY($2::uuid[])
+	`, userID, pq.Array(questionIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		existing[id] = true
+	}
+	return existing, rows.Err()
+}
+
+// CreateImportedReviews inserts cards for imported solves in one transaction
+// created_at comes from the review (the solve date), so imports don't count against today's new-card limit;
+// a card the user already has is left untouched. Each insert is logged as an "import" event.
+// Returns how many were inserted.
+func CreateImportedReviews(reviews []*models.Review) (int, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	inserted := 0
+	for _, review := range reviews {
+		var exists bool
+		if err := tx.QueryRow(`
+			SELECT EXISTS (SELECT 1 FROM reviews WHERE user_id = $1 AND question_id = $2)
+		`, review.UserID, review.QuestionID).Scan(&exists); err != nil {
+			return 0, err
+		}
+		if exists {
+			continue
+		}
+
+		err := tx.QueryRow(`
+			INSERT INTO reviews (user_id, question_id, card_state, quality,
+			                     easiness_factor, interval_days, interval_minutes, current_step, repetitions,
+			                     next_review_at, last_reviewed_at, total_reviews, total_lapses, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+			RETURNING id
+		`,
+			review.UserID, review.QuestionID, review.CardState, review.Quality,
+			review.EasinessFactor, review.IntervalDays, review.IntervalMinutes, review.CurrentStep, review.Repetitions,
+			review.NextReviewAt, review.LastReviewedAt, review.TotalReviews, review.TotalLapses, review.CreatedAt,
+		).Scan(&review.ID)
+		if err != nil {
+			return 0, err
+		}
+		if err := logReviewEvent(tx, NewReviewLogEntry("import", nil, review)); err != nil {
+			return 0, err
+		}
+		inserted++
+	}
+
+	return inserted, tx.Commit()
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"database/sql"
+	"leetcode-anki/backend/internal/models"
+)
+
+// CreateTranscription saves a voice transcription so it can be attached to the submitted attempt
+func CreateTranscription(t *models.Transcription) error {
+	query := `
+		INSERT INTO transcriptions (
+			user_id, raw_text, enhanced_text, language, backend, duration_seconds, size_bytes,
+			speech_metrics
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`
+
+	metricsJSON, err := jsonMarshal(t.Metrics)
+	if err != nil {
+		return err
+	}
+
+	return DB.QueryRow(
+		query,
+		t.UserID, t.RawText, t.EnhancedText, t.Language, t.Backend, t.DurationSeconds, t.SizeBytes,
+		metricsJSON,
+	).Scan(&t.ID, &t.CreatedAt)
+}
+
+// GetTranscription retrieves a transcription owned by the user
+func GetTranscription(userID, transcriptionID string) (*models.Transcription, error) {
+	query := `
+		SELECT id, user_id, raw_text, enhanced_text, language, backend,
+		       duration_seconds, size_bytes, speech_metrics, created_at
+		FROM transcriptions
+		WHERE id = $1 AND user_id = $2
+	`
+
+	var t models.Transcription
+	var metricsJSON []byte
+	err := DB.QueryRow(query, transcriptionID, userID).Scan(
+		&t.ID, &t.UserID, &t.RawText, &t.EnhancedText, &t.Language, &t.Backend,
+		&t.DurationSeconds, &t.SizeBytes, &metricsJSON, &t.CreatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := jsonUnmarshal(metricsJSON, &t.Metrics); err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// nullableTranscription scans the LEFT JOINed transcription columns of a history row
+type nullableTranscription struct {
+	ID              sql.NullString
+	RawText         sql.NullString
+	EnhancedText    sql.NullString
+	Language        sql.NullString
+	Backend         sql.NullString
+	DurationSeconds sql.NullFloat64
+	MetricsJSON     []byte
+}
+
+// toModel returns nil for typed answers
+func (t nullableTranscription) toModel(userID string) *models.Transcription {
+	if !t.ID.Valid {
+		return nil
+	}
+	transcription := &models.Transcription{
+		ID:              t.ID.String,
+		UserID:          userID,
+		RawText:         t.RawText.String,
+		EnhancedText:    t.EnhancedText.String,
+		Language:        t.Language.String,
+		Backend:         t.Backend.String,
+		DurationSeconds: t.DurationSeconds.Float64,
+	}
+	// Metrics are informational; a corrupted value shouldn't hide the transcript
+	_ = jsonUnmarshal(t.MetricsJSON, &transcription.Metrics)
+	return transcription
+}
@@ -0,0 +1,220 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"leetcode-anki/backend/internal/models"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ErrUndoStale is returned when the card was rescheduled again after the action being undone
+var ErrUndoStale = errors.New("card has been rescheduled since this action")
+
+// MaxUndoActions is how many answers/skips back a user can undo
+const MaxUndoActions = 5
+
+// UndoSessionGap ends a study sitting: after this long without answering, earlier actions can't be undone
+const UndoSessionGap = 30 * time.Minute
+
+// PushUndoAction records the state from before an answer or skip
+// A long pause since the previous action starts a new sitting, dropping the old stack;
+// otherwise only the newest MaxUndoActions are kept
+func PushUndoAction(a *models.UndoAction) error {
+	snapshotJSON, err := jsonMarshal(a.Review)
+	if err != nil {
+		return err
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		DELETE FROM undo_actions
+		WHERE user_id = $1 AND created_at < NOW() - make_interval(secs => $2)
+	`, a.UserID, UndoSessionGap.Seconds())
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO undo_actions (user_id, question_id, action, review_snapshot, coins,
+		                          current_streak, max_streak, last_streak_date, history_id, session_id, log_id, buried_ids,
+		                          after_last_reviewed_at, after_next_review_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING id, created_at
+	`, a.UserID, a.QuestionID, a.Action, snapshotJSON, a.Coins,
+		a.CurrentStreak, a.MaxStreak, a.LastStreakDate, a.HistoryID, a.SessionID, a.LogID, pq.Array(nonNil(a.BuriedIDs)),
+		a.AfterLastReviewedAt, a.AfterNextReviewAt,
+	).Scan(&a.ID, &a.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		DELETE FROM undo_actions
+		WHERE user_id = $1 AND id NOT IN (
+			SELECT id FROM undo_actions WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2
+		)
+	`, a.UserID, MaxUndoActions)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CountUndoActions returns how many actions the user can still undo
+func CountUndoActions(userID string) (int, error) {
+	var count int
+	err := DB.QueryRow(`
+		SELECT COUNT(*) FROM undo_actions
+		WHERE user_id = $1 AND created_at >= NOW() - make_interval(secs => $2)
+	`, userID, UndoSessionGap.Seconds()).Scan(&count)
+	return count, err
+}
+
+// UndoLastAction puts back the card, coins and streak from before the user's latest answer or skip,
+// unburies the siblings it buried, removes its graded attempt and reopens it in its study session.
+// nil when there's nothing to undo; ErrUndoStale if the card was rescheduled again since the action.
+// The review log keeps the undone entry and gets an "undo" entry pointing at it.
+func UndoLastAction(userID string) (*models.UndoAction, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var a models.UndoAction
+	var snapshotJSON []byte
+	var lastStreakDate, afterLastReviewed, afterNextReview sql.NullTime
+	var historyID, sessionID, logID sql.NullString
+	var buriedIDs pq.StringArray
+
+	err = tx.QueryRow(`
+		SELECT id, user_id, question_id, action, review_snapshot, coins,
+		       current_streak, max_streak, last_streak_date, history_id, session_id, log_id, buried_ids, created_at,
+		       after_last_reviewed_at, after_next_review_at
+		FROM undo_actions
+		WHERE user_id = $1 AND created_at >= NOW() - make_interval(secs => $2)
+		ORDER BY created_at DESC
+		LIMIT 1
+		FOR UPDATE
+	`, userID, UndoSessionGap.Seconds()).Scan(
+		&a.ID, &a.UserID, &a.QuestionID, &a.Action, &snapshotJSON, &a.Coins,
+		&a.CurrentStreak, &a.MaxStreak, &lastStreakDate, &historyID, &sessionID, &logID, &buriedIDs, &a.CreatedAt,
+		&afterLastReviewed, &afterNextReview,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := jsonUnmarshal(snapshotJSON, &a.Review); err != nil {
+		return nil, err
+	}
+	if lastStreakDate.Valid {
+		a.LastStreakDate = &lastStreakDate.Time
+	}
+	a.HistoryID = nullStringPtr(historyID)
+	a.SessionID = nullStringPtr(sessionID)
+	a.LogID = nullStringPtr(logID)
+	a.BuriedIDs = buriedIDs
+	a.AfterLastReviewedAt = nullTimePtr(afterLastReviewed)
+	a.AfterNextReviewAt = nullTimePtr(afterNextReview)
+
+	current, err := getReview(tx, userID, a.QuestionID)
+	if err != nil {
+		return nil, err
+	}
+	if undoStale(&a, current) {
+		return nil, ErrUndoStale
+	}
+
+	if err := updateReview(tx, &a.Review); err != nil {
+		return nil, err
+	}
+
+	entry := NewReviewLogEntry("undo", current, &a.Review)
+	entry.UndoesID = a.LogID
+	if err := logReviewEvent(tx, entry); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE user_stats
+		SET coins = $2, current_streak = $3, max_streak = $4, last_streak_date = $5, updated_at = NOW()
+		WHERE user_id = $1
+	`, userID, a.Coins, a.CurrentStreak, a.MaxStreak, a.LastStreakDate)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only cards still buried until tomorrow, as the action left them
+	if len(a.BuriedIDs) > 0 {
+		_, err = tx.Exec(`
+			UPDATE reviews SET buried_until = NULL
+			WHERE user_id = $1 AND question_id = ANY($2::uuid[]) AND buried_until = (CURRENT_DATE + 1)::timestamptz
+		`, userID, pq.Array(a.BuriedIDs))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if a.HistoryID != nil {
+		if _, err := tx.Exec(`DELETE FROM history WHERE id = $1 AND user_id = $2`, *a.HistoryID, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	if a.SessionID != nil {
+		_, err = tx.Exec(`
+			UPDATE study_session_cards SET answered_at = NULL, score = NULL
+			WHERE session_id = $1 AND question_id = $2
+		`, *a.SessionID, a.QuestionID)
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec(`UPDATE study_sessions SET finished_at = NULL WHERE id = $1 AND user_id = $2`, *a.SessionID, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := tx.Exec(`DELETE FROM undo_actions WHERE id = $1`, a.ID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// undoStale reports whether the card is no longer where the action left it
+// Actions saved before the post-action schedule was recorded can't be checked and are let through
+func undoStale(a *models.UndoAction, current *models.Review) bool {
+	if a.AfterNextReviewAt == nil {
+		return false
+	}
+	if current == nil || !current.NextReviewAt.Equal(*a.AfterNextReviewAt) {
+		return true
+	}
+	if current.LastReviewedAt == nil || a.AfterLastReviewedAt == nil {
+		return current.LastReviewedAt != a.AfterLastReviewedAt
+	}
+	return !current.LastReviewedAt.Equal(*a.AfterLastReviewedAt)
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
@@ -0,0 +1,36 @@
+package database
+
+import (
+	"leetcode-anki/backend/internal/models"
+	"testing"
+	"time"
+)
+
+func TestUndoStale(t *testing.T) {
+	answered := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	due := answered.AddDate(0, 0, 3)
+	later := answered.Add(2 * time.Hour)
+
+	action := &models.UndoAction{AfterLastReviewedAt: &answered, AfterNextReviewAt: &due}
+	tests := []struct {
+		name    string
+		action  *models.UndoAction
+		current *models.Review
+		want    bool
+	}{
+		{"untouched since", action, &models.Review{LastReviewedAt: &answered, NextReviewAt: due}, false},
+		{"answered again", action, &models.Review{LastReviewedAt: &later, NextReviewAt: due}, true},
+		{"rescheduled", action, &models.Review{LastReviewedAt: &answered, NextReviewAt: due.AddDate(0, 0, 2)}, true},
+		{"reset to new", action, &models.Review{NextReviewAt: due}, true},
+		{"card gone", action, nil, true},
+		{"skipped new card", &models.UndoAction{AfterNextReviewAt: &due}, &models.Review{NextReviewAt: due}, false},
+		{"saved before tracking", &models.UndoAction{}, &models.Review{NextReviewAt: later}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := undoStale(tt.action, tt.current); got != tt.want {
+				t.Errorf("undoStale = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"leetcode-anki/backend/internal/models"
+	"time"
+)
+
+// MaxVacationDays bounds the length of one vacation
+const MaxVacationDays = 90
+
+// ErrVacationOverlap is returned when a new vacation overlaps one the user already has
+var ErrVacationOverlap = errors.New("vacation overlaps an existing one")
+
+// ErrVacationInPast is returned for a vacation starting before today
+// Due dates already missed are what rebalancing is for
+var ErrVacationInPast = errors.New("vacation can't start in the past")
+
+// sqlDate formats a calendar date for a ::date parameter
+// Dates are passed as text so the database doesn't shift them into its session time zone
+func sqlDate(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+const vacationColumns = `id, user_id, starts_on, ends_on, applied_at, cards_shifted, created_at`
+
+func scanVacation(row rowScanner) (*models.Vacation, error) {
+	var v models.Vacation
+	var appliedAt sql.NullTime
+
+	err := row.Scan(&v.ID, &v.UserID, &v.StartsOn, &v.EndsOn, &appliedAt, &v.CardsShifted, &v.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if appliedAt.Valid {
+		v.AppliedAt = &appliedAt.Time
+	}
+	return &v, nil
+}
+
+// CreateVacation saves an upcoming vacation, filling in its ID and CreatedAt
+// "Today" is the database's CURRENT_DATE, the same day the queue pauses on
+func CreateVacation(v *models.Vacation) error {
+	var inPast, overlaps bool
+	err := DB.QueryRow(`
+		SELECT $2::date < CURRENT_DATE, EXISTS (
+			SELECT 1 FROM vacations
+			WHERE user_id = $1 AND starts_on <= $3::date AND ends_on >= $2::date
+		)
+	`, v.UserID, sqlDate(v.StartsOn), sqlDate(v.EndsOn)).Scan(&inPast, &overlaps)
+	if err != nil {
+		return err
+	}
+	if inPast {
+		return ErrVacationInPast
+	}
+	if overlaps {
+		return ErrVacationOverlap
+	}
+
+	return DB.QueryRow(`
+		INSERT INTO vacations (user_id, starts_on, ends_on)
+		VALUES ($1, $2::date, $3::date)
+		RETURNING id, created_at
+	`, v.UserID, sqlDate(v.StartsOn), sqlDate(v.EndsOn)).Scan(&v.ID, &v.CreatedAt)
+}
+
+// GetVacations returns the user's vacations, latest first
+func GetVacations(userID string, limit int) ([]models.Vacation, error) {
+	rows, err := DB.Query(`
+		SELECT `+vacationColumns+` FROM vacations
+		WHERE user_id = $1
+		ORDER BY starts_on DESC
+		LIMIT $2
+	`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	vacations := []models.Vacation{}
+	for rows.Next() {
+		v, err := scanVacation(rows)
+		if err != nil {
+			return nil, err
+		}
+		vacations = append(vacations, *v)
+	}
+	return vacations, rows.Err()
+}
+
+// GetActiveVacation returns the vacation the user is on today (nil if none)
+func GetActiveVacation(userID string) (*models.Vacation, error) {
+	row := DB.QueryRow(`
+		SELECT `+vacationColumns+` FROM vacations
+		WHERE user_id = $1 AND starts_on <= CURRENT_DATE AND ends_on >= CURRENT_DATE
+	`, userID)
+	v, err := scanVacation(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return v, err
+}
+
+// EndVacation cancels an upcoming vacation or cuts a running one short, ending it yesterday
+// Returns false if the user has no such vacation that isn't over yet
+func EndVacation(userID, vacationID string) (bool, error) {
+	res, err := DB.Exec(`
+		DELETE FROM vacations
+		WHERE id = $1 AND user_id = $2 AND starts_on >= CURRENT_DATE
+	`, vacationID, userID)
+	if err != nil {
+		return false, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n > 0 {
+		return n > 0, err
+	}
+
+	res, err = DB.Exec(`
+		UPDATE vacations SET ends_on = CURRENT_DATE - 1
+		WHERE id = $1 AND user_id = $2 AND ends_on >= CURRENT_DATE AND applied_at IS NULL
+	`, vacationID, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ApplyEndedVacations pushes back the due dates paused by vacations that are over
+// Every scheduled card due from a vacation's first day on moves later by its length, logged as a
+// "vacation" event; cards answered during the vacation already have a fresh due date and stay.
+// Each vacation is applied once. Returns how many cards moved.
+func ApplyEndedVacations(userID string) (int, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	type ended struct {
+		id       string
+		startsOn time.Time
+		endsOn   time.Time
+		days     int
+	}
+	rows, err := tx.Query(`
+		SELECT id, starts_on, ends_on, ends_on - starts_on + 1
+		FROM vacations
+		WHERE user_id = $1 AND ends_on < CURRENT_DATE AND applied_at IS NULL
+		ORDER BY starts_on
+		FOR UPDATE
+	`, userID)
+	if err != nil {
+		return 0, err
+	}
+	var vacations []ended
+	for rows.Next() {
+		var v ended
+		if err := rows.Scan(&v.id, &v.startsOn, &v.endsOn, &v.days); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		vacations = append(vacations, v)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(vacations) == 0 {
+		return 0, nil
+	}
+
+	total := 0
+	for _, v := range vacations {
+		reviews, err := queryReviews(tx,
+			`r.user_id = $1 AND r.card_state IN ('learning', 'review', 'relearning') AND r.next_review_at >= $2::date
+			AND (r.last_reviewed_at IS NULL OR r.last_reviewed_at < $2::date OR r.last_reviewed_at >= $3::date + 1)`,
+			`r.next_review_at`, userID, sqlDate(v.startsOn), sqlDate(v.endsOn))
+		if err != nil {
+			return 0, err
+		}
+
+		for _, review := range reviews {
+			before := *review
+			review.NextReviewAt = review.NextReviewAt.AddDate(0, 0, v.days)
+			if err := updateReview(tx, review); err != nil {
+				return 0, err
+			}
+			if err := logReviewEvent(tx, NewReviewLogEntry("vacation", &before, review)); err != nil {
+				return 0, err
+			}
+		}
+
+		_, err = tx.Exec(`UPDATE vacations SET applied_at = NOW(), cards_shifted = $2 WHERE id = $1`, v.id, len(reviews))
+		if err != nil {
+			return 0, err
+		}
+		total += len(reviews)
+	}
+
+	return total, tx.Commit()
+}
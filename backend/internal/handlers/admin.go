@@ -1,27 +1,42 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"leetcode-anki/backend/config"
 	"leetcode-anki/backend/internal/database"
 	"leetcode-anki/backend/internal/services"
+	"log"
 	"net/http"
 	"strconv"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/lib/pq"
 )
 
 type AdminHandler struct {
-	leetcodeService *services.LeetCodeService
+	problemSource services.ProblemSource
+	pregenerator  *services.SolutionPregenerator
+	leetcode      *services.LeetCodeService // Owns the problem catalog, whatever the problem source
+	refiller      *services.PoolRefiller
 }
 
 func NewAdminHandler() *AdminHandler {
 	return &AdminHandler{
-		leetcodeService: services.NewLeetCodeService(),
+		problemSource: newProblemSource(),
+		pregenerator: services.NewSolutionPregenerator(
+			services.NewLLMService(),
+			config.AppConfig.PregenConcurrency,
+			config.AppConfig.PregenRequestsPerMinute,
+		),
+		leetcode: services.NewLeetCodeService(),
+		refiller: sharedPoolRefiller(),
 	}
 }
 
-// RefreshProblems manually fetches new problems from LeetCode
+// RefreshProblems manually fetches new problems from the configured problem source
 func (h *AdminHandler) RefreshProblems(c *gin.Context) {
 	// Get counts from query params (default: 5 easy, 10 medium, 5 hard)
 	easyCount := getIntParam(c, "easy", 5)
@@ -29,34 +44,24 @@ func (h *AdminHandler) RefreshProblems(c *gin.Context) {
 	hardCount := getIntParam(c, "hard", 5)
 
 	// Fetch problems
-	problems, err := h.leetcodeService.FetchRandomProblems(easyCount, mediumCount, hardCount)
+	problems, err := h.problemSource.FetchRandomProblems(c.Request.Context(), easyCount, mediumCount, hardCount)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to fetch problems from LeetCode",
+			"error": fmt.Sprintf("Failed to fetch problems from %s", h.problemSource.Name()),
 		})
 		return
 	}
 
-	// Insert problems
-	inserted := 0
-	skipped := 0
-
-	for _, problem := range problems {
-		err := h.insertProblem(problem)
-		if err != nil {
-			if isDuplicate(err) {
-				skipped++
-			}
-		} else {
-			inserted++
-		}
-	}
+	result := services.ImportProblems(problems)
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":  "Problems refreshed successfully",
-		"inserted": inserted,
-		"skipped":  skipped,
-		"total":    len(problems),
+		"message":   "Problems refreshed successfully",
+		"source":    h.problemSource.Name(),
+		"inserted":  result.Inserted,
+		"updated":   result.Updated,
+		"unchanged": result.Unchanged,
+		"failed":    result.Failed,
+		"total":     len(problems),
 	})
 }
 
@@ -88,6 +93,101 @@ func (h *AdminHandler) GetProblemStats(c *gin.Context) {
 	c.JSON(http.StatusOK, stats)
 }
 
+// StartSolutionPregen starts a background job that generates solution breakdowns
+// for every question that doesn't have one yet
+func (h *AdminHandler) StartSolutionPregen(c *gin.Context) {
+	limit := getIntParam(c, "limit", 0)
+
+	if err := h.pregenerator.Start(limit); err != nil {
+		status := http.StatusConflict
+		if errors.Is(err, services.ErrPregenLimit) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Solution pre-generation started",
+	})
+}
+
+// GetSolutionPregenStatus reports progress of the current or last pre-generation job
+func (h *AdminHandler) GetSolutionPregenStatus(c *gin.Context) {
+	remaining, err := database.CountQuestionsWithoutSolution()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count questions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"progress":  h.pregenerator.Progress(),
+		"remaining": remaining,
+	})
+}
+
+// StartCatalogSync syncs the problem catalog in the background (?full=true walks the whole list)
+func (h *AdminHandler) StartCatalogSync(c *gin.Context) {
+	full := c.Query("full") == "true"
+
+	go func() {
+		// Detached from the request so the sync outlives it
+		if _, err := h.leetcode.SyncCatalog(context.Background(), full); err != nil {
+			log.Printf("⚠️ Catalog sync failed: %v", err)
+		}
+	}()
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Catalog sync started",
+		"full":    full,
+	})
+}
+
+// GetCatalogStatus reports the catalog size and recent sync runs
+func (h *AdminHandler) GetCatalogStatus(c *gin.Context) {
+	count, err := database.GetCatalogCount()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count catalog"})
+		return
+	}
+
+	syncs, err := database.GetRecentCatalogSyncs(10)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch catalog syncs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": count,
+		"syncs": syncs,
+	})
+}
+
+// GetRefillRuns reports the refill worker's state and its recent runs
+func (h *AdminHandler) GetRefillRuns(c *gin.Context) {
+	runs, err := database.GetRecentRefillRuns(getIntParam(c, "limit", 20))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch refill runs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": h.refiller.Status(),
+		"runs":   runs,
+	})
+}
+
+// GetGraderCalibration returns how often users override the grader's score with their own rating
+func (h *AdminHandler) GetGraderCalibration(c *gin.Context) {
+	calibration, err := database.GetGraderCalibration()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute grader calibration"})
+		return
+	}
+
+	c.JSON(http.StatusOK, calibration)
+}
+
 // Helper functions
 
 func getIntParam(c *gin.Context, key string, defaultValue int) int {
@@ -104,48 +204,30 @@ func getIntParam(c *gin.Context, key string, defaultValue int) int {
 	return value
 }
 
-func (h *AdminHandler) insertProblem(problem *services.LeetCodeProblem) error {
-	topics := make([]string, len(problem.TopicTags))
-	for i, tag := range problem.TopicTags {
-		topics[i] = tag.Name
-	}
-
-	leetcodeID := 0
-	if _, err := fmt.Sscanf(problem.QuestionID, "%d", &leetcodeID); err != nil {
-		// Log error but try to continue or handle gracefully - wait, returning error is safer here as ID is critical
-		return fmt.Errorf("failed to parse LeetCode ID from %s: %w", problem.QuestionID, err)
-	}
-
-	descriptionMarkdown := services.StripHTMLTags(problem.Content)
-
-	query := `
-		INSERT INTO questions 
-		(leetcode_id, title, slug, difficulty, description_markdown, topics)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		ON CONFLICT (leetcode_id) DO NOTHING
-		RETURNING id
-	`
-
-	var id string
-	err := database.DB.QueryRow(
-		query,
-		leetcodeID,
-		problem.Title,
-		problem.TitleSlug,
-		problem.Difficulty,
-		descriptionMarkdown,
-		pq.Array(topics),
-	).Scan(&id)
+var (
+	poolRefiller     *services.PoolRefiller
+	poolRefillerOnce sync.Once
+)
 
-	return err
+// sharedPoolRefiller returns the process-wide refill worker, so requests from every handler coalesce
+func sharedPoolRefiller() *services.PoolRefiller {
+	poolRefillerOnce.Do(func() {
+		poolRefiller = services.NewPoolRefiller(
+			newProblemSource(),
+			config.AppConfig.RefillThreshold,
+			config.AppConfig.RefillBatchSize,
+			time.Duration(config.AppConfig.RefillMinIntervalMinutes)*time.Minute,
+		)
+	})
+	return poolRefiller
 }
 
-func isDuplicate(err error) bool {
-	if err == nil {
-		return false
-	}
-	if pqErr, ok := err.(*pq.Error); ok {
-		return pqErr.Code == "23505"
+// newProblemSource builds the configured problem source, falling back to LeetCode if it can't be loaded
+func newProblemSource() services.ProblemSource {
+	source, err := services.NewProblemSource()
+	if err != nil {
+		log.Printf("⚠️ %v, falling back to LeetCode", err)
+		return services.NewLeetCodeService()
 	}
-	return false
+	return source
 }
@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"leetcode-anki/backend/internal/database"
+	"leetcode-anki/backend/internal/models"
+	"leetcode-anki/backend/internal/services"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxSetDueDays bounds how far ahead a due date can be set
+const maxSetDueDays = 3650
+
+// CardsHandler serves the card browser's bulk operations; every change goes to the review log
+type CardsHandler struct {
+	srsService *services.SM2Algorithm
+}
+
+func NewCardsHandler() *CardsHandler {
+	return &CardsHandler{
+		srsService: services.NewSM2Algorithm(),
+	}
+}
+
+// ResetCards handles POST /api/cards/reset
+// Selected cards go back to "new" with a fresh schedule; keep_lapses preserves their lapse counts
+func (h *CardsHandler) ResetCards(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req models.ResetCardsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	cards, skipped, ok := selectCards(c, userID, req.QuestionIDs)
+	if !ok {
+		return
+	}
+
+	applyCardChanges(c, userID, "reset", cards, skipped, func(review *models.Review) error {
+		switch review.CardState {
+		case "new":
+			return errors.New("card is already new")
+		case "suspended":
+			return services.ErrCardSuspended
+		}
+		h.srsService.ResetCard(review, req.KeepLapses)
+		return nil
+	})
+}
+
+// ForgetCards handles POST /api/cards/forget
+// Resets every studied card of a topic and/or list, as if never learned (suspended cards stay suspended)
+func (h *CardsHandler) ForgetCards(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req models.ForgetCardsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	if req.Topic == "" && req.ListSlug == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "topic or list_slug is required"})
+		return
+	}
+
+	selection := &models.Deck{}
+	if req.Topic != "" {
+		selection.Topics = []string{req.Topic}
+	}
+	if req.ListSlug != "" {
+		list, err := database.GetProblemListBySlug(userID, req.ListSlug)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch list"})
+			return
+		}
+		if list == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "List not found"})
+			return
+		}
+		selection.ListID = &list.ID
+	}
+
+	cards, err := database.GetStudiedReviews(userID, selection)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch cards"})
+		return
+	}
+
+	applyCardChanges(c, userID, "forget", cards, nil, func(review *models.Review) error {
+		if review.CardState == "suspended" {
+			return services.ErrCardSuspended
+		}
+		h.srsService.ResetCard(review, req.KeepLapses)
+		return nil
+	})
+}
+
+// RepositionCards handles POST /api/cards/reposition
+// Puts new cards at positions start, start+step, ... of the new-card queue, in the order given.
+// With shift, repositioned cards already at or after start move back to make room.
+func (h *CardsHandler) RepositionCards(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req models.RepositionCardsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	if req.Start == 0 {
+		req.Start = 1
+	}
+	if req.Step == 0 {
+		req.Step = 1
+	}
+	if req.Start < 1 || req.Step < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start and step must be positive"})
+		return
+	}
+	if len(req.QuestionIDs) == 0 || len(req.QuestionIDs) > database.MaxBulkCards {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Select between 1 and %d cards", database.MaxBulkCards)})
+		return
+	}
+
+	queue, err := database.GetNewQueue(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch new cards"})
+		return
+	}
+	byQuestion := make(map[string]*models.Review, len(queue))
+	for _, review := range queue {
+		byQuestion[review.QuestionID] = review
+	}
+
+	positions := make(map[string]int)
+	var cards []*models.Review
+	skipped := []models.SkippedCard{}
+	for _, id := range req.QuestionIDs {
+		review, ok := byQuestion[id]
+		if !ok {
+			skipped = append(skipped, models.SkippedCard{QuestionID: id, Reason: "not a new card"})
+			continue
+		}
+		if _, dup := positions[id]; dup {
+			continue
+		}
+		positions[id] = req.Start + len(cards)*req.Step
+		cards = append(cards, review)
+	}
+
+	if req.Shift {
+		by := len(cards) * req.Step
+		for _, review := range queue {
+			if _, moving := positions[review.QuestionID]; moving {
+				continue
+			}
+			if review.NewPosition != nil && *review.NewPosition >= req.Start {
+				positions[review.QuestionID] = *review.NewPosition + by
+				cards = append(cards, review)
+			}
+		}
+	}
+
+	applyCardChanges(c, userID, "reposition", cards, skipped, func(review *models.Review) error {
+		position := positions[review.QuestionID]
+		review.NewPosition = &position
+		return nil
+	})
+}
+
+// SetDueDate handles POST /api/cards/set-due
+// Makes the selected cards due `days` days from today; new and learning cards become review cards
+func (h *CardsHandler) SetDueDate(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req models.SetDueRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	if req.Days < 0 || req.Days > maxSetDueDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("days must be between 0 and %d", maxSetDueDays)})
+		return
+	}
+
+	cards, skipped, ok := selectCards(c, userID, req.QuestionIDs)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	applyCardChanges(c, userID, "set_due", cards, skipped, func(review *models.Review) error {
+		return h.srsService.SetDueDate(review, req.Days, req.SetInterval, now)
+	})
+}
+
+// selectCards loads the user's cards for the selected questions; questions without a card are skipped
+// Writes the error response and returns false if the selection is empty or too large
+func selectCards(c *gin.Context, userID string, questionIDs []string) ([]*models.Review, []models.SkippedCard, bool) {
+	if len(questionIDs) == 0 || len(questionIDs) > database.MaxBulkCards {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Select between 1 and %d cards", database.MaxBulkCards)})
+		return nil, nil, false
+	}
+
+	cards, err := database.GetReviewsByQuestionIDs(userID, questionIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch cards"})
+		return nil, nil, false
+	}
+
+	found := make(map[string]bool, len(cards))
+	for _, review := range cards {
+		found[review.QuestionID] = true
+	}
+	skipped := []models.SkippedCard{}
+	for _, id := range questionIDs {
+		if !found[id] {
+			skipped = append(skipped, models.SkippedCard{QuestionID: id, Reason: "no card for this question"})
+			found[id] = true // Report duplicates once
+		}
+	}
+
+	return cards, skipped, true
+}
+
+// applyCardChanges runs change on each card and saves the ones it applied to, logged as event
+// A card change refuses (returns an error for) is reported as skipped with the error as the reason
+func applyCardChanges(c *gin.Context, userID, event string, cards []*models.Review, skipped []models.SkippedCard, change func(*models.Review) error) {
+	if skipped == nil {
+		skipped = []models.SkippedCard{}
+	}
+
+	var before, after []*models.Review
+	for _, review := range cards {
+		original := *review
+		if err := change(review); err != nil {
+			skipped = append(skipped, models.SkippedCard{QuestionID: review.QuestionID, Reason: err.Error()})
+			continue
+		}
+		before = append(before, &original)
+		after = append(after, review)
+	}
+
+	if len(after) > 0 {
+		if err := database.SaveCardChanges(event, before, after); err != nil {
+			log.Printf("❌ Failed to %s cards for user %s: %v", event, userID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update cards"})
+			return
+		}
+		_ = database.RefreshUserStats(userID)
+		log.Printf("🗂️ %s: %d cards for user %s", event, len(after), userID)
+	}
+
+	c.JSON(http.StatusOK, models.CardOperationResult{
+		Updated: len(after),
+		Skipped: skipped,
+	})
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"leetcode-anki/backend/internal/database"
+	"leetcode-anki/backend/internal/services"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CustomQuestionsHandler struct{}
+
+func NewCustomQuestionsHandler() *CustomQuestionsHandler {
+	return &CustomQuestionsHandler{}
+}
+
+// ListCustomQuestions handles GET /api/custom-questions
+// Returns the user's own questions and those shared with their teams
+func (h *CustomQuestionsHandler) ListCustomQuestions(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	questions, err := database.GetCustomQuestions(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch custom questions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"questions": questions})
+}
+
+// CreateCustomQuestion handles POST /api/custom-questions
+func (h *CustomQuestionsHandler) CreateCustomQuestion(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req services.CustomQuestionInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	question, err := req.ToQuestion(userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.checkTeam(c, userID, question.TeamID) {
+		return
+	}
+
+	if err := database.CreateCustomQuestion(question); err != nil {
+		log.Printf("❌ Failed to create custom question: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create question"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"question": question})
+}
+
+// UpdateCustomQuestion handles PUT /api/custom-questions/:id (owner only)
+// Leaving reference_solution empty keeps the current solution breakdown
+func (h *CustomQuestionsHandler) UpdateCustomQuestion(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req services.CustomQuestionInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	question, err := req.ToQuestion(userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.checkTeam(c, userID, question.TeamID) {
+		return
+	}
+	question.ID = c.Param("id")
+
+	found, err := database.UpdateCustomQuestion(question)
+	if err != nil {
+		log.Printf("❌ Failed to update custom question %s: %v", question.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update question"})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Question not found"})
+		return
+	}
+
+	updated, err := database.GetQuestionByID(question.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch question"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"question": updated})
+}
+
+// DeleteCustomQuestion handles DELETE /api/custom-questions/:id (owner only)
+// Everyone's cards and attempts for the question go with it
+func (h *CustomQuestionsHandler) DeleteCustomQuestion(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	found, err := database.DeleteCustomQuestion(userID, c.Param("id"))
+	if err != nil {
+		log.Printf("❌ Failed to delete custom question %s: %v", c.Param("id"), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete question"})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Question not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Question deleted"})
+}
+
+// checkTeam makes sure the user belongs to the team they're sharing with; writes the error response if not
+func (h *CustomQuestionsHandler) checkTeam(c *gin.Context, userID string, teamID *string) bool {
+	if teamID == nil {
+		return true
+	}
+
+	role, err := database.GetTeamRole(*teamID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check team membership"})
+		return false
+	}
+	if role == "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You are not a member of this team"})
+		return false
+	}
+	return true
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"leetcode-anki/backend/internal/database"
 	"leetcode-anki/backend/internal/models"
+	"leetcode-anki/backend/internal/services"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -15,9 +16,18 @@ func NewDashboardHandler() *DashboardHandler {
 }
 
 // GetDashboard returns Anki-style dashboard with due counts and today's stats
+// ?deck_id= scopes the due counts and next due time to that deck
 func (h *DashboardHandler) GetDashboard(c *gin.Context) {
 	userID := c.GetString("user_id")
 
+	deck, ok := deckFromQuery(c, userID)
+	if !ok {
+		return
+	}
+
+	// Move due dates paused by a vacation that's over before counting
+	vacation := settleVacations(userID)
+
 	// Get user stats
 	stats, err := database.GetUserStats(userID)
 	if err != nil {
@@ -26,11 +36,15 @@ func (h *DashboardHandler) GetDashboard(c *gin.Context) {
 	}
 
 	// Get due counts by type (learning, review, new)
-	dueCounts, err := database.GetDueCountsByType(userID)
+	dueCounts, err := database.GetDueCountsByType(userID, deck)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch due counts"})
 		return
 	}
+	if vacation != nil {
+		// Nothing is due while reviews are paused
+		*dueCounts = models.DueCounts{NewStudiedToday: dueCounts.NewStudiedToday}
+	}
 
 	// Get today's stats
 	todayStats, err := database.GetTodayStats(userID)
@@ -40,16 +54,27 @@ func (h *DashboardHandler) GetDashboard(c *gin.Context) {
 	}
 
 	// Get next card due time
-	nextCardTime, err := database.GetNextDueCardTime(userID)
+	nextCardTime, err := database.GetNextDueCardTime(userID, deck)
 	if err != nil {
 		// Not critical, continue
 		nextCardTime = nil
 	}
 
+	// Today's new cards against the per-difficulty quotas
+	introduced, err := database.CountReviewsCreatedTodayByDifficulty(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch new card quotas"})
+		return
+	}
+
 	// Check if all cards are studied
+	newCardsLimit := stats.NewCardsLimit
+	if deck != nil && deck.NewPerDay != nil {
+		newCardsLimit = *deck.NewPerDay
+	}
 	allStudied := dueCounts.LearningDue == 0 &&
 		dueCounts.ReviewsDue == 0 &&
-		(dueCounts.NewAvailable == 0 || dueCounts.NewStudiedToday >= 20) // Assuming 20 daily limit
+		(dueCounts.NewAvailable == 0 || dueCounts.NewStudiedToday >= newCardsLimit)
 
 	dashboard := models.DashboardData{
 		Stats:           *stats,
@@ -57,6 +82,9 @@ func (h *DashboardHandler) GetDashboard(c *gin.Context) {
 		TodayStats:      *todayStats,
 		NextCardDueAt:   nextCardTime,
 		AllCardsStudied: allStudied,
+		Deck:            deck,
+		Vacation:        vacation,
+		NewQuotas:       services.NewQuotaBuckets(stats.NewDifficultyQuotas, introduced),
 	}
 
 	c.JSON(http.StatusOK, dashboard)
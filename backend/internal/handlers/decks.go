@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"errors"
+	"leetcode-anki/backend/internal/database"
+	"leetcode-anki/backend/internal/models"
+	"leetcode-anki/backend/internal/services"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DecksHandler struct{}
+
+func NewDecksHandler() *DecksHandler {
+	return &DecksHandler{}
+}
+
+// GetDecks handles GET /api/decks
+func (h *DecksHandler) GetDecks(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	decks, err := database.GetUserDecks(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch decks"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"decks": decks})
+}
+
+// CreateDeck handles POST /api/decks
+func (h *DecksHandler) CreateDeck(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	deck, ok := h.bindDeck(c, userID)
+	if !ok {
+		return
+	}
+
+	if err := database.CreateDeck(deck); err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"deck": deck})
+}
+
+// UpdateDeck handles PUT /api/decks/:id
+func (h *DecksHandler) UpdateDeck(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	deck, ok := h.bindDeck(c, userID)
+	if !ok {
+		return
+	}
+	deck.ID = c.Param("id")
+
+	found, err := database.UpdateDeck(deck)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Deck not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deck": deck})
+}
+
+// DeleteDeck handles DELETE /api/decks/:id; the deck's cards stay in the collection
+func (h *DecksHandler) DeleteDeck(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	found, err := database.DeleteDeck(userID, c.Param("id"))
+	if err != nil {
+		log.Printf("❌ Failed to delete deck %s: %v", c.Param("id"), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete deck"})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Deck not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Deck deleted"})
+}
+
+// bindDeck parses and validates the request body, resolving a list deck's slug; writes the error response if invalid
+func (h *DecksHandler) bindDeck(c *gin.Context, userID string) (*models.Deck, bool) {
+	var req services.DeckInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return nil, false
+	}
+
+	deck, err := req.ToDeck(userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	if deck.Kind == "list" {
+		list, err := database.GetProblemListBySlug(userID, req.ListSlug)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch list"})
+			return nil, false
+		}
+		if list == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "List not found"})
+			return nil, false
+		}
+		deck.ListID = &list.ID
+	}
+
+	return deck, true
+}
+
+func (h *DecksHandler) writeError(c *gin.Context, err error) {
+	if errors.Is(err, database.ErrDeckNameTaken) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	log.Printf("❌ Failed to save deck: %v", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save deck"})
+}
+
+// deckFromQuery loads the deck named by ?deck_id= (nil without one); writes the error response if it can't
+func deckFromQuery(c *gin.Context, userID string) (*models.Deck, bool) {
+	deckID := c.Query("deck_id")
+	if deckID == "" {
+		return nil, true
+	}
+
+	deck, err := database.GetDeck(userID, deckID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch deck"})
+		return nil, false
+	}
+	if deck == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Deck not found"})
+		return nil, false
+	}
+	return deck, true
+}
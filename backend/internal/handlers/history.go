@@ -1,18 +1,27 @@
 package handlers
 
 import (
+	"context"
 	"leetcode-anki/backend/internal/database"
+	"leetcode-anki/backend/internal/models"
+	"leetcode-anki/backend/internal/services"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-type HistoryHandler struct{}
+type HistoryHandler struct {
+	llmService *services.LLMService
+}
 
 func NewHistoryHandler() *HistoryHandler {
-	return &HistoryHandler{}
+	return &HistoryHandler{
+		llmService: services.NewLLMService(),
+	}
 }
 
 // GetHistory retrieves the user's submission history with pagination and filters
@@ -69,6 +78,54 @@ func (h *HistoryHandler) GetHistory(c *gin.Context) {
 	})
 }
 
+// GetReviewLog handles GET /api/review-log?since=<RFC3339>&after=<seq>&limit=
+// Exports the append-only log of schedule changes, oldest first; pass next_after back as after to page
+func (h *HistoryHandler) GetReviewLog(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "1000"))
+	if err != nil || limit < 1 || limit > database.MaxReviewLogPage {
+		limit = database.MaxReviewLogPage
+	}
+
+	var since *time.Time
+	if sinceStr := c.Query("since"); sinceStr != "" {
+		t, err := time.Parse(time.RFC3339Nano, sinceStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "since must be an RFC 3339 timestamp"})
+			return
+		}
+		since = &t
+	}
+
+	var after int64
+	if afterStr := c.Query("after"); afterStr != "" {
+		after, err = strconv.ParseInt(afterStr, 10, 64)
+		if err != nil || after < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "after must be a seq from a previous page"})
+			return
+		}
+	}
+
+	entries, err := database.GetReviewLog(userID, since, after, limit)
+	if err != nil {
+		log.Printf("❌ Failed to fetch review log for user %s: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch review log"})
+		return
+	}
+
+	var nextAfter *int64
+	if len(entries) == limit {
+		nextAfter = &entries[len(entries)-1].Seq
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":       entries,
+		"limit":      limit,
+		"next_after": nextAfter,
+	})
+}
+
 // GetQuestionHistory retrieves all attempts for a specific question
 func (h *HistoryHandler) GetQuestionHistory(c *gin.Context) {
 	userID := c.GetString("user_id")
@@ -89,3 +146,49 @@ func (h *HistoryHandler) GetQuestionHistory(c *gin.Context) {
 		"data": history,
 	})
 }
+
+// GetQuestionProgress compares all attempts at a question: sub-score trends plus
+// an LLM summary of recurring mistakes. Pass summary=false to skip the LLM call.
+func (h *HistoryHandler) GetQuestionProgress(c *gin.Context) {
+	userID := c.GetString("user_id")
+	questionID := c.Param("question_id")
+
+	if questionID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Question ID is required"})
+		return
+	}
+
+	history, err := database.GetHistoryByQuestion(userID, questionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch question history"})
+		return
+	}
+
+	if len(history) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No attempts found for this question"})
+		return
+	}
+
+	// History comes newest first; trends read oldest first
+	attempts := make([]models.History, len(history))
+	for i, attempt := range history {
+		attempts[len(history)-1-i] = attempt
+	}
+
+	progress := services.BuildAnswerProgress(questionID, attempts[0].QuestionTitle, attempts)
+
+	if len(attempts) >= 2 && c.DefaultQuery("summary", "true") != "false" {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		summary, err := h.llmService.SummarizeRecurringMistakes(ctx, progress.QuestionTitle, attempts)
+		if err != nil {
+			// Trends are still useful without the summary
+			log.Printf("⚠️ Failed to summarize recurring mistakes: %v", err)
+		} else {
+			progress.RecurringMistakes = summary
+		}
+	}
+
+	c.JSON(http.StatusOK, progress)
+}
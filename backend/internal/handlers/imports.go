@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"leetcode-anki/backend/internal/database"
+	"leetcode-anki/backend/internal/services"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxSolvedExportBytes caps LeetCode export uploads; a few thousand submissions is well under this
+const maxSolvedExportBytes = 10 << 20
+
+type ImportHandler struct {
+	srsService *services.SM2Algorithm
+}
+
+func NewImportHandler() *ImportHandler {
+	return &ImportHandler{
+		srsService: services.NewSM2Algorithm(),
+	}
+}
+
+// ImportSolved handles POST /api/import/solved
+// Accepts a LeetCode submissions export (JSON or CSV) or a saved profile response, as a multipart
+// "file" field or the raw request body. With ?dry_run=true it only returns the plan.
+func (h *ImportHandler) ImportSolved(c *gin.Context) {
+	userID := c.GetString("user_id")
+	dryRun := c.Query("dry_run") == "true"
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSolvedExportBytes+multipartOverhead)
+
+	name, data, err := readExportUpload(c)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Export file too large (max 10 MB)"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No export file provided"})
+		return
+	}
+
+	solved, err := services.ParseSolvedExport(name, data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	plan, err := services.PlanSolvedImport(h.srsService, userID, solved)
+	if err != nil {
+		log.Printf("❌ Failed to plan solved import: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to match solved problems"})
+		return
+	}
+
+	if !dryRun {
+		if err := plan.Apply(); err != nil {
+			log.Printf("❌ Failed to import solved problems: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create cards"})
+			return
+		}
+		if err := database.RefreshUserStats(userID); err != nil {
+			log.Printf("⚠️ Failed to refresh user stats: %v", err)
+		}
+		log.Printf("📥 Imported %d solved problems for %s (%d already had cards, %d not in pool)", plan.Created, userID, plan.Existing, plan.Unmatched)
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+// readExportUpload returns the uploaded file, or the raw body when it isn't a multipart upload
+func readExportUpload(c *gin.Context) (string, []byte, error) {
+	if file, header, err := c.Request.FormFile("file"); err == nil {
+		defer file.Close()
+		data, err := io.ReadAll(file)
+		return header.Filename, data, err
+	} else if !errors.Is(err, http.ErrNotMultipart) {
+		return "", nil, err
+	}
+
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(data) == 0 {
+		return "", nil, errors.New("empty body")
+	}
+
+	// The body's content type stands in for a file extension
+	name := "export.json"
+	if c.ContentType() == "text/csv" {
+		name = "export.csv"
+	}
+	return name, data, nil
+}
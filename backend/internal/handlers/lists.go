@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"context"
+	"leetcode-anki/backend/internal/database"
+	"leetcode-anki/backend/internal/services"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ListsHandler struct {
+	problemSource services.ProblemSource
+	fetching      sync.Map // list ID -> struct{}, so one list is only fetched once at a time
+}
+
+func NewListsHandler() *ListsHandler {
+	return &ListsHandler{
+		problemSource: newProblemSource(),
+	}
+}
+
+// GetLists handles GET /api/lists
+func (h *ListsHandler) GetLists(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	lists, err := database.GetProblemLists(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch lists"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"lists": lists})
+}
+
+// GetList handles GET /api/lists/:slug with items in list order
+func (h *ListsHandler) GetList(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	list, err := database.GetProblemListBySlug(userID, c.Param("slug"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch list"})
+		return
+	}
+	if list == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "List not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// Subscribe handles POST /api/lists/:slug/subscribe
+// New cards are then drawn from the list in order; missing problems are fetched in the background
+func (h *ListsHandler) Subscribe(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	list, err := database.GetProblemListBySlug(userID, c.Param("slug"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch list"})
+		return
+	}
+	if list == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "List not found"})
+		return
+	}
+
+	if err := database.SubscribeToList(userID, list.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to subscribe to list"})
+		return
+	}
+
+	if list.AvailableCount < list.ItemCount {
+		go h.fetchMissingProblems(list.ID, list.Slug)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":         "Subscribed to list",
+		"slug":            list.Slug,
+		"item_count":      list.ItemCount,
+		"available_count": list.AvailableCount,
+	})
+}
+
+// Unsubscribe handles DELETE /api/lists/:slug/subscribe
+// Cards already drawn from the list stay in the user's deck
+func (h *ListsHandler) Unsubscribe(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	list, err := database.GetProblemListBySlug(userID, c.Param("slug"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch list"})
+		return
+	}
+	if list == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "List not found"})
+		return
+	}
+
+	if err := database.UnsubscribeFromList(userID, list.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unsubscribe from list"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Unsubscribed from list", "slug": list.Slug})
+}
+
+// fetchMissingProblems imports list problems that aren't in the pool yet
+func (h *ListsHandler) fetchMissingProblems(listID, slug string) {
+	if _, running := h.fetching.LoadOrStore(listID, struct{}{}); running {
+		return
+	}
+	defer h.fetching.Delete(listID)
+
+	// Outlives the subscribe request, so it can't use the request context
+	result, err := services.FetchMissingListProblems(context.Background(), h.problemSource, listID)
+	if err != nil {
+		log.Printf("⚠️ Failed to fetch missing problems for list %s: %v", slug, err)
+		return
+	}
+	if result.Failed > 0 {
+		log.Printf("⚠️ List %s: %d problems could not be fetched", slug, result.Failed)
+	}
+}
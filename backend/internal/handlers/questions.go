@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"errors"
 	"leetcode-anki/backend/internal/database"
 	"leetcode-anki/backend/internal/models"
+	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -39,7 +41,7 @@ func (h *QuestionsHandler) GetAllQuestions(c *gin.Context) {
 	}
 
 	// Get total count (for pagination)
-	totalCount, err := database.GetTotalQuestionCount(filters)
+	totalCount, err := database.GetTotalQuestionCount(userID, filters)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count questions"})
 		return
@@ -52,18 +54,89 @@ func (h *QuestionsHandler) GetAllQuestions(c *gin.Context) {
 	})
 }
 
+// GetQuestionRevisions handles GET /api/questions/:id/revisions
+// Lists every imported version of the question's content, newest first
+func (h *QuestionsHandler) GetQuestionRevisions(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	question, err := getVisibleQuestion(userID, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Question not found"})
+		return
+	}
+
+	revisions, err := database.GetQuestionRevisions(question.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch revisions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"revisions": revisions})
+}
+
+// GetRelatedQuestions handles GET /api/questions/:id/related
+// Neighbours come from LeetCode's similar questions and shared solution patterns
+func (h *QuestionsHandler) GetRelatedQuestions(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	question, err := getVisibleQuestion(userID, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Question not found"})
+		return
+	}
+
+	related, err := database.GetRelatedQuestions(userID, question.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch related questions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"related": related})
+}
+
+// GetQuestionPrerequisites handles GET /api/questions/:id/prerequisites
+// Lists what should be learned first and whether the user has; new cards wait for these
+// unless the user turned prerequisite enforcement off
+func (h *QuestionsHandler) GetQuestionPrerequisites(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	question, err := getVisibleQuestion(userID, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Question not found"})
+		return
+	}
+
+	prerequisites, err := database.GetQuestionPrerequisites(userID, question.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prerequisites"})
+		return
+	}
+
+	unlocked := true
+	for _, p := range prerequisites {
+		unlocked = unlocked && p.Met
+	}
+
+	c.JSON(http.StatusOK, gin.H{"prerequisites": prerequisites, "unlocked": unlocked})
+}
+
 // GetQuestionDetail returns detailed info about a specific question
 func (h *QuestionsHandler) GetQuestionDetail(c *gin.Context) {
 	userID := c.GetString("user_id")
 	questionID := c.Param("id")
 
 	// Get question
-	question, err := database.GetQuestionByID(questionID)
+	question, err := getVisibleQuestion(userID, questionID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Question not found"})
 		return
 	}
 
+	// Hints, examples, constraints, starter code, similar questions
+	if err := database.LoadQuestionMetadata(question); err != nil {
+		log.Printf("⚠️ Failed to load metadata for question %s: %v", questionID, err)
+	}
+
 	// Get user's review if exists
 	review, err := database.GetReview(userID, questionID)
 	if err != nil {
@@ -85,9 +158,9 @@ func (h *QuestionsHandler) GetQuestionDetail(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
-// GetTopics returns all unique topics from questions
+// GetTopics returns all unique topics from questions the user can see
 func (h *QuestionsHandler) GetTopics(c *gin.Context) {
-	topics, err := database.GetAllTopics()
+	topics, err := database.GetAllTopics(c.GetString("user_id"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch topics"})
 		return
@@ -111,12 +184,16 @@ func (h *QuestionsHandler) SuspendCard(c *gin.Context) {
 	}
 
 	// Update to suspended state
+	before := *review
 	review.CardState = "suspended"
 	err = database.UpdateReview(review)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to suspend card"})
 		return
 	}
+	if before.CardState != review.CardState {
+		recordReviewEvent(database.NewReviewLogEntry("suspend", &before, review))
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Card suspended successfully",
@@ -137,6 +214,7 @@ func (h *QuestionsHandler) UnsuspendCard(c *gin.Context) {
 	}
 
 	// Restore previous state (default to learning)
+	before := *review
 	if review.CardState == "suspended" {
 		if review.IntervalDays >= 1 {
 			review.CardState = "review"
@@ -150,9 +228,34 @@ func (h *QuestionsHandler) UnsuspendCard(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unsuspend card"})
 		return
 	}
+	if before.CardState != review.CardState {
+		recordReviewEvent(database.NewReviewLogEntry("unsuspend", &before, review))
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Card unsuspended successfully",
 		"review":  review,
 	})
 }
+
+// errQuestionNotVisible hides other users' custom questions behind a plain "not found"
+var errQuestionNotVisible = errors.New("question not visible to user")
+
+// getVisibleQuestion loads a question the user is allowed to see
+func getVisibleQuestion(userID, questionID string) (*models.Question, error) {
+	question, err := database.GetQuestionByID(questionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if question.OwnerID != nil && *question.OwnerID != userID {
+		ok, err := database.CanAccessQuestion(userID, questionID)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, errQuestionNotVisible
+		}
+	}
+	return question, nil
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"leetcode-anki/backend/config"
 	"leetcode-anki/backend/internal/database"
@@ -15,23 +16,34 @@ import (
 )
 
 type ReviewHandler struct {
-	srsService      *services.SM2Algorithm
-	llmService      *services.LLMService
-	leetcodeService *services.LeetCodeService
+	srsService *services.SM2Algorithm
+	llmService *services.LLMService
+	refiller   *services.PoolRefiller
+	sandbox    *services.CodeSandbox
 }
 
 func NewReviewHandler() *ReviewHandler {
 	return &ReviewHandler{
-		srsService:      services.NewSM2Algorithm(),
-		llmService:      services.NewLLMService(),
-		leetcodeService: services.NewLeetCodeService(),
+		srsService: services.NewSM2Algorithm(),
+		llmService: services.NewLLMService(),
+		refiller:   sharedPoolRefiller(),
+		sandbox: services.NewCodeSandbox(
+			config.AppConfig.SandboxRuntime,
+			time.Duration(config.AppConfig.SandboxTimeoutSecs)*time.Second,
+			config.AppConfig.SandboxMemoryMB,
+		),
 	}
 }
 
+// maxImplementationAttempts bounds regeneration when sandbox validation fails
+const maxImplementationAttempts = 2
+
 // ensureNewCardsQueue fills queue to user's limit
-func (h *ReviewHandler) ensureNewCardsQueue(userID string) error {
+// With a deck, only the deck's questions are introduced, within both the deck's and the user's limits.
+// Difficulties with a quota (e.g. 1 Hard a day) stop being introduced once it's used up.
+func (h *ReviewHandler) ensureNewCardsQueue(userID string, deck *models.Deck) error {
 	// 1. Check STRICT daily limit first (how many have we actually fetched today?)
-	fetchedToday, err := database.CountReviewsCreatedToday(userID)
+	fetchedToday, err := database.CountReviewsCreatedToday(userID, nil)
 	if err != nil {
 		return err
 	}
@@ -44,18 +56,29 @@ func (h *ReviewHandler) ensureNewCardsQueue(userID string) error {
 
 	// Calculate how many more we can strictly fetch today
 	remainingDailyQuota := userStats.NewCardsLimit - fetchedToday
+
+	// A deck's own limit applies on top of the user's
+	limit := userStats.NewCardsLimit
+	if deck != nil && deck.NewPerDay != nil {
+		limit = *deck.NewPerDay
+		deckFetchedToday, err := database.CountReviewsCreatedToday(userID, deck)
+		if err != nil {
+			return err
+		}
+		remainingDailyQuota = min(remainingDailyQuota, limit-deckFetchedToday)
+	}
 	if remainingDailyQuota <= 0 {
 		return nil // Already reached daily limit, do NOT fetch more
 	}
 
 	// 2. Check queue capacity (how many are currently waiting?)
-	newInQueue, err := database.CountNewStateCards(userID)
+	newInQueue, err := database.CountNewStateCards(userID, deck)
 	if err != nil {
 		return err
 	}
 
 	// Queue space is also limited by the daily limit setting (as a queue size cap)
-	queueSpace := userStats.NewCardsLimit - newInQueue
+	queueSpace := limit - newInQueue
 	if queueSpace <= 0 {
 		return nil
 	}
@@ -67,8 +90,19 @@ func (h *ReviewHandler) ensureNewCardsQueue(userID string) error {
 		needed = remainingDailyQuota
 	}
 
+	// 3. Per-difficulty quotas: a difficulty whose quota is used up isn't drawn from
+	introduced, err := database.CountReviewsCreatedTodayByDifficulty(userID)
+	if err != nil {
+		return err
+	}
+	quotas := services.NewQuotaBuckets(userStats.NewDifficultyQuotas, introduced)
+
 	for i := 0; i < needed; i++ {
-		question, err := database.GetNewCard(userID)
+		question, err := database.GetNewCard(userID, deck, database.NewCardOptions{
+			Order:                userStats.NewGatherOrder,
+			RequirePrerequisites: userStats.EnforcePrerequisites,
+			SkipDifficulties:     services.ExhaustedDifficulties(quotas),
+		})
 		if question == nil || err != nil {
 			break
 		}
@@ -78,6 +112,14 @@ func (h *ReviewHandler) ensureNewCardsQueue(userID string) error {
 			log.Printf("⚠️ Failed to create review for card %s: %v", question.ID, err)
 			continue
 		}
+		services.ChargeNewQuota(quotas, question.Difficulty)
+
+		// A sibling answered earlier today would give this one away
+		if userStats.BurySiblings {
+			if err := database.BuryIfSiblingStudiedToday(userID, question.ID); err != nil {
+				log.Printf("⚠️ Failed to bury new card %s: %v", question.ID, err)
+			}
+		}
 	}
 
 	// Refresh stats and maybe fetch more questions
@@ -89,85 +131,128 @@ func (h *ReviewHandler) ensureNewCardsQueue(userID string) error {
 	return nil
 }
 
-// GetNextCard retrieves the next card following Anki's priority order
-// Priority: 1. New cards (within daily limit, USER REQUESTED PRIORITY) -> 2. Learning cards -> 3. Review cards
+// GetNextCard retrieves the next card following Anki's queue order
+// Learning cards that are due always come first (they're on a short clock). New cards then go
+// before, after or mixed in with reviews, per the user's new_card_position setting; reviews are
+// sorted by review_sort and stop at the daily review cap.
+// ?deck_id= restricts every queue to that deck and applies its limits
 func (h *ReviewHandler) GetNextCard(c *gin.Context) {
 	userID := c.GetString("user_id")
 
-	// Fill queue if needed
-	if err := h.ensureNewCardsQueue(userID); err != nil {
-		log.Printf("⚠️ Failed to ensure new cards queue: %v", err)
-	}
-
-	// Get counts
-	dueCounts, err := database.GetDueCountsByType(userID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch due counts"})
+	deck, ok := deckFromQuery(c, userID)
+	if !ok {
 		return
 	}
+	deckID := ""
+	if deck != nil {
+		deckID = deck.ID
+	}
 
-	// PRIORITY 1: New cards
-	newCard, err := database.GetNextNewCardReview(userID)
+	userStats, err := database.GetUserStats(userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch new card"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user settings"})
 		return
 	}
-	if newCard != nil {
+
+	// Due dates are paused while on vacation
+	if vacation := settleVacations(userID); vacation != nil {
 		c.JSON(http.StatusOK, models.NextCardResponse{
-			Card:      newCard,
-			Type:      "new",
-			Message:   "New card to learn",
-			DueCounts: *dueCounts,
+			Message:  vacationMessage(vacation),
+			DeckID:   deckID,
+			Vacation: vacation,
 		})
 		return
 	}
 
-	// PRIORITY 2: Learning cards
-	learningCard, err := database.GetNextLearningCard(userID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch learning card"})
-		return
-	}
-	if learningCard != nil {
-		c.JSON(http.StatusOK, models.NextCardResponse{
-			Card:      learningCard,
-			Type:      "learning",
-			Message:   "Continue learning this card",
-			DueCounts: *dueCounts,
-		})
-		return
+	// Fill queue if needed
+	if err := h.ensureNewCardsQueue(userID, deck); err != nil {
+		log.Printf("⚠️ Failed to ensure new cards queue: %v", err)
 	}
 
-	// PRIORITY 3: Review cards
-	reviewCard, err := database.GetNextReviewCard(userID)
+	// Get counts
+	dueCounts, err := database.GetDueCountsByType(userID, deck)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch review card"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch due counts"})
 		return
 	}
-	if reviewCard != nil {
-		c.JSON(http.StatusOK, models.NextCardResponse{
-			Card:      reviewCard,
-			Type:      "review",
-			Message:   "Review this card",
-			DueCounts: *dueCounts,
-		})
-		return
+
+	type queue struct {
+		cardType string
+		message  string
+		fetch    func() (*models.Card, error)
+	}
+	learningQueue := queue{"learning", "Continue learning this card", func() (*models.Card, error) {
+		return database.GetNextLearningCard(userID, deck)
+	}}
+	newQueue := queue{"new", "New card to learn", func() (*models.Card, error) {
+		return database.GetNextNewCardReview(userID, deck)
+	}}
+	reviewQueue := queue{"review", "Review this card", func() (*models.Card, error) {
+		if dueCounts.ReviewsDue == 0 {
+			return nil, nil // Nothing due, or the daily review cap is reached
+		}
+		return database.GetNextReviewCard(userID, deck, userStats.ReviewSort)
+	}}
+
+	queues := []queue{learningQueue, newQueue, reviewQueue}
+	switch userStats.NewCardPosition {
+	case "after":
+		queues = []queue{learningQueue, reviewQueue, newQueue}
+	case "mixed":
+		answeredToday := 0
+		if today, err := database.GetTodayStats(userID); err == nil {
+			answeredToday = today.ReviewsDone
+		}
+		if !services.NewCardTurn(answeredToday, dueCounts.NewStudiedToday, dueCounts.NewAvailable, dueCounts.ReviewsDue) {
+			queues = []queue{learningQueue, reviewQueue, newQueue}
+		}
+	}
+
+	for _, q := range queues {
+		card, err := q.fetch()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to fetch %s card", q.cardType)})
+			return
+		}
+		if card != nil {
+			loadCardMetadata(card)
+			c.JSON(http.StatusOK, models.NextCardResponse{
+				Card:      card,
+				Type:      q.cardType,
+				Message:   q.message,
+				DueCounts: *dueCounts,
+				DeckID:    deckID,
+			})
+			return
+		}
 	}
 
 	// No cards available
-	nextCardTime, err := database.GetNextDueCardTime(userID)
+	nextCardTime, err := database.GetNextDueCardTime(userID, deck)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch next due time"})
 		return
 	}
 
-	userStats, err := database.GetUserStats(userID)
-	newCardsLimit := config.AppConfig.NewCardsPerDay
-	if err == nil && userStats != nil {
-		newCardsLimit = userStats.NewCardsLimit
+	newCardsLimit := userStats.NewCardsLimit
+	if deck != nil && deck.NewPerDay != nil {
+		newCardsLimit = *deck.NewPerDay
 	}
 
-	if nextCardTime == nil {
+	if dueCounts.ReviewLimitReached {
+		message := fmt.Sprintf("You've reached your daily limit of %d reviews.", userStats.ReviewsLimit)
+		if deck != nil && deck.ReviewsPerDay != nil && *deck.ReviewsPerDay < userStats.ReviewsLimit {
+			message = fmt.Sprintf("You've reached this deck's daily limit of %d reviews.", *deck.ReviewsPerDay)
+		}
+		c.JSON(http.StatusOK, models.NextCardResponse{
+			Card:          nil,
+			Type:          "",
+			Message:       message,
+			NextCardDueAt: nil,
+			DueCounts:     *dueCounts,
+			DeckID:        deckID,
+		})
+	} else if nextCardTime == nil {
 		message := "🎉 Congratulations! You've studied all available cards."
 		if dueCounts.NewStudiedToday >= newCardsLimit {
 			message += fmt.Sprintf(" You also reached your daily limit of %d new cards!", newCardsLimit)
@@ -178,6 +263,7 @@ func (h *ReviewHandler) GetNextCard(c *gin.Context) {
 			Message:       message,
 			NextCardDueAt: nil,
 			DueCounts:     *dueCounts,
+			DeckID:        deckID,
 		})
 	} else {
 		timeUntil := time.Until(*nextCardTime)
@@ -188,6 +274,7 @@ func (h *ReviewHandler) GetNextCard(c *gin.Context) {
 			Message:       message,
 			NextCardDueAt: nextCardTime,
 			DueCounts:     *dueCounts,
+			DeckID:        deckID,
 		})
 	}
 }
@@ -203,7 +290,7 @@ func (h *ReviewHandler) SubmitAnswer(c *gin.Context) {
 	}
 
 	// Get question
-	question, err := database.GetQuestionByID(req.QuestionID)
+	question, err := getVisibleQuestion(userID, req.QuestionID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Question not found"})
 		return
@@ -221,9 +308,55 @@ func (h *ReviewHandler) SubmitAnswer(c *gin.Context) {
 		return
 	}
 
+	// Inside a practice-only session the answer is graded but the card keeps its schedule
+	session, ok := studySessionForAnswer(c, userID, req.SessionID, req.QuestionID)
+	if !ok {
+		return
+	}
+	practice := session != nil && !session.Reschedule
+
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	// Attach the voice transcription (raw + enhanced) if the answer was dictated
+	var transcription *models.Transcription
+	if req.TranscriptionID != "" {
+		transcription, err = database.GetTranscription(userID, req.TranscriptionID)
+		if err != nil {
+			log.Printf("⚠️ Failed to fetch transcription %s: %v", req.TranscriptionID, err)
+			transcription = nil
+		}
+	}
+
+	// ORAL MODE: grade delivery from the raw transcript alongside correctness scoring
+	deliveryDone := make(chan struct{})
+	var communicationScores *models.CommunicationScores
+	var deliveryFeedback string
+	if req.Mode == "oral" && transcription != nil {
+		go func() {
+			defer close(deliveryDone)
+			metrics := transcription.Metrics
+			if metrics == nil {
+				metrics = &models.SpeechMetrics{DurationSeconds: transcription.DurationSeconds}
+			}
+			var err error
+			communicationScores, deliveryFeedback, err = h.llmService.GradeDelivery(ctx, question.Title, transcription.RawText, metrics)
+			if err != nil {
+				// Correctness grading still counts without delivery feedback
+				log.Printf("⚠️ Delivery grading failed: %v", err)
+			}
+		}()
+	} else {
+		close(deliveryDone)
+	}
+
+	// Previous attempt lets the tutor say what improved or regressed (nil on first attempt)
+	previousAttempt, err := database.GetLatestAttempt(userID, req.QuestionID)
+	if err != nil {
+		log.Printf("⚠️ Failed to fetch previous attempt: %v", err)
+		previousAttempt = nil
+	}
+
 	var score int
 	var feedback string
 	var correctApproach string
@@ -241,6 +374,7 @@ func (h *ReviewHandler) SubmitAnswer(c *gin.Context) {
 			question.DescriptionMarkdown,
 			req.Answer,
 			question.SolutionBreakdown,
+			previousAttempt,
 		)
 
 		if err != nil {
@@ -255,7 +389,8 @@ func (h *ReviewHandler) SubmitAnswer(c *gin.Context) {
 
 		log.Printf("⚡ Fast scoring complete in ~3-5s")
 	} else {
-		// 🐢 SLOW PATH: First time seeing this question, generate full solution (~16s)
+		// 🐢 SLOW PATH: Breakdown not pre-generated yet, generate full solution (~16s)
+		// Run cmd/pregen-solutions (or POST /api/admin/pregen-solutions) to avoid this
 		log.Printf("🔄 No cached solution for question %s - FULL scoring and caching", question.ID)
 
 		score, feedback, correctApproach, subScores, solutionBreakdown, err = h.llmService.ScoreAnswer(
@@ -288,43 +423,34 @@ func (h *ReviewHandler) SubmitAnswer(c *gin.Context) {
 	log.Printf("📝 Feedback: %s", feedback)
 	log.Printf("📈 SubScores: %+v", subScores)
 
-	// Capture state BEFORE calculation to check for graduation
-	wasLearning := review.CardState == "learning" || review.CardState == "relearning" || review.CardState == "new"
-	wasMature := review.CardState == "review" && review.IntervalDays > 21
+	// Delivery doesn't affect scheduling: the SRS tracks whether they know the solution
+	<-deliveryDone
 
-	// Update review using SM-2 algorithm
-	h.srsService.CalculateNextReview(review, score)
+	// Snapshot for undo before anything about the card, coins or streak changes
+	undo := h.undoSnapshot(userID, "submit", review, session)
+	before := *review
 
-	// Save updated review
-	err = database.UpdateReview(review)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update review"})
-		return
-	}
+	if !practice {
+		// Update review using SM-2 algorithm (a card answered before it's due counts as an early review)
+		h.srsService.CalculateNextReview(review, score)
 
-	// GAMIFICATION: Calculate Coins
-	coinsEarned := 0
-
-	// Base reward for doing a review (non-zero score)
-	if score > 0 {
-		coinsEarned += 1
-	}
-
-	// Check for bonuses
-	isNowReview := review.CardState == "review"
-	isNowMature := review.CardState == "review" && review.IntervalDays > 21
+		// Save updated review
+		err = database.UpdateReview(review)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update review"})
+			return
+		}
 
-	// Bonus 1: Graduation (Learning -> Review)
-	if wasLearning && isNowReview {
-		coinsEarned += 10
-		log.Printf("💰 GRADUATION BONUS: +10 coins for user %s", userID)
+		buried := h.burySiblings(userID, req.QuestionID)
+		if undo != nil {
+			undo.BuriedIDs = buried
+		}
 	}
 
-	// Bonus 2: Maturity (Young -> Mature)
-	// We check if it WAS NOT mature and IS NOW mature
-	if !wasMature && isNowMature {
-		coinsEarned += 10
-		log.Printf("💰 MATURITY BONUS: +10 coins for user %s", userID)
+	// GAMIFICATION: Calculate Coins (base reward plus graduation and maturity bonuses)
+	coinsEarned := services.AnswerCoins(&before, review, score)
+	if coinsEarned > 1 {
+		log.Printf("💰 BONUS: +%d coins for user %s", coinsEarned, userID)
 	}
 
 	// Update user coins
@@ -359,6 +485,17 @@ func (h *ReviewHandler) SubmitAnswer(c *gin.Context) {
 		IntervalMinutes:   review.IntervalMinutes,
 		IntervalDays:      review.IntervalDays,
 		TimeSpentSeconds:  req.TimeSpentSeconds,
+		Transcription:     transcription,
+
+		CommunicationScores: communicationScores,
+		DeliveryFeedback:    deliveryFeedback,
+		Practice:            practice,
+	}
+	if session != nil {
+		history.SessionID = &session.ID
+	}
+	if !practice {
+		history.PreReview = &before
 	}
 
 	err = database.CreateHistory(history)
@@ -367,6 +504,26 @@ func (h *ReviewHandler) SubmitAnswer(c *gin.Context) {
 		log.Printf("⚠️ Failed to save history: %v", err)
 	} else {
 		log.Printf("✅ History saved successfully: ID=%s", history.ID)
+		if undo != nil {
+			undo.HistoryID = &history.ID
+		}
+	}
+
+	if !practice {
+		entry := database.NewReviewLogEntry("submit", &before, review)
+		entry.Rating = &score
+		if history.ID != "" {
+			entry.HistoryID = &history.ID
+		}
+		if recordReviewEvent(entry) && undo != nil {
+			undo.LogID = &entry.ID
+		}
+	}
+
+	if session != nil {
+		if err := database.MarkStudySessionCardAnswered(session.ID, req.QuestionID, score); err != nil {
+			log.Printf("⚠️ Failed to mark session card answered: %v", err)
+		}
 	}
 
 	// Refresh user stats
@@ -378,6 +535,14 @@ func (h *ReviewHandler) SubmitAnswer(c *gin.Context) {
 		log.Printf("⚠️ Failed to update streak: %v", err)
 	}
 
+	h.pushUndo(undo, review)
+
+	// Self-rating buttons, with the grader's pick preselected
+	var ratings []models.RatingOption
+	if !practice {
+		ratings = h.srsService.PreviewRatings(&before)
+	}
+
 	// Return response with enhanced info
 	c.JSON(http.StatusOK, models.SubmitAnswerResponse{
 		Score:             score,
@@ -392,6 +557,13 @@ func (h *ReviewHandler) SubmitAnswer(c *gin.Context) {
 		CoinsEarned:       coinsEarned,
 		TotalCoins:        newTotalCoins,
 		CurrentStreak:     currentStreak,
+		Practice:          practice,
+		HistoryID:         history.ID,
+		Ratings:           ratings,
+		SuggestedRating:   services.SelfRatingForScore(score),
+
+		CommunicationScores: communicationScores,
+		DeliveryFeedback:    deliveryFeedback,
 	})
 }
 
@@ -417,22 +589,58 @@ func (h *ReviewHandler) SkipCard(c *gin.Context) {
 		return
 	}
 
-	// Treat skip as "Again" (score 0 = failed)
-	h.srsService.CalculateNextReview(review, 0)
-
-	// Save updated review
-	err = database.UpdateReview(review)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update review"})
+	session, ok := studySessionForAnswer(c, userID, req.SessionID, req.QuestionID)
+	if !ok {
 		return
 	}
+	undo := h.undoSnapshot(userID, "skip", review, session)
 
-	// Refresh user stats
-	_ = database.RefreshUserStats(userID)
+	message := "Card skipped and marked as 'Again'"
+	if session != nil && !session.Reschedule {
+		// Practice-only: move on without touching the schedule
+		message = "Card skipped (practice session, schedule unchanged)"
+	} else {
+		before := *review
+
+		// Treat skip as "Again" (score 0 = failed)
+		h.srsService.CalculateNextReview(review, 0)
+
+		// Save updated review
+		err = database.UpdateReview(review)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update review"})
+			return
+		}
+
+		entry := database.NewReviewLogEntry("skip", &before, review)
+		rating := 0
+		entry.Rating = &rating
+		if recordReviewEvent(entry) && undo != nil {
+			undo.LogID = &entry.ID
+		}
+
+		// Skipping still reveals the card, so its siblings are buried too
+		buried := h.burySiblings(userID, req.QuestionID)
+		if undo != nil {
+			undo.BuriedIDs = buried
+		}
+
+		// Refresh user stats
+		_ = database.RefreshUserStats(userID)
+	}
+
+	// Only once the schedule is saved, so a failed skip leaves the card in the session
+	if session != nil {
+		if err := database.MarkStudySessionCardAnswered(session.ID, req.QuestionID, 0); err != nil {
+			log.Printf("⚠️ Failed to mark session card answered: %v", err)
+		}
+	}
+
+	h.pushUndo(undo, review)
 
 	// Return response
 	c.JSON(http.StatusOK, gin.H{
-		"message":          "Card skipped and marked as 'Again'",
+		"message":          message,
 		"next_review_at":   review.NextReviewAt,
 		"card_state":       review.CardState,
 		"interval_minutes": review.IntervalMinutes,
@@ -440,61 +648,244 @@ func (h *ReviewHandler) SkipCard(c *gin.Context) {
 	})
 }
 
-// checkAndRefreshProblems triggers background refresh if problem pool is low
-func (h *ReviewHandler) checkAndRefreshProblems(userID string) {
-	count, err := database.GetUnusedProblemCount(userID)
-	if err != nil || count > 20 {
-		return // Enough problems available
+// UndoLast handles POST /api/review/undo
+// Restores the card, coins and streak from before the latest submit or skip and removes its
+// graded attempt; up to database.MaxUndoActions actions back within the current sitting
+func (h *ReviewHandler) UndoLast(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	action, err := database.UndoLastAction(userID)
+	if errors.Is(err, database.ErrUndoStale) {
+		c.JSON(http.StatusConflict, gin.H{"error": "The card has been rescheduled since this action"})
+		return
 	}
+	if err != nil {
+		log.Printf("❌ Failed to undo for user %s: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to undo"})
+		return
+	}
+	if action == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Nothing to undo"})
+		return
+	}
+
+	_ = database.RefreshUserStats(userID)
+	log.Printf("↩️ Undid %s of question %s for user %s", action.Action, action.QuestionID, userID)
 
-	// Problem pool is low, fetch more
-	// This runs in background, doesn't block user
-	problems, err := h.leetcodeService.FetchRandomProblems(5, 10, 5)
+	remaining, err := database.CountUndoActions(userID)
 	if err != nil {
-		return // Silently fail, not critical
+		remaining = 0
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        fmt.Sprintf("Undid %s", action.Action),
+		"action":         action.Action,
+		"question_id":    action.QuestionID,
+		"session_id":     action.SessionID,
+		"review":         action.Review,
+		"total_coins":    action.Coins,
+		"current_streak": action.CurrentStreak,
+		"undo_remaining": remaining,
+	})
+}
+
+// RebalanceBacklog handles POST /api/review/rebalance?deck_id=
+// Spreads overdue review cards over the next `days` days (today included), lowest predicted recall first
+func (h *ReviewHandler) RebalanceBacklog(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	deck, ok := deckFromQuery(c, userID)
+	if !ok {
+		return
 	}
 
-	// Insert new problems
-	for _, problem := range problems {
-		_ = h.insertProblem(problem) // Ignore errors (duplicates are fine)
+	var req struct {
+		Days int `json:"days" binding:"required"`
 	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	if req.Days < 1 || req.Days > database.MaxRebalanceDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("days must be between 1 and %d", database.MaxRebalanceDays)})
+		return
+	}
+
+	result, err := database.RebalanceBacklog(userID, deck, req.Days)
+	if err != nil {
+		log.Printf("❌ Failed to rebalance backlog for user %s: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rebalance backlog"})
+		return
+	}
+	if result.Rescheduled > 0 {
+		log.Printf("📆 Spread %d overdue cards over %d days for user %s", result.Overdue, result.Days, userID)
+		_ = database.RefreshUserStats(userID)
+	}
+
+	c.JSON(http.StatusOK, result)
 }
 
-// insertProblem helper to insert a LeetCode problem
-func (h *ReviewHandler) insertProblem(problem *services.LeetCodeProblem) error {
-	topics := make([]string, len(problem.TopicTags))
-	for i, tag := range problem.TopicTags {
-		topics[i] = tag.Name
-	}
-
-	leetcodeID := 0
-	if _, err := fmt.Sscanf(problem.QuestionID, "%d", &leetcodeID); err != nil {
-		return fmt.Errorf("failed to parse LeetCode ID from %s: %w", problem.QuestionID, err)
-	}
-
-	descriptionMarkdown := services.StripHTMLTags(problem.Content)
-
-	query := `
-		INSERT INTO questions 
-		(leetcode_id, title, slug, difficulty, description_markdown, topics)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		ON CONFLICT (leetcode_id) 
-		DO UPDATE SET 
-			description_markdown = EXCLUDED.description_markdown,
-			topics = EXCLUDED.topics
-	`
-
-	_, err := database.DB.Exec(
-		query,
-		leetcodeID,
-		problem.Title,
-		problem.TitleSlug,
-		problem.Difficulty,
-		descriptionMarkdown,
-		topics,
-	)
+// RateAnswer handles POST /api/review/:history_id/rate
+// Replaces the grader's score with the user's Again/Hard/Good/Easy: the card is rescheduled from its
+// state before the answer, as of when it was given. Allowed until the card is answered or rescheduled
+// again. Coins follow the rating; the streak doesn't depend on the score, so it's left alone.
+func (h *ReviewHandler) RateAnswer(c *gin.Context) {
+	userID := c.GetString("user_id")
+	historyID := c.Param("history_id")
+
+	var req models.RateAnswerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	rating, ok := services.SelfRatingScore(req.Rating)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "rating must be again, hard, good or easy"})
+		return
+	}
+
+	attempt, err := database.GetHistoryForRating(userID, historyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch answer"})
+		return
+	}
+	if attempt == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Answer not found"})
+		return
+	}
+	if attempt.PreReview == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "This answer didn't schedule the card and can't be rated"})
+		return
+	}
+
+	// The schedule (and coins) the answer currently has: the grader's, or an earlier self-rating's
+	currentScore := attempt.Score
+	if attempt.UserRating != nil {
+		currentScore = *attempt.UserRating
+	}
+	current := *attempt.PreReview
+	h.srsService.CalculateNextReviewAt(&current, currentScore, attempt.SubmittedAt)
+
+	rescheduled := *attempt.PreReview
+	h.srsService.CalculateNextReviewAt(&rescheduled, rating, attempt.SubmittedAt)
+
+	coinsDelta := services.AnswerCoins(attempt.PreReview, &rescheduled, rating) -
+		services.AnswerCoins(attempt.PreReview, &current, currentScore)
+
+	if _, err := database.ApplySelfRating(attempt, &rescheduled, rating, coinsDelta); err != nil {
+		if errors.Is(err, database.ErrRatingStale) {
+			c.JSON(http.StatusConflict, gin.H{"error": "The card has been rescheduled since this answer"})
+			return
+		}
+		log.Printf("❌ Failed to apply rating to %s: %v", historyID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply rating"})
+		return
+	}
+
+	overridden := services.SelfRatingForScore(attempt.Score) != req.Rating
+	if overridden {
+		log.Printf("🎚️ User %s rated %s %q (grader score %d)", userID, historyID, req.Rating, attempt.Score)
+	}
+
+	_ = database.RefreshUserStats(userID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"history_id":       attempt.ID,
+		"rating":           req.Rating,
+		"user_rating":      rating,
+		"score":            attempt.Score,
+		"overridden":       overridden,
+		"coins_delta":      coinsDelta,
+		"next_review_at":   rescheduled.NextReviewAt,
+		"card_state":       rescheduled.CardState,
+		"interval_minutes": rescheduled.IntervalMinutes,
+		"interval_days":    rescheduled.IntervalDays,
+	})
+}
+
+// undoSnapshot captures what an answer or skip is about to change; nil (no undo) if stats can't be read
+func (h *ReviewHandler) undoSnapshot(userID, action string, review *models.Review, session *models.StudySession) *models.UndoAction {
+	stats, err := database.GetUserStats(userID)
+	if err != nil {
+		log.Printf("⚠️ Failed to snapshot stats for undo: %v", err)
+		return nil
+	}
+
+	undo := &models.UndoAction{
+		UserID:         userID,
+		QuestionID:     review.QuestionID,
+		Action:         action,
+		Review:         *review,
+		Coins:          stats.Coins,
+		CurrentStreak:  stats.CurrentStreak,
+		MaxStreak:      stats.MaxStreak,
+		LastStreakDate: stats.LastStreakDate,
+	}
+	if session != nil {
+		undo.SessionID = &session.ID
+	}
+	return undo
+}
+
+// pushUndo saves an undo snapshot along with the schedule the action left; best effort,
+// the answer itself already went through
+func (h *ReviewHandler) pushUndo(undo *models.UndoAction, after *models.Review) {
+	if undo == nil {
+		return
+	}
+	undo.AfterLastReviewedAt = after.LastReviewedAt
+	undo.AfterNextReviewAt = &after.NextReviewAt
+	if err := database.PushUndoAction(undo); err != nil {
+		log.Printf("⚠️ Failed to save undo snapshot: %v", err)
+	}
+}
+
+// recordReviewEvent appends to the review log; best effort like history, reports whether it was saved
+func recordReviewEvent(entry *models.ReviewLogEntry) bool {
+	if err := database.LogReviewEvent(entry); err != nil {
+		log.Printf("⚠️ Failed to log %s of %s: %v", entry.Event, entry.QuestionID, err)
+		return false
+	}
+	return true
+}
+
+// burySiblings defers similar new/review cards to tomorrow if the user enabled it
+// Keeps near-duplicate problems from leaking each other's answers in one session.
+// Returns the buried question IDs so an undo can bring them back.
+func (h *ReviewHandler) burySiblings(userID, questionID string) []string {
+	stats, err := database.GetUserStats(userID)
+	if err != nil || !stats.BurySiblings {
+		return nil
+	}
+
+	buried, err := database.BuryRelatedCards(userID, questionID)
+	if err != nil {
+		log.Printf("⚠️ Failed to bury siblings of %s: %v", questionID, err)
+		return nil
+	}
+	if len(buried) > 0 {
+		log.Printf("🪦 Buried %d sibling cards of %s until tomorrow", len(buried), questionID)
+	}
+	return buried
+}
+
+// checkAndRefreshProblems queues a background refill if the user's problem pool is low
+// The refill worker coalesces requests, so calling this on every card is cheap
+func (h *ReviewHandler) checkAndRefreshProblems(userID string) {
+	count, err := database.GetUnusedProblemCount(userID)
+	if err != nil || count > h.refiller.Threshold() {
+		return // Enough problems available
+	}
+
+	h.refiller.Request(userID)
+}
 
-	return err
+// loadCardMetadata attaches hints, examples and starter code for the study page
+// Best effort: the card is still usable without them
+func loadCardMetadata(card *models.Card) {
+	if err := database.LoadQuestionMetadata(&card.Question); err != nil {
+		log.Printf("⚠️ Failed to load metadata for question %s: %v", card.Question.ID, err)
+	}
 }
 
 // Helper function to format duration nicely
@@ -526,10 +917,11 @@ func formatDuration(d time.Duration) string {
 // GetSolutionBreakdown returns the solution breakdown for a question
 // If not cached, it generates it in the background
 func (h *ReviewHandler) GetSolutionBreakdown(c *gin.Context) {
+	userID := c.GetString("user_id")
 	questionID := c.Param("questionId")
 
 	// Get question
-	question, err := database.GetQuestionByID(questionID)
+	question, err := getVisibleQuestion(userID, questionID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Question not found"})
 		return
@@ -550,11 +942,10 @@ func (h *ReviewHandler) GetSolutionBreakdown(c *gin.Context) {
 
 	log.Printf("🔄 Generating solution breakdown for question %s", question.ID)
 
-	_, _, _, _, solutionBreakdown, err := h.llmService.ScoreAnswer(
+	solutionBreakdown, _, err := h.llmService.GenerateSolution(
 		ctx,
 		question.Title,
 		question.DescriptionMarkdown,
-		"", // Empty answer - we just want the solution
 	)
 
 	if err != nil {
@@ -577,3 +968,124 @@ func (h *ReviewHandler) GetSolutionBreakdown(c *gin.Context) {
 		"cached":             false,
 	})
 }
+
+// GetReferenceImplementation returns a reference implementation of the solution in one language
+// Defaults to the user's preferred language. Generated on first request, optionally validated
+// in the container sandbox against the description's examples, then cached on the question.
+func (h *ReviewHandler) GetReferenceImplementation(c *gin.Context) {
+	userID := c.GetString("user_id")
+	questionID := c.Param("questionId")
+
+	language := c.Query("language")
+	if language == "" {
+		language = "python"
+		if stats, err := database.GetUserStats(userID); err == nil && stats.PreferredLanguage != "" {
+			language = stats.PreferredLanguage
+		}
+	}
+	if _, ok := services.SupportedLanguages[language]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Unsupported language: %s", language)})
+		return
+	}
+
+	question, err := getVisibleQuestion(userID, questionID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Question not found"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 90*time.Second)
+	defer cancel()
+
+	// The implementation follows the cached breakdown, so make sure there is one
+	solution := question.SolutionBreakdown
+	if solution == nil {
+		solution, _, err = h.llmService.GenerateSolution(ctx, question.Title, question.DescriptionMarkdown)
+		if err != nil {
+			log.Printf("❌ Failed to generate solution breakdown: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate solution breakdown"})
+			return
+		}
+		if err := database.UpdateQuestionSolution(question.ID, solution); err != nil {
+			log.Printf("⚠️ Failed to cache solution breakdown: %v", err)
+		}
+	}
+
+	if impl, ok := solution.Implementations[language]; ok && impl != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"implementation": impl,
+			"cached":         true,
+		})
+		return
+	}
+
+	impl, err := h.generateImplementation(ctx, question, solution, language)
+	if err != nil {
+		log.Printf("❌ Failed to generate %s implementation: %v", language, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate implementation"})
+		return
+	}
+
+	// Only cache code that passed validation (or couldn't be validated at all)
+	cacheable := impl.Validated || impl.ValidationOutput == ""
+	if cacheable {
+		if err := database.UpdateQuestionImplementation(question.ID, language, impl); err != nil {
+			log.Printf("⚠️ Failed to cache %s implementation: %v", language, err)
+		} else {
+			log.Printf("✅ %s implementation cached for question %s", language, question.ID)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"implementation": impl,
+		"cached":         false,
+	})
+}
+
+// generateImplementation asks the LLM for code and, if the sandbox is enabled, retries until it passes
+// Code for custom questions is never run: their descriptions are user-written and can steer the LLM.
+func (h *ReviewHandler) generateImplementation(ctx context.Context, question *models.Question, solution *models.SolutionBreakdown, language string) (*models.ReferenceImplementation, error) {
+	var impl *models.ReferenceImplementation
+	previousFailure := ""
+
+	for attempt := 1; attempt <= maxImplementationAttempts; attempt++ {
+		code, harness, err := h.llmService.GenerateImplementation(ctx, question.Title, question.DescriptionMarkdown, solution, language, previousFailure)
+		if err != nil {
+			return nil, err
+		}
+
+		impl = &models.ReferenceImplementation{
+			Language:    language,
+			Code:        code,
+			GeneratedAt: time.Now(),
+		}
+
+		if !config.AppConfig.SandboxEnabled || harness == "" || question.OwnerID != nil {
+			return impl, nil
+		}
+
+		result, err := h.sandbox.Run(ctx, language, harness)
+		if errors.Is(err, services.ErrToolchainUnavailable) {
+			log.Printf("⚠️ Skipping validation: %v", err)
+			return impl, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("sandbox error: %w", err)
+		}
+
+		impl.ValidationOutput = result.Output
+		if result.Passed {
+			impl.Validated = true
+			log.Printf("🧪 %s implementation passed sample tests (attempt %d, %s)", language, attempt, result.Duration)
+			return impl, nil
+		}
+
+		log.Printf("🧪 %s implementation failed sample tests (attempt %d)", language, attempt)
+		if impl.ValidationOutput == "" {
+			impl.ValidationOutput = "test harness exited with a non-zero status"
+		}
+		previousFailure = impl.ValidationOutput
+	}
+
+	return impl, nil
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"leetcode-anki/backend/internal/database"
+	"leetcode-anki/backend/internal/models"
+	"leetcode-anki/backend/internal/services"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StudySessionsHandler struct{}
+
+func NewStudySessionsHandler() *StudySessionsHandler {
+	return &StudySessionsHandler{}
+}
+
+// GetSessions handles GET /api/sessions
+func (h *StudySessionsHandler) GetSessions(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	sessions, err := database.GetStudySessions(userID, 50)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+// CreateSession handles POST /api/sessions
+// The matching cards are picked now; answering them later doesn't change the selection
+func (h *StudySessionsHandler) CreateSession(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req services.StudySessionInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	session, err := req.ToStudySession(userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := database.CreateStudySession(session); err != nil {
+		log.Printf("❌ Failed to create study session: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
+		return
+	}
+	if session.CardCount == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No cards match this search"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"session": session})
+}
+
+// GetSession handles GET /api/sessions/:id
+func (h *StudySessionsHandler) GetSession(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	session, err := database.GetStudySession(userID, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch session"})
+		return
+	}
+	if session == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"session": session})
+}
+
+// GetNextCard handles GET /api/sessions/:id/next
+// Answers go through the usual submit/skip endpoints with session_id set
+func (h *StudySessionsHandler) GetNextCard(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	session, err := database.GetStudySession(userID, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch session"})
+		return
+	}
+	if session == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+
+	// A rescheduling session would move due dates paused by the vacation; practice-only ones can go on
+	if session.Reschedule {
+		if vacation := settleVacations(userID); vacation != nil {
+			c.JSON(http.StatusOK, gin.H{
+				"card":     nil,
+				"session":  session,
+				"vacation": vacation,
+				"message":  vacationMessage(vacation),
+			})
+			return
+		}
+	}
+
+	var card *models.Card
+	if session.FinishedAt == nil {
+		card, err = database.GetNextSessionCard(userID, session.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch card"})
+			return
+		}
+	}
+	if card == nil {
+		c.JSON(http.StatusOK, gin.H{
+			"card":    nil,
+			"session": session,
+			"message": "🎉 Session complete!",
+		})
+		return
+	}
+
+	loadCardMetadata(card)
+	c.JSON(http.StatusOK, gin.H{
+		"card":      card,
+		"session":   session,
+		"remaining": session.CardCount - session.Answered,
+	})
+}
+
+// FinishSession handles POST /api/sessions/:id/finish; unanswered cards are left as they were
+func (h *StudySessionsHandler) FinishSession(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	found, err := database.FinishStudySession(userID, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to finish session"})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found or already finished"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session finished"})
+}
+
+// studySessionForAnswer loads the session an answer belongs to (nil without one)
+// and checks the card is still pending in it; writes the error response if not
+func studySessionForAnswer(c *gin.Context, userID, sessionID, questionID string) (*models.StudySession, bool) {
+	if sessionID == "" {
+		return nil, true
+	}
+
+	session, err := database.GetStudySession(userID, sessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch session"})
+		return nil, false
+	}
+	if session == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return nil, false
+	}
+	if session.FinishedAt != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Session is already finished"})
+		return nil, false
+	}
+
+	pending, err := database.IsStudySessionCard(session.ID, questionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch session"})
+		return nil, false
+	}
+	if !pending {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Card is not pending in this session"})
+		return nil, false
+	}
+
+	return session, true
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"leetcode-anki/backend/internal/database"
+	"leetcode-anki/backend/internal/services"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -38,3 +39,209 @@ func (h *SettingsHandler) UpdateDailyLimit(c *gin.Context) {
 		"new_cards_limit": req.NewCardsLimit,
 	})
 }
+
+type UpdateLanguageRequest struct {
+	Language string `json:"language" binding:"required"`
+}
+
+// UpdatePreferredLanguage updates the language used for reference implementations
+func (h *SettingsHandler) UpdatePreferredLanguage(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req UpdateLanguageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	if _, ok := services.SupportedLanguages[req.Language]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported language. Must be one of: python, java, go."})
+		return
+	}
+
+	if err := database.UpdateUserLanguage(userID, req.Language); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update language"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":            "Preferred language updated successfully",
+		"preferred_language": req.Language,
+	})
+}
+
+type UpdateTranscriptionLanguageRequest struct {
+	Language string `json:"language" binding:"required,max=8"`
+}
+
+// UpdateTranscriptionLanguage updates the speech recognition language for voice answers
+// Accepts an ISO-639-1 code (e.g. "en", "id") or "auto" to let the recognizer detect it
+func (h *SettingsHandler) UpdateTranscriptionLanguage(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req UpdateTranscriptionLanguageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid language. Use an ISO-639-1 code or \"auto\"."})
+		return
+	}
+
+	if req.Language != "auto" && len(req.Language) != 2 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid language. Use an ISO-639-1 code or \"auto\"."})
+		return
+	}
+
+	if err := database.UpdateUserTranscriptionLanguage(userID, req.Language); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update transcription language"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":                "Transcription language updated successfully",
+		"transcription_language": req.Language,
+	})
+}
+
+type UpdateBurySiblingsRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// UpdateBurySiblings toggles burying related cards for the rest of the day after one is reviewed
+func (h *SettingsHandler) UpdateBurySiblings(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req UpdateBurySiblingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request. Expected {\"enabled\": true|false}."})
+		return
+	}
+
+	if err := database.UpdateUserBurySiblings(userID, *req.Enabled); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update bury siblings setting"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Bury siblings setting updated successfully",
+		"bury_siblings": *req.Enabled,
+	})
+}
+
+type UpdateQueueSettingsRequest struct {
+	NewCardPosition *string `json:"new_card_position"`
+	ReviewSort      *string `json:"review_sort"`
+	NewGatherOrder  *string `json:"new_gather_order"`
+	ReviewsLimit    *int    `json:"reviews_limit" binding:"omitempty,min=0,max=9999"`
+	// Drops a custom reviews_limit so the server default applies again
+	ResetReviewsLimit bool `json:"reset_reviews_limit"`
+}
+
+// UpdateQueueSettings changes how the study queue is ordered and capped; omitted fields are kept
+func (h *SettingsHandler) UpdateQueueSettings(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req UpdateQueueSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request. reviews_limit must be between 0 and 9999."})
+		return
+	}
+	if req.ResetReviewsLimit && req.ReviewsLimit != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Set reviews_limit or reset_reviews_limit, not both"})
+		return
+	}
+
+	if req.NewCardPosition != nil {
+		if _, ok := services.NewCardPositions[*req.NewCardPosition]; !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid new_card_position", "options": services.NewCardPositions})
+			return
+		}
+	}
+	if req.ReviewSort != nil {
+		if _, ok := services.ReviewSorts[*req.ReviewSort]; !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review_sort", "options": services.ReviewSorts})
+			return
+		}
+	}
+	if req.NewGatherOrder != nil {
+		if _, ok := services.NewGatherOrders[*req.NewGatherOrder]; !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid new_gather_order", "options": services.NewGatherOrders})
+			return
+		}
+	}
+
+	if err := database.UpdateUserQueueSettings(userID, req.NewCardPosition, req.ReviewSort, req.NewGatherOrder, req.ReviewsLimit, req.ResetReviewsLimit); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update queue settings"})
+		return
+	}
+
+	stats, err := database.GetUserStats(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":           "Queue settings updated successfully",
+		"new_card_position": stats.NewCardPosition,
+		"review_sort":       stats.ReviewSort,
+		"new_gather_order":  stats.NewGatherOrder,
+		"reviews_limit":     stats.ReviewsLimit,
+	})
+}
+
+type UpdateNewQuotasRequest struct {
+	Quotas map[string]int `json:"quotas"` // e.g. {"Easy": 3, "Medium": 2, "Hard": 1}; empty removes all quotas
+}
+
+// UpdateNewQuotas sets how many new cards of each difficulty may be introduced per day
+// The overall new card limit still applies; a difficulty without a quota is only bound by it
+func (h *SettingsHandler) UpdateNewQuotas(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req UpdateNewQuotasRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request. Expected {\"quotas\": {\"Easy\": 3, \"Medium\": 2, \"Hard\": 1}}."})
+		return
+	}
+
+	quotas, err := services.NormalizeNewQuotas(req.Quotas)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := database.UpdateUserNewDifficultyQuotas(userID, quotas); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update new card quotas"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "New card quotas updated successfully",
+		"quotas":  quotas,
+	})
+}
+
+type UpdateEnforcePrerequisitesRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// UpdateEnforcePrerequisites toggles holding back new cards until their prerequisites are learned
+// Turning it off lets the user study anything in their usual gather order
+func (h *SettingsHandler) UpdateEnforcePrerequisites(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req UpdateEnforcePrerequisitesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request. Expected {\"enabled\": true|false}."})
+		return
+	}
+
+	if err := database.UpdateUserEnforcePrerequisites(userID, *req.Enabled); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update prerequisites setting"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":               "Prerequisites setting updated successfully",
+		"enforce_prerequisites": *req.Enabled,
+	})
+}
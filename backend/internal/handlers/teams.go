@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"errors"
+	"leetcode-anki/backend/internal/database"
+	"leetcode-anki/backend/internal/models"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TeamsHandler struct{}
+
+func NewTeamsHandler() *TeamsHandler {
+	return &TeamsHandler{}
+}
+
+// GetTeams handles GET /api/teams
+func (h *TeamsHandler) GetTeams(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	teams, err := database.GetUserTeams(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch teams"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"teams": teams})
+}
+
+// CreateTeam handles POST /api/teams; the creator becomes its owner
+func (h *TeamsHandler) CreateTeam(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Team name is required"})
+		return
+	}
+
+	team := &models.Team{Name: strings.TrimSpace(req.Name), CreatedBy: userID}
+	if err := database.CreateTeam(team); err != nil {
+		log.Printf("❌ Failed to create team: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create team"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"team": team})
+}
+
+// AddMember handles POST /api/teams/:id/members (team owners only)
+func (h *TeamsHandler) AddMember(c *gin.Context) {
+	var req struct {
+		UserID string `json:"user_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id is required"})
+		return
+	}
+
+	teamID := c.Param("id")
+	if !h.requireOwner(c, teamID) {
+		return
+	}
+
+	if err := database.AddTeamMember(teamID, req.UserID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add member"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member added", "user_id": req.UserID})
+}
+
+// RemoveMember handles DELETE /api/teams/:id/members/:userId
+// Owners can remove anyone; members can remove themselves (leave). The last owner must stay.
+func (h *TeamsHandler) RemoveMember(c *gin.Context) {
+	teamID := c.Param("id")
+	memberID := c.Param("userId")
+
+	if memberID != c.GetString("user_id") && !h.requireOwner(c, teamID) {
+		return
+	}
+
+	err := database.RemoveTeamMember(teamID, memberID)
+	if errors.Is(err, database.ErrLastTeamOwner) {
+		c.JSON(http.StatusConflict, gin.H{"error": "The last owner can't leave or be removed"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove member"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed", "user_id": memberID})
+}
+
+// requireOwner checks the requesting user owns the team; writes the error response if not
+func (h *TeamsHandler) requireOwner(c *gin.Context, teamID string) bool {
+	role, err := database.GetTeamRole(teamID, c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check team membership"})
+		return false
+	}
+	if role != "owner" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only team owners can manage members"})
+		return false
+	}
+	return true
+}
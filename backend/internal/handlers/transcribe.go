@@ -1,6 +1,11 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"leetcode-anki/backend/config"
+	"leetcode-anki/backend/internal/database"
+	"leetcode-anki/backend/internal/models"
 	"leetcode-anki/backend/internal/services"
 	"log"
 	"net/http"
@@ -8,40 +13,103 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// multipartOverhead leaves room for form boundaries and fields around the audio file
+const multipartOverhead = 1 << 20
+
 type TranscribeHandler struct {
-	llmService *services.LLMService
+	llmService  *services.LLMService
+	transcriber services.Transcriber
 }
 
 func NewTranscribeHandler() *TranscribeHandler {
+	transcriber, err := services.NewTranscriber()
+	if err != nil {
+		// Misconfigured local backend shouldn't take voice input down entirely
+		log.Printf("⚠️ %v, falling back to OpenAI Whisper", err)
+		transcriber = services.NewOpenAITranscriber(config.AppConfig.OpenAIKey, "", "")
+	}
+
 	return &TranscribeHandler{
-		llmService: services.NewLLMService(),
+		llmService:  services.NewLLMService(),
+		transcriber: transcriber,
 	}
 }
 
 type TranscribeResponse struct {
-	Text string `json:"text"`
+	Text            string                `json:"text"`             // Enhanced text, used as the answer
+	RawText         string                `json:"raw_text"`         // Exactly what the recognizer heard
+	TranscriptionID string                `json:"transcription_id"` // Pass to /api/review/submit to keep both with the attempt
+	Language        string                `json:"language"`
+	DurationSeconds float64               `json:"duration_seconds"`
+	Metrics         *models.SpeechMetrics `json:"metrics"` // Pace, pauses and filler words
 }
 
 // TranscribeAudio handles POST /api/transcribe
 func (h *TranscribeHandler) TranscribeAudio(c *gin.Context) {
+	userID := c.GetString("user_id")
+	maxBytes := config.AppConfig.MaxAudioBytes
+
+	// Reject oversized uploads before reading them into memory/disk
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes+multipartOverhead)
+
 	// Get the audio file from form
 	file, header, err := c.Request.FormFile("audio")
 	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Audio file too large (max %d MB)", maxBytes>>20)})
+			return
+		}
 		log.Printf("❌ Failed to get audio file: %v", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "No audio file provided"})
 		return
 	}
 	defer file.Close()
 
+	if header.Size > maxBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Audio file too large (max %d MB)", maxBytes>>20)})
+		return
+	}
+
 	log.Printf("🎤 Received audio file: %s (size: %d bytes)", header.Filename, header.Size)
 
-	// Transcribe using Whisper API
-	transcription, err := h.llmService.TranscribeAudio(c.Request.Context(), file, header.Filename)
+	// Form value overrides the user's saved language for one-off recordings
+	language := c.PostForm("language")
+	if language == "" {
+		language = "en"
+		if stats, err := database.GetUserStats(userID); err == nil && stats.TranscriptionLanguage != "" {
+			language = stats.TranscriptionLanguage
+		}
+	}
+	recognizerLanguage := language
+	if language == "auto" {
+		recognizerLanguage = ""
+	}
+
+	audio, err := services.NormalizeAudio(c.Request.Context(), file, header.Filename)
+	if err != nil {
+		log.Printf("❌ Audio normalization failed: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported or corrupted audio file"})
+		return
+	}
+	defer audio.Cleanup()
+
+	maxSeconds := float64(config.AppConfig.MaxAudioSeconds)
+	if audio.MaxDurationSeconds() > maxSeconds {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Recording too long (max %d seconds)", config.AppConfig.MaxAudioSeconds)})
+		return
+	}
+
+	transcript, err := h.transcriber.Transcribe(c.Request.Context(), audio.Path, recognizerLanguage)
 	if err != nil {
 		log.Printf("❌ Transcription failed: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Transcription failed"})
 		return
 	}
+	transcription := transcript.Text
+
+	// Timing metadata for oral grading (pace, pauses, filler words)
+	metrics := services.ComputeSpeechMetrics(transcript, audio.DurationSeconds)
 
 	// Enhance the transcription with GPT-4o-mini
 	enhanced, err := h.llmService.EnhanceAnswer(c.Request.Context(), transcription)
@@ -53,6 +121,29 @@ func (h *TranscribeHandler) TranscribeAudio(c *gin.Context) {
 
 	log.Printf("✅ Transcription successful: %s", enhanced)
 
+	// Keep both versions so the attempt can show what was actually said
+	record := &models.Transcription{
+		UserID:          userID,
+		RawText:         transcription,
+		EnhancedText:    enhanced,
+		Language:        language,
+		Backend:         h.transcriber.Name(),
+		DurationSeconds: metrics.DurationSeconds,
+		SizeBytes:       header.Size,
+		Metrics:         metrics,
+	}
+	if err := database.CreateTranscription(record); err != nil {
+		// Not critical: the answer text is still returned
+		log.Printf("⚠️ Failed to save transcription: %v", err)
+	}
+
 	// Return the enhanced text
-	c.JSON(http.StatusOK, TranscribeResponse{Text: enhanced})
+	c.JSON(http.StatusOK, TranscribeResponse{
+		Text:            enhanced,
+		RawText:         transcription,
+		TranscriptionID: record.ID,
+		Language:        language,
+		DurationSeconds: metrics.DurationSeconds,
+		Metrics:         metrics,
+	})
 }
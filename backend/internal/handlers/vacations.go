@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"leetcode-anki/backend/internal/database"
+	"leetcode-anki/backend/internal/models"
+	"leetcode-anki/backend/internal/services"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type VacationsHandler struct{}
+
+func NewVacationsHandler() *VacationsHandler {
+	return &VacationsHandler{}
+}
+
+// GetVacations handles GET /api/vacations
+func (h *VacationsHandler) GetVacations(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	active := settleVacations(userID)
+	vacations, err := database.GetVacations(userID, 50)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch vacations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"vacations": vacations,
+		"active":    active,
+	})
+}
+
+// CreateVacation handles POST /api/vacations
+// No cards are shown from starts_on to ends_on; afterwards due dates are pushed back by its length
+func (h *VacationsHandler) CreateVacation(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req services.VacationInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	vacation, err := req.ToVacation(userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := database.CreateVacation(vacation); err != nil {
+		if errors.Is(err, database.ErrVacationOverlap) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, database.ErrVacationInPast) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("❌ Failed to create vacation: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create vacation"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"vacation": vacation})
+}
+
+// EndVacation handles POST /api/vacations/:id/end
+// Cancels a vacation that hasn't started; a running one ends yesterday and due dates move right away
+func (h *VacationsHandler) EndVacation(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	ok, err := database.EndVacation(userID, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to end vacation"})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No upcoming or running vacation with this id"})
+		return
+	}
+
+	settleVacations(userID)
+	c.JSON(http.StatusOK, gin.H{"message": "Vacation ended"})
+}
+
+// vacationMessage tells the user why no card is shown
+func vacationMessage(v *models.Vacation) string {
+	return fmt.Sprintf("🌴 You're on vacation until %s. Reviews are paused.", v.EndsOn.Format("Jan 2"))
+}
+
+// settleVacations pushes back due dates for vacations that are over and returns the one running today
+// Best effort: failures are logged and the user is treated as not on vacation
+func settleVacations(userID string) *models.Vacation {
+	shifted, err := database.ApplyEndedVacations(userID)
+	if err != nil {
+		log.Printf("⚠️ Failed to apply ended vacations for user %s: %v", userID, err)
+	} else if shifted > 0 {
+		log.Printf("🌴 Pushed back %d cards after vacation for user %s", shifted, userID)
+	}
+
+	active, err := database.GetActiveVacation(userID)
+	if err != nil {
+		log.Printf("⚠️ Failed to check vacation for user %s: %v", userID, err)
+		return nil
+	}
+	return active
+}
@@ -15,6 +15,51 @@ type Question struct {
 	Topics              []string           `json:"topics"`
 	SolutionBreakdown   *SolutionBreakdown `json:"solution_breakdown,omitempty"` // Cached solution from LLM
 	CreatedAt           time.Time          `json:"created_at"`
+
+	// Set for user-authored questions; LeetCode questions have no owner and are public
+	OwnerID    *string `json:"owner_id,omitempty"`
+	TeamID     *string `json:"team_id,omitempty"`
+	Visibility string  `json:"visibility,omitempty"` // "public", "private" or "team"
+
+	// Rich metadata, loaded separately (see database.LoadQuestionMetadata)
+	Hints            []string          `json:"hints,omitempty"`
+	Examples         []QuestionExample `json:"examples,omitempty"`    // Parsed from the description
+	Constraints      []string          `json:"constraints,omitempty"` // Parsed from the description
+	ExampleTestcases string            `json:"example_testcases,omitempty"`
+	CodeSnippets     []CodeSnippet     `json:"code_snippets,omitempty"`
+	SimilarQuestions []SimilarQuestion `json:"similar_questions,omitempty"`
+	Stats            *LeetCodeStats    `json:"stats,omitempty"`
+}
+
+// QuestionExample is one worked example from a problem description
+type QuestionExample struct {
+	Input       string `json:"input"`
+	Output      string `json:"output"`
+	Explanation string `json:"explanation,omitempty"`
+}
+
+// CodeSnippet is LeetCode's starter code for one language
+type CodeSnippet struct {
+	Lang     string `json:"lang"`
+	LangSlug string `json:"lang_slug"`
+	Code     string `json:"code"`
+}
+
+// SimilarQuestion is a related problem as listed by LeetCode
+type SimilarQuestion struct {
+	Title      string  `json:"title"`
+	Slug       string  `json:"slug"`
+	Difficulty string  `json:"difficulty"`
+	QuestionID *string `json:"question_id,omitempty"` // Set when the problem is in the pool
+}
+
+// LeetCodeStats are community numbers for a problem at import time
+type LeetCodeStats struct {
+	Likes            int     `json:"likes"`
+	Dislikes         int     `json:"dislikes"`
+	AcceptanceRate   float64 `json:"acceptance_rate"` // Percent, 0-100
+	TotalAccepted    int64   `json:"total_accepted"`
+	TotalSubmissions int64   `json:"total_submissions"`
 }
 
 // Review represents a user's review card
@@ -33,6 +78,7 @@ type Review struct {
 	LastReviewedAt  *time.Time `json:"last_reviewed_at"`
 	TotalReviews    int        `json:"total_reviews"`
 	TotalLapses     int        `json:"total_lapses"`
+	NewPosition     *int       `json:"new_position,omitempty"` // Place in the new-card queue, if repositioned
 	CreatedAt       time.Time  `json:"created_at"`
 }
 
@@ -55,12 +101,12 @@ type QuestionWithProgress struct {
 
 // QuestionFilters for filtering question list
 type QuestionFilters struct {
-	Difficulty string `form:"difficulty"` // "Easy", "Medium", "Hard"
-	State      string `form:"state"`      // "unseen", "new", "learning", "review", "relearning"
-	Topic      string `form:"topic"`      // Any topic tag
-	SortBy     string `form:"sort_by"`    // "difficulty", "title", "progress", "leetcode_id"
-	Limit      int    `form:"limit"`
-	Offset     int    `form:"offset"`
+	Difficulty string `form:"difficulty" json:"difficulty,omitempty"` // "Easy", "Medium", "Hard"
+	State      string `form:"state" json:"state,omitempty"`           // "unseen", "new", "learning", "review", "relearning"
+	Topic      string `form:"topic" json:"topic,omitempty"`           // Any topic tag
+	SortBy     string `form:"sort_by" json:"sort_by,omitempty"`       // "difficulty", "title", "progress", "leetcode_id"
+	Limit      int    `form:"limit" json:"limit,omitempty"`
+	Offset     int    `form:"offset" json:"offset,omitempty"`
 }
 
 // SubmitAnswerRequest is the payload for answer submission
@@ -68,11 +114,15 @@ type SubmitAnswerRequest struct {
 	QuestionID       string `json:"question_id" binding:"required"`
 	Answer           string `json:"answer" binding:"required"`
 	TimeSpentSeconds int    `json:"time_spent_seconds"` // Time spent on this card in seconds
+	TranscriptionID  string `json:"transcription_id"`   // Set when the answer came from /api/transcribe
+	Mode             string `json:"mode"`               // "typed" (default) or "oral" to also grade delivery
+	SessionID        string `json:"session_id"`         // Set when answering inside a filtered study session
 }
 
 // SkipRequest is the payload for skipping a card
 type SkipRequest struct {
 	QuestionID string `json:"question_id" binding:"required"`
+	SessionID  string `json:"session_id"`
 }
 
 // SubmitAnswerResponse is the response after scoring
@@ -86,9 +136,31 @@ type SubmitAnswerResponse struct {
 	CardState         string             `json:"card_state"`
 	IntervalMinutes   int                `json:"interval_minutes"`
 	IntervalDays      int                `json:"interval_days"`
-	CoinsEarned       int                `json:"coins_earned"`   // Coins earned this submission
-	TotalCoins        int                `json:"total_coins"`    // New total coin balance
-	CurrentStreak     int                `json:"current_streak"` // New daily streak
+	CoinsEarned       int                `json:"coins_earned"`       // Coins earned this submission
+	TotalCoins        int                `json:"total_coins"`        // New total coin balance
+	CurrentStreak     int                `json:"current_streak"`     // New daily streak
+	Practice          bool               `json:"practice,omitempty"` // Practice-only session: the schedule was left as is
+	HistoryID         string             `json:"history_id,omitempty"`
+	Ratings           []RatingOption     `json:"ratings,omitempty"`          // Again/Hard/Good/Easy with where each would schedule the card
+	SuggestedRating   string             `json:"suggested_rating,omitempty"` // Button matching the grader's score (preselected)
+
+	CommunicationScores *CommunicationScores `json:"communication_scores,omitempty"` // Oral mode only
+	DeliveryFeedback    string               `json:"delivery_feedback,omitempty"`    // Oral mode only
+}
+
+// RatingOption is one self-rating button and the schedule it leads to
+type RatingOption struct {
+	Rating          string    `json:"rating"` // "again", "hard", "good", "easy"
+	Score           int       `json:"score"`  // What the scheduler is given
+	CardState       string    `json:"card_state"`
+	NextReviewAt    time.Time `json:"next_review_at"`
+	IntervalMinutes int       `json:"interval_minutes"`
+	IntervalDays    int       `json:"interval_days"`
+}
+
+// RateAnswerRequest overrides the grader's score of an answer
+type RateAnswerRequest struct {
+	Rating string `json:"rating" binding:"required"` // "again", "hard", "good", "easy"
 }
 
 // SubScores provides granular feedback on different aspects
@@ -99,6 +171,28 @@ type SubScores struct {
 	EdgeCaseAwareness       int `json:"edge_case_awareness"`      // 0-5: Did they consider edge cases?
 }
 
+// CommunicationScores grade how a spoken explanation was delivered (oral mode)
+type CommunicationScores struct {
+	Clarity     int `json:"clarity"`     // 0-5: Easy to follow, precise terminology?
+	Structure   int `json:"structure"`   // 0-5: Problem → approach → complexity → edge cases?
+	Pacing      int `json:"pacing"`      // 0-5: Comfortable speed, no long stalls?
+	Conciseness int `json:"conciseness"` // 0-5: Free of filler and rambling?
+	Confidence  int `json:"confidence"`  // 0-5: Committed to an approach without excessive hedging?
+}
+
+// SpeechMetrics are timing measurements of a spoken answer
+type SpeechMetrics struct {
+	DurationSeconds     float64        `json:"duration_seconds"`
+	SpeakingSeconds     float64        `json:"speaking_seconds"` // Duration minus pauses
+	WordCount           int            `json:"word_count"`
+	WordsPerMinute      float64        `json:"words_per_minute"` // Over speaking time
+	PauseCount          int            `json:"pause_count"`      // Silences of 2s or more
+	LongestPauseSeconds float64        `json:"longest_pause_seconds"`
+	TotalPauseSeconds   float64        `json:"total_pause_seconds"`
+	FillerWordCount     int            `json:"filler_word_count"`
+	FillerWords         map[string]int `json:"filler_words"`
+}
+
 // SolutionBreakdown provides comprehensive solution explanation
 type SolutionBreakdown struct {
 	Pattern               string   `json:"pattern"`                // e.g., "Two Pointers", "Dynamic Programming"
@@ -110,6 +204,17 @@ type SolutionBreakdown struct {
 	ComplexityExplanation string   `json:"complexity_explanation"` // Why this complexity
 	KeyInsights           []string `json:"key_insights"`           // What makes this solution optimal
 	CommonPitfalls        []string `json:"common_pitfalls"`        // What to watch out for
+
+	Implementations map[string]*ReferenceImplementation `json:"implementations,omitempty"` // Keyed by language, generated on demand
+}
+
+// ReferenceImplementation is a runnable solution in a specific language
+type ReferenceImplementation struct {
+	Language         string    `json:"language"`                    // "python", "java", "go"
+	Code             string    `json:"code"`                        // LeetCode-style solution
+	Validated        bool      `json:"validated"`                   // Passed the sample tests in the local sandbox
+	ValidationOutput string    `json:"validation_output,omitempty"` // Sandbox output (trimmed)
+	GeneratedAt      time.Time `json:"generated_at"`
 }
 
 // UserStats represents user's overall statistics
@@ -125,7 +230,22 @@ type UserStats struct {
 	CurrentStreak  int        `json:"current_streak"`   // Current daily streak
 	MaxStreak      int        `json:"max_streak"`       // All-time high streak
 	LastStreakDate *time.Time `json:"last_streak_date"` // Last day the user studied
-	UpdatedAt      time.Time  `json:"updated_at"`
+
+	PreferredLanguage     string `json:"preferred_language"`     // Language for reference implementations
+	TranscriptionLanguage string `json:"transcription_language"` // Speech recognition language ("auto" = detect)
+	BurySiblings          bool   `json:"bury_siblings"`          // Defer related cards for the day after reviewing one
+
+	// Queue settings
+	NewCardPosition string `json:"new_card_position"` // "before", "after" or "mixed" (with reviews)
+	ReviewSort      string `json:"review_sort"`       // "due", "overdueness", "retrievability", "random", "difficulty"
+	NewGatherOrder  string `json:"new_gather_order"`  // "list", "random", "difficulty"
+	ReviewsLimit    int    `json:"reviews_limit"`     // Daily review cap (the server default unless set)
+
+	EnforcePrerequisites bool `json:"enforce_prerequisites"` // Hold back new cards until their prerequisites are learned
+
+	NewDifficultyQuotas map[string]int `json:"new_difficulty_quotas,omitempty"` // Daily new cards per difficulty, within NewCardsLimit
+
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 // DueCounts represents cards due by type (Anki-style)
@@ -134,6 +254,8 @@ type DueCounts struct {
 	ReviewsDue      int `json:"reviews_due"`       // Review cards due today
 	NewAvailable    int `json:"new_available"`     // Total new cards available
 	NewStudiedToday int `json:"new_studied_today"` // New cards studied today
+
+	ReviewLimitReached bool `json:"review_limit_reached,omitempty"` // ReviewsDue was capped by the daily review limit
 }
 
 // TodayStats represents today's study session
@@ -149,7 +271,19 @@ type DashboardData struct {
 	DueCounts       DueCounts  `json:"due_counts"`
 	TodayStats      TodayStats `json:"today_stats"`
 	NextCardDueAt   *time.Time `json:"next_card_due_at"`
-	AllCardsStudied bool       `json:"all_cards_studied"` // Congrats message
+	AllCardsStudied bool       `json:"all_cards_studied"`  // Congrats message
+	Deck            *Deck      `json:"deck,omitempty"`     // Set when the dashboard is scoped to a deck
+	Vacation        *Vacation  `json:"vacation,omitempty"` // Set while the user is on vacation
+
+	NewQuotas []NewQuotaBucket `json:"new_quotas"` // Today's new cards per difficulty
+}
+
+// NewQuotaBucket is today's progress against one difficulty's new-card quota
+type NewQuotaBucket struct {
+	Difficulty string `json:"difficulty"`
+	Quota      *int   `json:"quota"`      // nil = no quota, only the overall new card limit
+	Introduced int    `json:"introduced"` // New cards of this difficulty added today
+	Remaining  *int   `json:"remaining"`  // nil without a quota
 }
 
 // NextCardResponse provides info about the next card or when it's due
@@ -159,6 +293,8 @@ type NextCardResponse struct {
 	Message       string     `json:"message"`
 	NextCardDueAt *time.Time `json:"next_card_due_at"`
 	DueCounts     DueCounts  `json:"due_counts"`
+	DeckID        string     `json:"deck_id,omitempty"`
+	Vacation      *Vacation  `json:"vacation,omitempty"` // Set while the user is on vacation (no cards are shown)
 }
 
 // QuestionStats provides aggregated statistics for a question across all users
@@ -214,4 +350,344 @@ type History struct {
 	QuestionTitle      string             `json:"question_title"`
 	QuestionLeetcodeID int                `json:"question_leetcode_id"`
 	QuestionDifficulty string             `json:"question_difficulty"`
+	Transcription      *Transcription     `json:"transcription,omitempty"` // Voice answers only
+	SessionID          *string            `json:"session_id,omitempty"`    // Answered in a filtered study session
+	Practice           bool               `json:"practice,omitempty"`      // The answer didn't reschedule the card
+	PreReview          *Review            `json:"-"`                       // Card before the answer, for re-rating
+	UserRating         *int               `json:"user_rating,omitempty"`   // Score the user chose over the grader's
+	RatedAt            *time.Time         `json:"rated_at,omitempty"`
+
+	CommunicationScores *CommunicationScores `json:"communication_scores,omitempty"` // Oral mode only
+	DeliveryFeedback    string               `json:"delivery_feedback,omitempty"`
+}
+
+// Transcription is a voice answer as recognized (raw) and after cleanup (enhanced)
+type Transcription struct {
+	ID              string         `json:"id"`
+	UserID          string         `json:"user_id"`
+	RawText         string         `json:"raw_text"`
+	EnhancedText    string         `json:"enhanced_text"`
+	Language        string         `json:"language"`
+	Backend         string         `json:"backend"` // Which transcriber produced it
+	DurationSeconds float64        `json:"duration_seconds"`
+	SizeBytes       int64          `json:"size_bytes"`
+	Metrics         *SpeechMetrics `json:"metrics,omitempty"` // Pacing and pauses, when timing was available
+	CreatedAt       time.Time      `json:"created_at"`
+}
+
+// AttemptPoint is one graded attempt in an answer-progress timeline
+type AttemptPoint struct {
+	HistoryID   string     `json:"history_id"`
+	SubmittedAt time.Time  `json:"submitted_at"`
+	Score       int        `json:"score"`
+	SubScores   *SubScores `json:"sub_scores"`
+}
+
+// DimensionTrend summarizes how one sub-score moved across attempts
+type DimensionTrend struct {
+	First  int    `json:"first"`
+	Latest int    `json:"latest"`
+	Best   int    `json:"best"`
+	Delta  int    `json:"delta"` // Latest - First
+	Trend  string `json:"trend"` // "improving", "declining", "steady"
+}
+
+// AnswerProgress tracks how a user's answers to one question evolved
+type AnswerProgress struct {
+	QuestionID        string                    `json:"question_id"`
+	QuestionTitle     string                    `json:"question_title"`
+	Attempts          int                       `json:"attempts"`
+	Timeline          []AttemptPoint            `json:"timeline"`           // Oldest first
+	ScoreTrend        DimensionTrend            `json:"score_trend"`        // Overall score
+	DimensionTrends   map[string]DimensionTrend `json:"dimension_trends"`   // Keyed by sub-score JSON name
+	RecurringMistakes string                    `json:"recurring_mistakes"` // LLM-written summary (empty if < 2 attempts)
+}
+
+// ProblemList is a curated, ordered list of problems (Blind 75, NeetCode 150, ...)
+// Items reference LeetCode IDs so a list can exist before all its problems are in the pool
+type ProblemList struct {
+	ID             string            `json:"id"`
+	Slug           string            `json:"slug"`
+	Name           string            `json:"name"`
+	Description    string            `json:"description"`
+	URL            string            `json:"url"`
+	Source         string            `json:"source"`          // "builtin" or "custom"
+	ItemCount      int               `json:"item_count"`      // Problems in the list
+	AvailableCount int               `json:"available_count"` // Problems already in the question pool
+	StartedCount   int               `json:"started_count"`   // Problems the user has a card for
+	Subscribed     bool              `json:"subscribed"`
+	SubscribedAt   *time.Time        `json:"subscribed_at,omitempty"`
+	Items          []ProblemListItem `json:"items,omitempty"` // Only filled for list detail
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+}
+
+// ProblemListItem is one problem in a list, with the user's card state if they've started it
+type ProblemListItem struct {
+	Position   int     `json:"position"`
+	Section    string  `json:"section"`
+	LeetcodeID int     `json:"leetcode_id"`
+	QuestionID *string `json:"question_id,omitempty"` // Nil until the problem is imported
+	Title      *string `json:"title,omitempty"`
+	Slug       *string `json:"slug,omitempty"`
+	Difficulty *string `json:"difficulty,omitempty"`
+	CardState  *string `json:"card_state,omitempty"`
+}
+
+// QuestionRevision is one imported version of a question's content
+type QuestionRevision struct {
+	ID                  string    `json:"id"`
+	QuestionID          string    `json:"question_id"`
+	ContentHash         string    `json:"content_hash"`
+	Title               string    `json:"title"`
+	Difficulty          string    `json:"difficulty"`
+	DescriptionMarkdown string    `json:"description_markdown"`
+	Topics              []string  `json:"topics"`
+	CreatedAt           time.Time `json:"created_at"`
+}
+
+// RelatedQuestion is a neighbour in the question relationship graph
+type RelatedQuestion struct {
+	QuestionID string   `json:"question_id"`
+	LeetcodeID int      `json:"leetcode_id"`
+	Title      string   `json:"title"`
+	Slug       string   `json:"slug"`
+	Difficulty string   `json:"difficulty"`
+	Pattern    string   `json:"pattern,omitempty"` // From the cached solution breakdown
+	Relations  []string `json:"relations"`         // "similar" (LeetCode) and/or "pattern"
+	CardState  *string  `json:"card_state,omitempty"`
+}
+
+// QuestionPrerequisite is a question to learn before another one, with the user's progress on it
+type QuestionPrerequisite struct {
+	QuestionID string   `json:"question_id"`
+	LeetcodeID int      `json:"leetcode_id"`
+	Title      string   `json:"title"`
+	Slug       string   `json:"slug"`
+	Difficulty string   `json:"difficulty"`
+	Sources    []string `json:"sources"` // "list" (earlier in a list section) and/or "pattern" (entry problem of its pattern)
+	CardState  *string  `json:"card_state,omitempty"`
+	Met        bool     `json:"met"` // The user has learned it (or suspended it)
+}
+
+// CatalogProblem is one entry of LeetCode's problem index, kept locally for lookups and random picks
+// It's metadata only; the full problem lands in questions when it's imported
+type CatalogProblem struct {
+	FrontendID int       `json:"frontend_id"` // The number shown on leetcode.com
+	Slug       string    `json:"slug"`
+	Title      string    `json:"title"`
+	Difficulty string    `json:"difficulty"`
+	PaidOnly   bool      `json:"paid_only"`
+	Category   string    `json:"category"` // "Algorithms", "Database", "Shell", ...
+	Topics     []string  `json:"topics"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// CatalogSyncRun records one catalog sync
+type CatalogSyncRun struct {
+	ID          string     `json:"id"`
+	Full        bool       `json:"full"`         // Walked the whole index instead of stopping at known problems
+	Fetched     int        `json:"fetched"`      // Entries read from LeetCode
+	Added       int        `json:"added"`        // New to the catalog
+	Updated     int        `json:"updated"`      // Known entries whose metadata changed
+	RemoteTotal int        `json:"remote_total"` // Problem count LeetCode reported
+	Error       *string    `json:"error,omitempty"`
+	StartedAt   time.Time  `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+}
+
+// RefillRun records one background top-up of the shared question pool
+type RefillRun struct {
+	ID         string         `json:"id"`
+	Source     string         `json:"source"`
+	Users      int            `json:"users"`     // Users whose low pool this run covered
+	Requested  map[string]int `json:"requested"` // Problems asked for per difficulty
+	Topics     []string       `json:"topics"`    // Under-covered topics that were preferred
+	Fetched    int            `json:"fetched"`
+	Inserted   int            `json:"inserted"`
+	Updated    int            `json:"updated"`
+	Unchanged  int            `json:"unchanged"`
+	Skipped    int            `json:"skipped"` // Premium or non-algorithm problems
+	Failed     int            `json:"failed"`
+	Errors     []string       `json:"errors"`
+	StartedAt  time.Time      `json:"started_at"`
+	FinishedAt *time.Time     `json:"finished_at,omitempty"`
+}
+
+// Team is a group of users who share custom questions
+type Team struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	CreatedBy   string    `json:"created_by"`
+	Role        string    `json:"role,omitempty"` // The requesting user's role: "owner" or "member"
+	MemberCount int       `json:"member_count"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Deck is a named subset of the user's collection studied on its own
+// Kind says which field defines it: a list, a topic set, difficulties or a saved question filter
+type Deck struct {
+	ID            string           `json:"id"`
+	UserID        string           `json:"user_id"`
+	Name          string           `json:"name"`
+	Kind          string           `json:"kind"` // "list", "topics", "difficulty", "filter"
+	ListID        *string          `json:"list_id,omitempty"`
+	Topics        []string         `json:"topics"`       // Matches questions with any of these topics
+	Difficulties  []string         `json:"difficulties"` // "Easy", "Medium", "Hard"
+	Filter        *QuestionFilters `json:"filter,omitempty"`
+	NewPerDay     *int             `json:"new_per_day"`     // nil = the user's new card limit
+	ReviewsPerDay *int             `json:"reviews_per_day"` // nil = no deck review cap
+	CreatedAt     time.Time        `json:"created_at"`
+	UpdatedAt     time.Time        `json:"updated_at"`
+}
+
+// StudySessionQuery selects the cards of a filtered study session; empty fields don't filter
+type StudySessionQuery struct {
+	Topics           []string `json:"topics,omitempty"`             // Any of these topics
+	Difficulties     []string `json:"difficulties,omitempty"`       // "Easy", "Medium", "Hard"
+	States           []string `json:"states,omitempty"`             // "new", "learning", "review", "relearning"
+	MinLapses        int      `json:"min_lapses,omitempty"`         // Cards forgotten at least this often
+	MaxLastScore     *int     `json:"max_last_score,omitempty"`     // Latest graded answer scored at most this
+	FailedWithinDays int      `json:"failed_within_days,omitempty"` // Failed (score < 3) at least once in the last N days
+	DueWithinDays    *int     `json:"due_within_days,omitempty"`    // Due now or within N days (0 = due today)
+	Order            string   `json:"order,omitempty"`              // "due" (default), "random", "lapses", "score"
+	Limit            int      `json:"limit,omitempty"`
+}
+
+// StudySession is a filtered, one-off selection of cards (Anki's filtered decks)
+// Practice-only sessions grade answers without touching the cards' schedule;
+// rescheduling ones update cards as usual, treating not-yet-due cards as early reviews.
+type StudySession struct {
+	ID         string            `json:"id"`
+	UserID     string            `json:"user_id"`
+	Name       string            `json:"name"`
+	Query      StudySessionQuery `json:"query"`
+	Reschedule bool              `json:"reschedule"`
+	CardCount  int               `json:"card_count"`
+	Answered   int               `json:"answered"`
+	CreatedAt  time.Time         `json:"created_at"`
+	FinishedAt *time.Time        `json:"finished_at,omitempty"`
+}
+
+// UndoAction is the state from just before a submit or skip, kept so it can be undone
+type UndoAction struct {
+	ID             string     `json:"id"`
+	UserID         string     `json:"user_id"`
+	QuestionID     string     `json:"question_id"`
+	Action         string     `json:"action"` // "submit" or "skip"
+	Review         Review     `json:"review"` // The card before the answer
+	Coins          int        `json:"coins"`
+	CurrentStreak  int        `json:"current_streak"`
+	MaxStreak      int        `json:"max_streak"`
+	LastStreakDate *time.Time `json:"last_streak_date,omitempty"`
+	HistoryID      *string    `json:"history_id,omitempty"` // The graded attempt to remove (submits only)
+	SessionID      *string    `json:"session_id,omitempty"` // Filtered study session the card was answered in
+	LogID          *string    `json:"log_id,omitempty"`     // Review log entry of the action
+	BuriedIDs      []string   `json:"buried_ids,omitempty"` // Sibling questions the action buried
+	CreatedAt      time.Time  `json:"created_at"`
+
+	// Schedule the action left the card in; undo refuses if the card has moved on since
+	AfterLastReviewedAt *time.Time `json:"-"`
+	AfterNextReviewAt   *time.Time `json:"-"`
+}
+
+// ReviewLogEntry is one event in the append-only log of schedule changes
+// Before/after describe the card around the event; stats and exports should read this, not history
+type ReviewLogEntry struct {
+	ID             string     `json:"id"`
+	Seq            int64      `json:"seq"` // Increases with every entry; the export cursor
+	UserID         string     `json:"user_id"`
+	QuestionID     string     `json:"question_id"`
+	Event          string     `json:"event"`            // "submit", "skip", "undo", "reschedule", "suspend", "unsuspend", "import", ...
+	Rating         *int       `json:"rating,omitempty"` // Score (0-5) the scheduler was given
+	StateBefore    string     `json:"state_before"`
+	StateAfter     string     `json:"state_after"`
+	IntervalBefore int        `json:"interval_before"` // Minutes
+	IntervalAfter  int        `json:"interval_after"`
+	EaseBefore     *float64   `json:"ease_before,omitempty"`
+	EaseAfter      *float64   `json:"ease_after,omitempty"`
+	DueBefore      *time.Time `json:"due_before,omitempty"`
+	DueAfter       *time.Time `json:"due_after,omitempty"`
+	ElapsedDays    *float64   `json:"elapsed_days,omitempty"` // Since the card's previous review
+	HistoryID      *string    `json:"history_id,omitempty"`   // Graded attempt behind a submit
+	UndoesID       *string    `json:"undoes_id,omitempty"`    // Entry reverted by an undo
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// GraderCalibration compares the grader's scores with the ratings users chose instead
+type GraderCalibration struct {
+	Rated        int                 `json:"rated"`         // Answers the user rated themselves
+	Overridden   int                 `json:"overridden"`    // ...with a different button than the grader's score maps to
+	OverrideRate float64             `json:"override_rate"` // Overridden / Rated
+	Raised       int                 `json:"raised"`        // User rated higher than the grader
+	Lowered      int                 `json:"lowered"`
+	MeanDelta    float64             `json:"mean_delta"` // Average of user rating minus the grader's button score
+	ByScore      []CalibrationBucket `json:"by_score"`
+}
+
+// CalibrationBucket is the override breakdown for one grader score
+type CalibrationBucket struct {
+	Score      int     `json:"score"`
+	Rated      int     `json:"rated"`
+	Overridden int     `json:"overridden"`
+	MeanRating float64 `json:"mean_rating"`
+}
+
+// Vacation pauses a user's due dates from StartsOn to EndsOn (inclusive)
+type Vacation struct {
+	ID           string     `json:"id"`
+	UserID       string     `json:"user_id"`
+	StartsOn     time.Time  `json:"starts_on"`
+	EndsOn       time.Time  `json:"ends_on"`
+	AppliedAt    *time.Time `json:"applied_at,omitempty"` // When due dates were pushed back, after it ended
+	CardsShifted int        `json:"cards_shifted"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// RebalanceResult reports how an overdue backlog was spread out
+type RebalanceResult struct {
+	Overdue     int   `json:"overdue"`     // Overdue review cards found
+	Rescheduled int   `json:"rescheduled"` // Moved to a later day (the rest stay due today)
+	Days        int   `json:"days"`
+	PerDay      []int `json:"per_day"` // Cards due on each day, today first
+}
+
+// ResetCardsRequest puts cards back to "new"
+type ResetCardsRequest struct {
+	QuestionIDs []string `json:"question_ids" binding:"required"`
+	KeepLapses  bool     `json:"keep_lapses"`
+}
+
+// RepositionCardsRequest moves new cards to positions Start, Start+Step, ... of the new-card queue
+type RepositionCardsRequest struct {
+	QuestionIDs []string `json:"question_ids" binding:"required"` // In the order they should come
+	Start       int      `json:"start"`                           // Default 1
+	Step        int      `json:"step"`                            // Default 1
+	Shift       bool     `json:"shift"`                           // Move cards already at or after Start out of the way
+}
+
+// SetDueRequest makes cards due Days days from today
+type SetDueRequest struct {
+	QuestionIDs []string `json:"question_ids" binding:"required"`
+	Days        int      `json:"days"`         // 0 = now
+	SetInterval bool     `json:"set_interval"` // Review cards also take Days as their interval
+}
+
+// ForgetCardsRequest resets every studied card of a topic or list
+type ForgetCardsRequest struct {
+	Topic      string `json:"topic"`
+	ListSlug   string `json:"list_slug"`
+	KeepLapses bool   `json:"keep_lapses"`
+}
+
+// CardOperationResult reports a card browser operation
+type CardOperationResult struct {
+	Updated int           `json:"updated"`
+	Skipped []SkippedCard `json:"skipped"`
+}
+
+// SkippedCard is a selected card an operation didn't apply to
+type SkippedCard struct {
+	QuestionID string `json:"question_id"`
+	Reason     string `json:"reason"`
 }
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// wavBytesPerSecond is the data rate of 16kHz mono 16-bit PCM
+const wavBytesPerSecond = 16000 * 2
+
+// wavHeaderBytes is the size of a canonical WAV header
+const wavHeaderBytes = 44
+
+// minCompressedBytesPerSecond is a low bitrate for browser recordings (16 kbps Opus)
+// Dividing an unmeasured file's size by it overestimates the duration rather than underestimates it
+const minCompressedBytesPerSecond = 2000
+
+// NormalizedAudio is an uploaded recording prepared for speech recognition
+type NormalizedAudio struct {
+	Path            string
+	DurationSeconds float64 // 0 when ffmpeg isn't available to measure it
+	Normalized      bool    // Converted to 16kHz mono WAV
+	sizeBytes       int64
+	dir             string
+}
+
+// MaxDurationSeconds is the measured duration, or an upper estimate from the file size when it
+// couldn't be measured, so length limits still hold without ffmpeg
+func (a *NormalizedAudio) MaxDurationSeconds() float64 {
+	if a.DurationSeconds > 0 {
+		return a.DurationSeconds
+	}
+	return float64(a.sizeBytes) / minCompressedBytesPerSecond
+}
+
+// Cleanup removes the temporary files
+func (a *NormalizedAudio) Cleanup() {
+	if err := os.RemoveAll(a.dir); err != nil {
+		log.Printf("⚠️ Failed to clean up %s: %v", a.dir, err)
+	}
+}
+
+// NormalizeAudio saves the upload and converts it to 16kHz mono WAV with ffmpeg
+// Browsers record webm/ogg/mp4 depending on vendor; local models need a single format.
+// Without ffmpeg the original file is passed through unchanged.
+func NormalizeAudio(ctx context.Context, audio io.Reader, filename string) (*NormalizedAudio, error) {
+	dir, err := os.MkdirTemp("", "leetcode-anki-audio-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	result := &NormalizedAudio{dir: dir}
+
+	ext := strings.ToLower(filepath.Ext(filename))
+	if ext == "" {
+		ext = ".webm" // Browser MediaRecorder default
+	}
+	inputPath := filepath.Join(dir, "input"+ext)
+
+	input, err := os.Create(inputPath)
+	if err != nil {
+		result.Cleanup()
+		return nil, fmt.Errorf("failed to save audio: %w", err)
+	}
+	result.sizeBytes, err = io.Copy(input, audio)
+	input.Close()
+	if err != nil {
+		result.Cleanup()
+		return nil, fmt.Errorf("failed to save audio: %w", err)
+	}
+
+	ffmpeg, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		log.Printf("⚠️ ffmpeg not found, transcribing audio without normalization")
+		result.Path = inputPath
+		return result, nil
+	}
+
+	outputPath := filepath.Join(dir, "normalized.wav")
+	cmd := exec.CommandContext(ctx, ffmpeg,
+		"-nostdin", "-y", "-loglevel", "error",
+		"-i", inputPath,
+		"-ar", "16000", "-ac", "1", "-c:a", "pcm_s16le",
+		outputPath,
+	)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		result.Cleanup()
+		return nil, fmt.Errorf("ffmpeg failed: %w: %s", err, truncateOutput(string(output)))
+	}
+
+	info, err := os.Stat(outputPath)
+	if err != nil {
+		result.Cleanup()
+		return nil, fmt.Errorf("failed to stat normalized audio: %w", err)
+	}
+
+	result.Path = outputPath
+	result.Normalized = true
+	result.DurationSeconds = float64(info.Size()-wavHeaderBytes) / wavBytesPerSecond
+	return result, nil
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMaxDurationSeconds(t *testing.T) {
+	tests := []struct {
+		name  string
+		audio NormalizedAudio
+		want  float64
+	}{
+		{"measured", NormalizedAudio{DurationSeconds: 12.5, sizeBytes: 1 << 20}, 12.5},
+		{"estimated from size", NormalizedAudio{sizeBytes: 600 * minCompressedBytesPerSecond}, 600},
+		{"empty", NormalizedAudio{}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.audio.MaxDurationSeconds(); got != tt.want {
+				t.Errorf("MaxDurationSeconds = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeAudioWithoutFFmpeg(t *testing.T) {
+	t.Setenv("PATH", t.TempDir()) // No ffmpeg to find
+
+	upload := bytes.Repeat([]byte{1}, 20*minCompressedBytesPerSecond)
+	audio, err := NormalizeAudio(context.Background(), bytes.NewReader(upload), "")
+	if err != nil {
+		t.Fatalf("NormalizeAudio: %v", err)
+	}
+
+	if audio.Normalized || audio.DurationSeconds != 0 {
+		t.Errorf("normalized = %v, duration = %v, want the upload passed through unmeasured", audio.Normalized, audio.DurationSeconds)
+	}
+	if filepath.Ext(audio.Path) != ".webm" {
+		t.Errorf("path = %s, want the browser default extension", audio.Path)
+	}
+	saved, err := os.ReadFile(audio.Path)
+	if err != nil || !bytes.Equal(saved, upload) {
+		t.Errorf("saved upload differs (err %v)", err)
+	}
+	// A long recording can't slip past the length limit just because it wasn't measured
+	if got := audio.MaxDurationSeconds(); got != 20 {
+		t.Errorf("MaxDurationSeconds = %v, want 20", got)
+	}
+
+	audio.Cleanup()
+	if _, err := os.Stat(audio.Path); !os.IsNotExist(err) {
+		t.Errorf("Cleanup left %s behind", audio.Path)
+	}
+}
+
+func TestNormalizeAudioMeasuresDuration(t *testing.T) {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		t.Skip("ffmpeg not installed")
+	}
+
+	audio, err := NormalizeAudio(context.Background(), bytes.NewReader(sineWAV(8000, 3)), "answer.WAV")
+	if err != nil {
+		t.Fatalf("NormalizeAudio: %v", err)
+	}
+	defer audio.Cleanup()
+
+	if !audio.Normalized || !strings.HasSuffix(audio.Path, "normalized.wav") {
+		t.Errorf("normalized = %v, path = %s", audio.Normalized, audio.Path)
+	}
+	if math.Abs(audio.DurationSeconds-3) > 0.1 {
+		t.Errorf("duration = %v, want about 3s", audio.DurationSeconds)
+	}
+}
+
+// sineWAV encodes seconds of a 440 Hz tone as mono 16-bit PCM WAV
+func sineWAV(sampleRate, seconds int) []byte {
+	samples := sampleRate * seconds
+	var buf bytes.Buffer
+	write := func(v interface{}) { binary.Write(&buf, binary.LittleEndian, v) }
+
+	buf.WriteString("RIFF")
+	write(uint32(36 + samples*2))
+	buf.WriteString("WAVEfmt ")
+	write(uint32(16))
+	write(uint16(1)) // PCM
+	write(uint16(1)) // Mono
+	write(uint32(sampleRate))
+	write(uint32(sampleRate * 2))
+	write(uint16(2))
+	write(uint16(16))
+	buf.WriteString("data")
+	write(uint32(samples * 2))
+	for i := 0; i < samples; i++ {
+		write(int16(8000 * math.Sin(2*math.Pi*440*float64(i)/float64(sampleRate))))
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"leetcode-anki/backend/internal/database"
+	"leetcode-anki/backend/internal/models"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	catalogPageSize = 100
+
+	// A lookup miss syncs at most this often, so unknown IDs can't hammer LeetCode
+	catalogMissSyncInterval = 10 * time.Minute
+)
+
+var (
+	// catalogSyncMu serializes syncs across the process; lastCatalogSync is guarded by it
+	catalogSyncMu   sync.Mutex
+	lastCatalogSync time.Time
+)
+
+// SyncCatalog refreshes problem_catalog from LeetCode's problem list
+// An incremental sync walks the list newest-first and stops once a page brings nothing new and the
+// catalog is as large as LeetCode reports; a full sync walks everything to pick up renames,
+// difficulty changes and premium flags. Every run is recorded in problem_catalog_syncs.
+func (l *LeetCodeService) SyncCatalog(ctx context.Context, full bool) (models.CatalogSyncRun, error) {
+	catalogSyncMu.Lock()
+	defer catalogSyncMu.Unlock()
+	return l.syncCatalogLocked(ctx, full)
+}
+
+func (l *LeetCodeService) syncCatalogLocked(ctx context.Context, full bool) (models.CatalogSyncRun, error) {
+	run := models.CatalogSyncRun{Full: full, StartedAt: time.Now()}
+
+	id, err := database.StartCatalogSync(full)
+	if err != nil {
+		return run, fmt.Errorf("failed to record catalog sync: %w", err)
+	}
+	run.ID = id
+
+	err = l.walkCatalog(ctx, &run)
+	if err != nil {
+		msg := err.Error()
+		run.Error = &msg
+	} else {
+		lastCatalogSync = time.Now()
+	}
+
+	if finishErr := database.FinishCatalogSync(run); finishErr != nil {
+		log.Printf("⚠️ Failed to record catalog sync result: %v", finishErr)
+	}
+
+	log.Printf("📚 Catalog sync (full=%v): fetched %d, added %d, updated %d, LeetCode total %d",
+		full, run.Fetched, run.Added, run.Updated, run.RemoteTotal)
+	return run, err
+}
+
+// walkCatalog pages through the problem list, saving each page as it arrives
+func (l *LeetCodeService) walkCatalog(ctx context.Context, run *models.CatalogSyncRun) error {
+	for skip := 0; ; skip += catalogPageSize {
+		entries, total, err := l.fetchCatalogPage(ctx, skip, catalogPageSize)
+		if err != nil {
+			return err
+		}
+		run.RemoteTotal = total
+		run.Fetched += len(entries)
+
+		added, updated, err := database.UpsertCatalogProblems(entries)
+		if err != nil {
+			return fmt.Errorf("failed to save catalog page: %w", err)
+		}
+		run.Added += added
+		run.Updated += updated
+
+		if skip+catalogPageSize >= total {
+			return nil
+		}
+
+		if !run.Full && added == 0 {
+			count, err := database.GetCatalogCount()
+			if err != nil {
+				return err
+			}
+			if count >= total {
+				return nil
+			}
+		}
+	}
+}
+
+// syncCatalogIfStale runs an incremental sync unless one finished recently
+func (l *LeetCodeService) syncCatalogIfStale(ctx context.Context) error {
+	catalogSyncMu.Lock()
+	defer catalogSyncMu.Unlock()
+
+	if time.Since(lastCatalogSync) < catalogMissSyncInterval {
+		return nil
+	}
+	_, err := l.syncCatalogLocked(ctx, false)
+	return err
+}
+
+// ensureCatalog runs a first full sync when the catalog is empty
+func (l *LeetCodeService) ensureCatalog(ctx context.Context) error {
+	// Checked under the lock so concurrent callers don't each run the first sync
+	catalogSyncMu.Lock()
+	defer catalogSyncMu.Unlock()
+
+	count, err := database.GetCatalogCount()
+	if err != nil {
+		return fmt.Errorf("failed to count catalog: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	log.Printf("📚 Problem catalog is empty, running a full sync")
+	_, err = l.syncCatalogLocked(ctx, true)
+	return err
+}
+
+// StartCatalogSync keeps the catalog fresh in the background until ctx is cancelled
+// Each tick runs an incremental sync, or a full one when the last full sync is older than fullEvery
+func (l *LeetCodeService) StartCatalogSync(ctx context.Context, interval, fullEvery time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			full := true
+			if last, err := database.GetLastCatalogSync(true); err == nil && last != nil && time.Since(last.StartedAt) < fullEvery {
+				full = false
+			}
+
+			if _, err := l.SyncCatalog(ctx, full); err != nil && ctx.Err() == nil {
+				log.Printf("⚠️ Catalog sync failed: %v", err)
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// fetchCatalogPage fetches one page of the problem list, newest problems first
+func (l *LeetCodeService) fetchCatalogPage(ctx context.Context, skip, limit int) ([]models.CatalogProblem, int, error) {
+	query := `
+        query problemsetQuestionList($categorySlug: String, $skip: Int, $limit: Int, $filters: QuestionListFilterInput) {
+            problemsetQuestionList: questionList(
+                categorySlug: $categorySlug
+                skip: $skip
+                limit: $limit
+                filters: $filters
+            ) {
+                total: totalNum
+                questions: data {
+                    frontendQuestionId: questionFrontendId
+                    titleSlug
+                    title
+                    difficulty
+                    paidOnly: isPaidOnly
+                    categoryTitle
+                    topicTags {
+                        name
+                    }
+                }
+            }
+        }
+    `
+
+	variables := map[string]interface{}{
+		"categorySlug": "",
+		"skip":         skip,
+		"limit":        limit,
+		"filters": map[string]interface{}{
+			"orderBy":   "FRONTEND_ID",
+			"sortOrder": "DESCENDING",
+		},
+	}
+
+	respData, err := l.executeQuery(ctx, query, variables)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var result struct {
+		ProblemsetQuestionList struct {
+			Total     int `json:"total"`
+			Questions []struct {
+				FrontendQuestionID string  `json:"frontendQuestionId"`
+				TitleSlug          string  `json:"titleSlug"`
+				Title              string  `json:"title"`
+				Difficulty         string  `json:"difficulty"`
+				PaidOnly           bool    `json:"paidOnly"`
+				CategoryTitle      string  `json:"categoryTitle"`
+				TopicTags          []Topic `json:"topicTags"`
+			} `json:"questions"`
+		} `json:"problemsetQuestionList"`
+	}
+
+	if err := json.Unmarshal(respData, &result); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse problems list: %w", err)
+	}
+
+	entries := make([]models.CatalogProblem, 0, len(result.ProblemsetQuestionList.Questions))
+	for _, q := range result.ProblemsetQuestionList.Questions {
+		// Skips the odd non-numeric ID (LCP/contest problems)
+		id, err := strconv.Atoi(q.FrontendQuestionID)
+		if err != nil {
+			continue
+		}
+
+		topics := make([]string, 0, len(q.TopicTags))
+		for _, tag := range q.TopicTags {
+			topics = append(topics, tag.Name)
+		}
+
+		entries = append(entries, models.CatalogProblem{
+			FrontendID: id,
+			Slug:       q.TitleSlug,
+			Title:      q.Title,
+			Difficulty: q.Difficulty,
+			PaidOnly:   q.PaidOnly,
+			Category:   q.CategoryTitle,
+			Topics:     topics,
+		})
+	}
+
+	return entries, result.ProblemsetQuestionList.Total, nil
+}
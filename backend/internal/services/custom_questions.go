@@ -0,0 +1,111 @@
+package services
+
+import (
+	"fmt"
+	"leetcode-anki/backend/internal/models"
+	"strings"
+)
+
+// CustomQuestionInput is what a user submits to create or edit their own question
+type CustomQuestionInput struct {
+	Title       string   `json:"title"`
+	Description string   `json:"description"` // Markdown
+	Difficulty  string   `json:"difficulty"`  // "Easy", "Medium" or "Hard"
+	Topics      []string `json:"topics"`
+	Visibility  string   `json:"visibility"` // "private" (default) or "team"
+	TeamID      string   `json:"team_id"`    // Required with "team"
+
+	// Optional reference solution; seeds the solution breakdown used for grading
+	ReferenceSolution string   `json:"reference_solution"`
+	Pattern           string   `json:"pattern"`
+	TimeComplexity    string   `json:"time_complexity"`
+	SpaceComplexity   string   `json:"space_complexity"`
+	KeyInsights       []string `json:"key_insights"`
+}
+
+// ToQuestion validates the input and builds the question owned by ownerID
+// Membership of TeamID is checked by the caller
+func (in CustomQuestionInput) ToQuestion(ownerID string) (*models.Question, error) {
+	title := strings.TrimSpace(in.Title)
+	if title == "" {
+		return nil, fmt.Errorf("title is required")
+	}
+	description := strings.TrimSpace(in.Description)
+	if description == "" {
+		return nil, fmt.Errorf("description is required")
+	}
+
+	difficulty, ok := normalizeDifficulty(in.Difficulty)
+	if !ok {
+		return nil, fmt.Errorf("difficulty must be Easy, Medium or Hard")
+	}
+
+	q := &models.Question{
+		Title:               title,
+		Slug:                slugify(title),
+		Difficulty:          difficulty,
+		DescriptionMarkdown: description,
+		Topics:              []string{},
+		OwnerID:             &ownerID,
+		SolutionBreakdown:   in.solutionBreakdown(),
+	}
+	if q.Slug == "" {
+		q.Slug = "custom"
+	}
+
+	for _, topic := range in.Topics {
+		if topic = strings.TrimSpace(topic); topic != "" {
+			q.Topics = append(q.Topics, topic)
+		}
+	}
+
+	switch in.Visibility {
+	case "", "private":
+		q.Visibility = "private"
+	case "team":
+		if in.TeamID == "" {
+			return nil, fmt.Errorf("team_id is required to share with a team")
+		}
+		q.Visibility = "team"
+		teamID := in.TeamID
+		q.TeamID = &teamID
+	default:
+		return nil, fmt.Errorf("visibility must be private or team")
+	}
+
+	return q, nil
+}
+
+// solutionBreakdown turns the reference solution into a breakdown, or nil to let the LLM generate one
+func (in CustomQuestionInput) solutionBreakdown() *models.SolutionBreakdown {
+	solution := strings.TrimSpace(in.ReferenceSolution)
+	if solution == "" {
+		return nil
+	}
+
+	keyInsights := []string{}
+	for _, insight := range in.KeyInsights {
+		if insight = strings.TrimSpace(insight); insight != "" {
+			keyInsights = append(keyInsights, insight)
+		}
+	}
+
+	return &models.SolutionBreakdown{
+		Pattern:         strings.TrimSpace(in.Pattern),
+		ApproachSteps:   []string{},
+		Pseudocode:      solution,
+		TimeComplexity:  strings.TrimSpace(in.TimeComplexity),
+		SpaceComplexity: strings.TrimSpace(in.SpaceComplexity),
+		KeyInsights:     keyInsights,
+		CommonPitfalls:  []string{},
+	}
+}
+
+// normalizeDifficulty accepts any casing of Easy/Medium/Hard and returns the stored form
+func normalizeDifficulty(difficulty string) (string, bool) {
+	switch strings.ToLower(difficulty) {
+	case "easy", "medium", "hard":
+		return strings.ToUpper(difficulty[:1]) + strings.ToLower(difficulty[1:]), true
+	}
+	return "", false
+}
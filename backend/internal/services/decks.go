@@ -0,0 +1,88 @@
+package services
+
+import (
+	"fmt"
+	"leetcode-anki/backend/internal/models"
+	"strings"
+)
+
+// DeckInput is what a user submits to create or edit a deck
+type DeckInput struct {
+	Name          string                  `json:"name"`
+	Kind          string                  `json:"kind"`         // "list", "topics", "difficulty" or "filter"
+	ListSlug      string                  `json:"list_slug"`    // Required with "list"
+	Topics        []string                `json:"topics"`       // Required with "topics"
+	Difficulties  []string                `json:"difficulties"` // Required with "difficulty"
+	Filter        *models.QuestionFilters `json:"filter"`       // Required with "filter"; difficulty and topic apply
+	NewPerDay     *int                    `json:"new_per_day"`
+	ReviewsPerDay *int                    `json:"reviews_per_day"`
+}
+
+// ToDeck validates the input and builds the deck owned by userID
+// Only the field matching Kind is kept; the caller resolves ListSlug and sets ListID
+func (in DeckInput) ToDeck(userID string) (*models.Deck, error) {
+	name := strings.TrimSpace(in.Name)
+	if name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if (in.NewPerDay != nil && *in.NewPerDay < 0) || (in.ReviewsPerDay != nil && *in.ReviewsPerDay < 0) {
+		return nil, fmt.Errorf("daily limits can't be negative")
+	}
+
+	deck := &models.Deck{
+		UserID:        userID,
+		Name:          name,
+		Kind:          in.Kind,
+		Topics:        []string{},
+		Difficulties:  []string{},
+		NewPerDay:     in.NewPerDay,
+		ReviewsPerDay: in.ReviewsPerDay,
+	}
+
+	switch in.Kind {
+	case "list":
+		if in.ListSlug == "" {
+			return nil, fmt.Errorf("list_slug is required for a list deck")
+		}
+	case "topics":
+		for _, topic := range in.Topics {
+			if topic = strings.TrimSpace(topic); topic != "" {
+				deck.Topics = append(deck.Topics, topic)
+			}
+		}
+		if len(deck.Topics) == 0 {
+			return nil, fmt.Errorf("at least one topic is required for a topics deck")
+		}
+	case "difficulty":
+		for _, d := range in.Difficulties {
+			difficulty, ok := normalizeDifficulty(d)
+			if !ok {
+				return nil, fmt.Errorf("difficulties must be Easy, Medium or Hard")
+			}
+			deck.Difficulties = append(deck.Difficulties, difficulty)
+		}
+		if len(deck.Difficulties) == 0 {
+			return nil, fmt.Errorf("at least one difficulty is required for a difficulty deck")
+		}
+	case "filter":
+		if in.Filter == nil {
+			return nil, fmt.Errorf("filter is required for a filter deck")
+		}
+		filter := models.QuestionFilters{Topic: strings.TrimSpace(in.Filter.Topic)}
+		if in.Filter.Difficulty != "" {
+			difficulty, ok := normalizeDifficulty(in.Filter.Difficulty)
+			if !ok {
+				return nil, fmt.Errorf("filter difficulty must be Easy, Medium or Hard")
+			}
+			filter.Difficulty = difficulty
+		}
+		if filter == (models.QuestionFilters{}) {
+			return nil, fmt.Errorf("filter needs a difficulty or a topic")
+		}
+		deck.Filter = &filter
+	default:
+		return nil, fmt.Errorf("kind must be list, topics, difficulty or filter")
+	}
+
+	return deck, nil
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"leetcode-anki/backend/internal/database"
+	"log"
+	"sync"
+)
+
+// ProblemBatchFetcher fetches problems by ID with a small worker pool and imports them as they arrive
+// Rate limiting and retries live in the source; the fetcher only bounds concurrency and tracks progress
+type ProblemBatchFetcher struct {
+	source      ProblemSource
+	concurrency int
+}
+
+// BatchFetchResult is an ImportResult plus how many IDs a checkpoint let us skip
+type BatchFetchResult struct {
+	ImportResult
+	Resumed int `json:"resumed"`
+}
+
+// NewProblemBatchFetcher uses the source's own concurrency (LEETCODE_CONCURRENCY); offline sources get one worker
+func NewProblemBatchFetcher(source ProblemSource) *ProblemBatchFetcher {
+	concurrency := 1
+	if c, ok := source.(interface{ Concurrency() int }); ok && c.Concurrency() > 1 {
+		concurrency = c.Concurrency()
+	}
+	return &ProblemBatchFetcher{source: source, concurrency: concurrency}
+}
+
+// FetchAndImport fetches and imports every ID, continuing past individual failures
+// With a non-empty job, completed IDs are checkpointed so a later run resumes where this one stopped;
+// the checkpoint is cleared once a run finishes with nothing failed
+// onResult, if set, is called after each ID (never concurrently)
+func (f *ProblemBatchFetcher) FetchAndImport(ctx context.Context, job string, ids []int, onResult func(id int, result ImportResult)) (BatchFetchResult, error) {
+	var result BatchFetchResult
+
+	pending := ids
+	if job != "" {
+		completed, err := database.GetFetchCheckpoint(job)
+		if err != nil {
+			return result, fmt.Errorf("failed to load checkpoint %s: %w", job, err)
+		}
+
+		pending = make([]int, 0, len(ids))
+		for _, id := range ids {
+			if completed[id] {
+				result.Resumed++
+				continue
+			}
+			pending = append(pending, id)
+		}
+		if result.Resumed > 0 {
+			log.Printf("⏩ Resuming %s: %d already done, %d to go", job, result.Resumed, len(pending))
+		}
+	}
+
+	var mu sync.Mutex
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < f.concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range jobs {
+				one := f.fetchOne(ctx, id)
+
+				mu.Lock()
+				result.merge(one)
+				if one.Failed == 0 && job != "" {
+					if err := database.AddFetchCheckpoint(job, id); err != nil {
+						log.Printf("⚠️ Failed to checkpoint #%d for %s: %v", id, job, err)
+					}
+				}
+				if onResult != nil {
+					onResult(id, one)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for _, id := range pending {
+		select {
+		case jobs <- id:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+
+	if job != "" && result.Failed == 0 {
+		if err := database.ClearFetchCheckpoint(job); err != nil {
+			log.Printf("⚠️ Failed to clear checkpoint %s: %v", job, err)
+		}
+	}
+	return result, nil
+}
+
+// fetchOne fetches and imports a single problem
+func (f *ProblemBatchFetcher) fetchOne(ctx context.Context, id int) ImportResult {
+	problem, err := f.source.FetchProblemByID(ctx, id)
+	if err != nil {
+		var result ImportResult
+		result.fail(fmt.Sprintf("#%d", id), err)
+		return result
+	}
+	return ImportProblems([]*LeetCodeProblem{problem})
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"fmt"
+	"leetcode-anki/backend/internal/database"
+	"leetcode-anki/backend/internal/models"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ImportResult counts what an import did
+type ImportResult struct {
+	Inserted  int      `json:"inserted"`
+	Updated   int      `json:"updated"`   // Content changed; a new revision was recorded
+	Unchanged int      `json:"unchanged"` // Same content hash as the stored question
+	Failed    int      `json:"failed"`
+	Errors    []string `json:"errors,omitempty"`
+}
+
+// Total is the number of questions the import looked at
+func (r ImportResult) Total() int {
+	return r.Inserted + r.Updated + r.Unchanged + r.Failed
+}
+
+// ImportProblems converts fetched problems and upserts them into questions
+func ImportProblems(problems []*LeetCodeProblem) ImportResult {
+	var result ImportResult
+	questions := make([]*models.Question, 0, len(problems))
+
+	for _, problem := range problems {
+		question, err := QuestionFromLeetCode(problem)
+		if err != nil {
+			result.fail(problem.TitleSlug, err)
+			continue
+		}
+		questions = append(questions, question)
+	}
+
+	result.merge(ImportQuestions(questions))
+	return result
+}
+
+// ImportQuestions upserts questions through the shared database path
+func ImportQuestions(questions []*models.Question) ImportResult {
+	var result ImportResult
+
+	for _, question := range questions {
+		outcome, err := database.UpsertQuestion(question)
+		if err != nil {
+			result.fail(question.Slug, err)
+			continue
+		}
+
+		switch outcome {
+		case database.UpsertInserted:
+			result.Inserted++
+		case database.UpsertUpdated:
+			result.Updated++
+		default:
+			result.Unchanged++
+		}
+	}
+
+	return result
+}
+
+func (r *ImportResult) fail(name string, err error) {
+	r.Failed++
+	r.Errors = append(r.Errors, fmt.Sprintf("%s: %v", name, err))
+}
+
+func (r *ImportResult) merge(other ImportResult) {
+	r.Inserted += other.Inserted
+	r.Updated += other.Updated
+	r.Unchanged += other.Unchanged
+	r.Failed += other.Failed
+	r.Errors = append(r.Errors, other.Errors...)
+}
+
+// QuestionFileEntry is one question in our own question file format
+// Descriptions are already Markdown, unlike LeetCode's HTML content
+type QuestionFileEntry struct {
+	LeetcodeID  int      `json:"leetcode_id"`
+	Title       string   `json:"title"`
+	Slug        string   `json:"slug"` // Derived from the title if omitted
+	Difficulty  string   `json:"difficulty"`
+	Description string   `json:"description"`
+	Topics      []string `json:"topics"`
+	Hints       []string `json:"hints"`
+}
+
+var slugInvalidChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// LoadQuestionFiles reads question files (JSON/YAML) from a file or every data file in a directory
+func LoadQuestionFiles(path string) ([]*models.Question, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := []string{path}
+	if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		paths = paths[:0]
+		for _, entry := range entries {
+			if !entry.IsDir() && isDataFile(entry.Name()) {
+				paths = append(paths, filepath.Join(path, entry.Name()))
+			}
+		}
+	}
+
+	var questions []*models.Question
+	for _, p := range paths {
+		var entries []QuestionFileEntry
+		if err := readDataFile(p, &entries); err != nil {
+			return nil, err
+		}
+
+		for i, entry := range entries {
+			question, err := entry.toQuestion()
+			if err != nil {
+				return nil, fmt.Errorf("%s entry %d: %w", p, i+1, err)
+			}
+			questions = append(questions, question)
+		}
+	}
+
+	return questions, nil
+}
+
+// toQuestion validates the entry and fills in defaults
+func (e QuestionFileEntry) toQuestion() (*models.Question, error) {
+	if e.LeetcodeID <= 0 {
+		return nil, fmt.Errorf("leetcode_id is required")
+	}
+	if e.Title == "" {
+		return nil, fmt.Errorf("title is required")
+	}
+	switch e.Difficulty {
+	case "Easy", "Medium", "Hard":
+	default:
+		return nil, fmt.Errorf("difficulty must be Easy, Medium or Hard, got %q", e.Difficulty)
+	}
+
+	slug := e.Slug
+	if slug == "" {
+		slug = strings.Trim(slugInvalidChars.ReplaceAllString(strings.ToLower(e.Title), "-"), "-")
+	}
+
+	topics := e.Topics
+	if topics == nil {
+		topics = []string{}
+	}
+
+	description := strings.TrimSpace(e.Description)
+
+	return &models.Question{
+		LeetcodeID:          e.LeetcodeID,
+		Title:               e.Title,
+		Slug:                slug,
+		Difficulty:          e.Difficulty,
+		DescriptionMarkdown: description,
+		Topics:              topics,
+		Hints:               e.Hints,
+		Examples:            ParseExamples(description),
+		Constraints:         ParseConstraints(description),
+	}, nil
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadQuestionFilesExample(t *testing.T) {
+	questions, err := LoadQuestionFiles("../../fixtures/questions")
+	if err != nil {
+		t.Fatalf("LoadQuestionFiles: %v", err)
+	}
+	if len(questions) != 1 {
+		t.Fatalf("got %d questions, want 1", len(questions))
+	}
+
+	q := questions[0]
+	if q.LeetcodeID != 1000001 || q.Title != "Design a Rate Limiter" || q.Difficulty != "Medium" {
+		t.Errorf("got %d %q %q", q.LeetcodeID, q.Title, q.Difficulty)
+	}
+	if q.Slug != "design-a-rate-limiter" {
+		t.Errorf("slug = %q, want it derived from the title", q.Slug)
+	}
+	if strings.Join(q.Topics, ",") != "Design,Hash Table,Queue" {
+		t.Errorf("topics = %v", q.Topics)
+	}
+	if q.DescriptionMarkdown != strings.TrimSpace(q.DescriptionMarkdown) {
+		t.Errorf("description isn't trimmed: %q", q.DescriptionMarkdown)
+	}
+}
+
+func TestLoadQuestionFiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		wantSlug string
+		wantErr  string
+	}{
+		{"json object", "q.json", `{"leetcode_id": 7, "title": "Reverse: Integer!", "difficulty": "Easy"}`, "reverse-integer", ""},
+		{"explicit slug", "q.yml", "leetcode_id: 7\ntitle: Reverse Integer\nslug: rev-int\ndifficulty: Hard\n", "rev-int", ""},
+		{"missing id", "q.yaml", "title: Reverse Integer\ndifficulty: Easy\n", "", "leetcode_id is required"},
+		{"missing title", "q.yaml", "leetcode_id: 7\ndifficulty: Easy\n", "", "title is required"},
+		{"lowercase difficulty", "q.yaml", "leetcode_id: 7\ntitle: Reverse Integer\ndifficulty: easy\n", "", "difficulty must be"},
+		{"bad yaml", "q.yaml", "leetcode_id: [7\n", "", "failed to parse"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			questions, err := LoadQuestionFiles(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want it to mention %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadQuestionFiles: %v", err)
+			}
+			if len(questions) != 1 || questions[0].Slug != tt.wantSlug {
+				t.Fatalf("got %+v, want one question with slug %q", questions, tt.wantSlug)
+			}
+			if questions[0].Topics == nil {
+				t.Error("topics is nil, want an empty list")
+			}
+		})
+	}
+}
+
+func TestImportProblemsRecordsConversionFailures(t *testing.T) {
+	// Nothing converts, so nothing reaches the database
+	result := ImportProblems([]*LeetCodeProblem{
+		{QuestionID: "abc", TitleSlug: "bad-id"},
+		{QuestionID: "", TitleSlug: "no-id"},
+	})
+
+	if result.Failed != 2 || result.Total() != 2 {
+		t.Errorf("got %+v, want 2 failures", result)
+	}
+	if len(result.Errors) != 2 || !strings.HasPrefix(result.Errors[0], "bad-id: ") {
+		t.Errorf("errors = %v", result.Errors)
+	}
+}
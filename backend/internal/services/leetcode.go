@@ -2,12 +2,19 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"leetcode-anki/backend/config"
+	"leetcode-anki/backend/internal/database"
+	"log"
+	"math/rand"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	md "github.com/JohannesKaufmann/html-to-markdown"
@@ -19,17 +26,50 @@ const (
 )
 
 type LeetCodeService struct {
-	client *http.Client
+	client      *http.Client
+	limiter     *TokenBucket
+	concurrency int
+	maxRetries  int
+}
+
+var (
+	leetcodeLimiter     *TokenBucket
+	leetcodeLimiterOnce sync.Once
+)
+
+// sharedLeetCodeLimiter returns the process-wide limiter, so every handler's
+// LeetCodeService together stays under LEETCODE_REQUESTS_PER_MINUTE
+func sharedLeetCodeLimiter() *TokenBucket {
+	leetcodeLimiterOnce.Do(func() {
+		leetcodeLimiter = NewTokenBucket(
+			float64(config.AppConfig.LeetCodeRequestsPerMinute)/60,
+			config.AppConfig.LeetCodeBurst,
+		)
+	})
+	return leetcodeLimiter
 }
 
 func NewLeetCodeService() *LeetCodeService {
+	concurrency := config.AppConfig.LeetCodeConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
 	return &LeetCodeService{
 		client: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		limiter:     sharedLeetCodeLimiter(),
+		concurrency: concurrency,
+		maxRetries:  config.AppConfig.LeetCodeMaxRetries,
 	}
 }
 
+// Concurrency is how many requests batch operations keep in flight
+func (l *LeetCodeService) Concurrency() int {
+	return l.concurrency
+}
+
 // LeetCodeProblem represents a problem from the API
 type LeetCodeProblem struct {
 	QuestionID     string   `json:"questionId"`
@@ -40,266 +80,205 @@ type LeetCodeProblem struct {
 	TopicTags      []Topic  `json:"topicTags"`
 	Hints          []string `json:"hints"`
 	SampleTestCase string   `json:"sampleTestCase"`
-}
 
-type Topic struct {
-	Name string `json:"name"`
-	Slug string `json:"slug"`
+	ExampleTestcases string                `json:"exampleTestcases"`
+	CodeSnippets     []LeetCodeCodeSnippet `json:"codeSnippets"`
+	SimilarQuestions LeetCodeSimilarList   `json:"similarQuestions"`
+	Stats            *LeetCodeProblemStats `json:"stats"`
+	Likes            int                   `json:"likes"`
+	Dislikes         int                   `json:"dislikes"`
+	IsPaidOnly       bool                  `json:"isPaidOnly"`
+	CategoryTitle    string                `json:"categoryTitle"` // "Algorithms", "Database", ...
 }
 
-// GraphQL request/response structures
-type graphQLRequest struct {
-	Query     string                 `json:"query"`
-	Variables map[string]interface{} `json:"variables,omitempty"`
+// IsFreeAlgorithm reports whether the problem is a free algorithms problem
+// Sources that don't report a category (fixtures) count as algorithms
+func (p *LeetCodeProblem) IsFreeAlgorithm() bool {
+	return !p.IsPaidOnly && (p.CategoryTitle == "" || p.CategoryTitle == "Algorithms")
 }
 
-type graphQLResponse struct {
-	Data   json.RawMessage `json:"data"`
-	Errors []struct {
-		Message string `json:"message"`
-	} `json:"errors,omitempty"`
+type LeetCodeCodeSnippet struct {
+	Lang     string `json:"lang"`
+	LangSlug string `json:"langSlug"`
+	Code     string `json:"code"`
 }
 
-// FetchRandomProblems fetches random problems by difficulty
-func (l *LeetCodeService) FetchRandomProblems(easyCount, mediumCount, hardCount int) ([]*LeetCodeProblem, error) {
-	problems := []*LeetCodeProblem{}
-
-	difficulties := map[string]int{
-		"EASY":   easyCount,
-		"MEDIUM": mediumCount,
-		"HARD":   hardCount,
-	}
-
-	current := 0
-
-	for difficulty, count := range difficulties {
-		for i := 0; i < count; i++ {
-			current++
-
-			slug, err := l.fetchRandomProblemSlug(difficulty)
-			if err != nil {
-				return nil, fmt.Errorf("failed to fetch random %s problem: %w", difficulty, err)
-			}
-
-			problem, err := l.FetchProblemDetail(slug)
-			if err != nil {
-				return nil, fmt.Errorf("failed to fetch problem details for %s: %w", slug, err)
-			}
-
-			problems = append(problems, problem)
-
-			// Rate limiting
-			time.Sleep(1 * time.Second)
-		}
-	}
-
-	return problems, nil
+type LeetCodeSimilarQuestion struct {
+	Title      string `json:"title"`
+	TitleSlug  string `json:"titleSlug"`
+	Difficulty string `json:"difficulty"`
 }
 
-// fetchRandomProblemSlug gets a random problem slug by difficulty
-func (l *LeetCodeService) fetchRandomProblemSlug(difficulty string) (string, error) {
-	query := `
-        query randomQuestion($categorySlug: String, $filters: QuestionListFilterInput) {
-            randomQuestion(categorySlug: $categorySlug, filters: $filters) {
-                titleSlug
-            }
-        }
-    `
-
-	variables := map[string]interface{}{
-		"categorySlug": "algorithms",
-		"filters": map[string]interface{}{
-			"difficulty": difficulty,
-		},
-	}
+// LeetCodeSimilarList is similarQuestions, which the API returns as a JSON-encoded string
+// Fixtures may use either that string or a plain list
+type LeetCodeSimilarList []LeetCodeSimilarQuestion
 
-	respData, err := l.executeQuery(query, variables)
-	if err != nil {
-		return "", err
-	}
-
-	var result struct {
-		RandomQuestion struct {
-			TitleSlug string `json:"titleSlug"`
-		} `json:"randomQuestion"`
+func (l *LeetCodeSimilarList) UnmarshalJSON(data []byte) error {
+	var list []LeetCodeSimilarQuestion
+	if err := unmarshalEncodedJSON(data, &list); err != nil {
+		return err
 	}
+	*l = list
+	return nil
+}
 
-	if err := json.Unmarshal(respData, &result); err != nil {
-		return "", fmt.Errorf("failed to parse response: %w", err)
-	}
+// LeetCodeProblemStats is the stats field, also a JSON-encoded string in the API
+type LeetCodeProblemStats struct {
+	TotalAcceptedRaw   int64  `json:"totalAcceptedRaw"`
+	TotalSubmissionRaw int64  `json:"totalSubmissionRaw"`
+	ACRate             string `json:"acRate"` // e.g. "55.3%"
+}
 
-	if result.RandomQuestion.TitleSlug == "" {
-		return "", fmt.Errorf("no random question returned")
+func (s *LeetCodeProblemStats) UnmarshalJSON(data []byte) error {
+	type plain LeetCodeProblemStats
+	var stats plain
+	if err := unmarshalEncodedJSON(data, &stats); err != nil {
+		return err
 	}
-
-	return result.RandomQuestion.TitleSlug, nil
+	*s = LeetCodeProblemStats(stats)
+	return nil
 }
 
-// Fixed version of the LeetCode service methods
-
-// fetchTitleSlugByID gets the problem slug from its numeric LeetCode ID
-// This uses questionList to find the problem by its frontendQuestionId
-func (l *LeetCodeService) fetchTitleSlugByID(questionID int) (string, error) {
-	query := `
-        query problemsetQuestionList($categorySlug: String, $skip: Int, $limit: Int, $filters: QuestionListFilterInput) {
-            problemsetQuestionList: questionList(
-                categorySlug: $categorySlug
-                skip: $skip
-                limit: $limit
-                filters: $filters
-            ) {
-                questions: data {
-                    titleSlug
-                    frontendQuestionId: questionFrontendId
-                }
-            }
-        }
-    `
-
-	variables := map[string]interface{}{
-		"categorySlug": "",
-		"skip":         questionID - 1, // Skip to approximately the question
-		"limit":        100,            // Fetch a range around the ID
-		"filters":      map[string]interface{}{},
+// unmarshalEncodedJSON decodes a value that may be wrapped in a JSON string
+func unmarshalEncodedJSON(data []byte, v interface{}) error {
+	trimmed := bytes.TrimSpace(data)
+	if bytes.Equal(trimmed, []byte("null")) {
+		return nil
 	}
-
-	respData, err := l.executeQuery(query, variables)
-	if err != nil {
-		return "", err
+	if len(trimmed) > 0 && trimmed[0] == '"' {
+		var encoded string
+		if err := json.Unmarshal(trimmed, &encoded); err != nil {
+			return err
+		}
+		if encoded == "" {
+			return nil
+		}
+		trimmed = []byte(encoded)
 	}
+	return json.Unmarshal(trimmed, v)
+}
 
-	var result struct {
-		ProblemsetQuestionList struct {
-			Questions []struct {
-				TitleSlug          string `json:"titleSlug"`
-				FrontendQuestionId string `json:"frontendQuestionId"`
-			} `json:"questions"`
-		} `json:"problemsetQuestionList"`
-	}
+type Topic struct {
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
 
-	if err := json.Unmarshal(respData, &result); err != nil {
-		return "", fmt.Errorf("failed to parse slug response for ID %d: %w", questionID, err)
-	}
+// GraphQL request/response structures
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
 
-	// Find the question with matching frontend ID
-	targetID := strconv.Itoa(questionID)
-	for _, q := range result.ProblemsetQuestionList.Questions {
-		if q.FrontendQuestionId == targetID {
-			return q.TitleSlug, nil
-		}
-	}
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors,omitempty"`
+}
 
-	return "", fmt.Errorf("problem slug not found for ID: %d", questionID)
+// FetchRandomProblems fetches random problems by difficulty
+func (l *LeetCodeService) FetchRandomProblems(ctx context.Context, easyCount, mediumCount, hardCount int) ([]*LeetCodeProblem, error) {
+	return l.FetchTargetedProblems(ctx, map[string]int{"Easy": easyCount, "Medium": mediumCount, "Hard": hardCount}, nil)
 }
 
-// Alternative approach: Use a mapping file or fetch all problems once
-// This is more efficient for batch operations like LeetCode 150
-
-func (l *LeetCodeService) FetchAllProblems() (map[int]string, error) {
-	// This fetches all problems and creates an ID -> slug mapping
-	allProblems := make(map[int]string)
-	skip := 0
-	limit := 100
-
-	for {
-		query := `
-            query problemsetQuestionList($categorySlug: String, $skip: Int, $limit: Int, $filters: QuestionListFilterInput) {
-                problemsetQuestionList: questionList(
-                    categorySlug: $categorySlug
-                    skip: $skip
-                    limit: $limit
-                    filters: $filters
-                ) {
-                    total: totalNum
-                    questions: data {
-                        titleSlug
-                        frontendQuestionId: questionFrontendId
-                    }
-                }
-            }
-        `
+// FetchTargetedProblems fetches counts[difficulty] problems each, preferring ones tagged with any of topics
+// Picks come from the local catalog (free algorithm problems not yet in the pool), so there are no repeats
+// Details are fetched with a small worker pool; a failed fetch is logged and skipped. An error is returned
+// only if nothing could be fetched or the context was cancelled (with whatever was fetched so far)
+func (l *LeetCodeService) FetchTargetedProblems(ctx context.Context, counts map[string]int, topics []string) ([]*LeetCodeProblem, error) {
+	if err := l.ensureCatalog(ctx); err != nil {
+		return nil, err
+	}
 
-		variables := map[string]interface{}{
-			"categorySlug": "",
-			"skip":         skip,
-			"limit":        limit,
-			"filters":      map[string]interface{}{},
+	var slugs []string
+	for difficulty, count := range counts {
+		if count <= 0 {
+			continue
 		}
-
-		respData, err := l.executeQuery(query, variables)
+		picked, err := database.GetRandomCatalogSlugs(difficulty, count, topics)
 		if err != nil {
-			return nil, err
-		}
-
-		var result struct {
-			ProblemsetQuestionList struct {
-				Total     int `json:"total"`
-				Questions []struct {
-					TitleSlug          string `json:"titleSlug"`
-					FrontendQuestionId string `json:"frontendQuestionId"`
-				} `json:"questions"`
-			} `json:"problemsetQuestionList"`
-		}
-
-		if err := json.Unmarshal(respData, &result); err != nil {
-			return nil, fmt.Errorf("failed to parse problems list: %w", err)
+			return nil, fmt.Errorf("failed to pick %s problems from catalog: %w", difficulty, err)
 		}
+		slugs = append(slugs, picked...)
+	}
 
-		// Add to map
-		for _, q := range result.ProblemsetQuestionList.Questions {
-			id, err := strconv.Atoi(q.FrontendQuestionId)
-			if err == nil {
-				allProblems[id] = q.TitleSlug
+	var (
+		mu       sync.Mutex
+		problems []*LeetCodeProblem
+		lastErr  error
+	)
+
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for w := 0; w < l.concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for slug := range jobs {
+				problem, err := l.FetchProblemDetail(ctx, slug)
+
+				mu.Lock()
+				if err != nil {
+					lastErr = err
+					log.Printf("⚠️ Failed to fetch problem details for %s: %v", slug, err)
+				} else {
+					problems = append(problems, problem)
+				}
+				mu.Unlock()
 			}
-		}
+		}()
+	}
 
-		// Check if we've fetched all problems
-		if len(result.ProblemsetQuestionList.Questions) < limit {
-			break
+feed:
+	for _, slug := range slugs {
+		select {
+		case jobs <- slug:
+		case <-ctx.Done():
+			break feed
 		}
+	}
+	close(jobs)
+	wg.Wait()
 
-		skip += limit
-
-		// Rate limiting
-		time.Sleep(500 * time.Millisecond)
+	if ctx.Err() != nil {
+		return problems, ctx.Err()
 	}
+	if len(problems) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return problems, nil
+}
 
-	return allProblems, nil
+// Name identifies this problem source
+func (l *LeetCodeService) Name() string {
+	return "leetcode"
 }
 
-// Optimized approach for seed_150/main.go
-func (l *LeetCodeService) FetchProblemsByIDs(problemIDs []int) ([]*LeetCodeProblem, error) {
-	// First, fetch all problems to build ID -> slug mapping
-	fmt.Println("Building problem ID to slug mapping...")
-	mapping, err := l.FetchAllProblems()
+// FetchProblemByID resolves the frontend ID through the local catalog, then fetches the problem
+// An unknown ID triggers an incremental catalog sync first, in case the problem is brand new
+func (l *LeetCodeService) FetchProblemByID(ctx context.Context, questionID int) (*LeetCodeProblem, error) {
+	entry, err := database.GetCatalogProblemByID(questionID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to build problem mapping: %w", err)
+		return nil, fmt.Errorf("failed to look up problem %d in catalog: %w", questionID, err)
 	}
 
-	problems := make([]*LeetCodeProblem, 0, len(problemIDs))
-
-	for _, id := range problemIDs {
-		slug, ok := mapping[id]
-		if !ok {
-			return nil, fmt.Errorf("problem ID %d not found in mapping", id)
+	if entry == nil {
+		if err := l.syncCatalogIfStale(ctx); err != nil {
+			return nil, fmt.Errorf("failed to sync problem catalog: %w", err)
 		}
-
-		problem, err := l.FetchProblemDetail(slug)
-		if err != nil {
-			return nil, fmt.Errorf("failed to fetch problem %d (%s): %w", id, slug, err)
+		if entry, err = database.GetCatalogProblemByID(questionID); err != nil {
+			return nil, fmt.Errorf("failed to look up problem %d in catalog: %w", questionID, err)
+		}
+		if entry == nil {
+			return nil, fmt.Errorf("problem ID %d not found in catalog", questionID)
 		}
-
-		problems = append(problems, problem)
-
-		// Rate limiting
-		time.Sleep(500 * time.Millisecond)
 	}
 
-	return problems, nil
+	return l.FetchProblemDetail(ctx, entry.Slug)
 }
 
 // FetchProblemDetail fetches full details for a specific problem
-func (l *LeetCodeService) FetchProblemDetail(titleSlug string) (*LeetCodeProblem, error) {
+func (l *LeetCodeService) FetchProblemDetail(ctx context.Context, titleSlug string) (*LeetCodeProblem, error) {
 	query := `
         query questionData($titleSlug: String!) {
             question(titleSlug: $titleSlug) {
@@ -314,6 +293,18 @@ func (l *LeetCodeService) FetchProblemDetail(titleSlug string) (*LeetCodeProblem
                 }
                 hints
                 sampleTestCase
+                exampleTestcases
+                codeSnippets {
+                    lang
+                    langSlug
+                    code
+                }
+                similarQuestions
+                stats
+                likes
+                dislikes
+                isPaidOnly
+                categoryTitle
             }
         }
     `
@@ -322,7 +313,7 @@ func (l *LeetCodeService) FetchProblemDetail(titleSlug string) (*LeetCodeProblem
 		"titleSlug": titleSlug,
 	}
 
-	respData, err := l.executeQuery(query, variables)
+	respData, err := l.executeQuery(ctx, query, variables)
 	if err != nil {
 		return nil, err
 	}
@@ -342,8 +333,44 @@ func (l *LeetCodeService) FetchProblemDetail(titleSlug string) (*LeetCodeProblem
 	return result.Question, nil
 }
 
+// leetcodeStatusError is a non-200 response from the GraphQL endpoint
+type leetcodeStatusError struct {
+	StatusCode int
+	RetryAfter time.Duration // From the Retry-After header, if any
+	Body       string
+}
+
+func (e *leetcodeStatusError) Error() string {
+	return fmt.Sprintf("LeetCode API returned status %d: %s", e.StatusCode, e.Body)
+}
+
+// leetcodeTransportError is a request that failed before a complete response arrived
+type leetcodeTransportError struct {
+	Err error
+}
+
+func (e *leetcodeTransportError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *leetcodeTransportError) Unwrap() error {
+	return e.Err
+}
+
+// isRetryable reports whether a failed query may succeed when sent again
+// GraphQL errors and unparseable responses are permanent; transport errors, 429s and 5xxs aren't
+func isRetryable(err error) bool {
+	var statusErr *leetcodeStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= 500
+	}
+	var transportErr *leetcodeTransportError
+	return errors.As(err, &transportErr)
+}
+
 // executeQuery sends a GraphQL request to LeetCode
-func (l *LeetCodeService) executeQuery(query string, variables map[string]interface{}) (json.RawMessage, error) {
+// Every attempt waits on the shared rate limiter; 429s, 5xxs and network errors are retried with backoff
+func (l *LeetCodeService) executeQuery(ctx context.Context, query string, variables map[string]interface{}) (json.RawMessage, error) {
 	reqBody := graphQLRequest{
 		Query:     query,
 		Variables: variables,
@@ -354,7 +381,42 @@ func (l *LeetCodeService) executeQuery(query string, variables map[string]interf
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", LeetCodeGraphQLEndpoint, bytes.NewBuffer(jsonData))
+	for attempt := 0; ; attempt++ {
+		if err := l.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+
+		data, err := l.doQuery(ctx, jsonData)
+		if err == nil {
+			return data, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		if !isRetryable(err) || attempt >= l.maxRetries {
+			return nil, err
+		}
+
+		var retryAfter time.Duration
+		var statusErr *leetcodeStatusError
+		if errors.As(err, &statusErr) {
+			retryAfter = statusErr.RetryAfter
+		}
+		wait := retryBackoff(attempt, retryAfter)
+		log.Printf("⏳ LeetCode request failed (attempt %d/%d), retrying in %s: %v", attempt+1, l.maxRetries+1, wait, err)
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// doQuery performs one GraphQL round trip
+func (l *LeetCodeService) doQuery(ctx context.Context, jsonData []byte) (json.RawMessage, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", LeetCodeGraphQLEndpoint, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -367,18 +429,22 @@ func (l *LeetCodeService) executeQuery(query string, variables map[string]interf
 	// Execute request
 	resp, err := l.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
+		return nil, &leetcodeTransportError{Err: fmt.Errorf("failed to execute request: %w", err)}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("LeetCode API returned status %d: %s", resp.StatusCode, string(body))
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		statusErr := &leetcodeStatusError{StatusCode: resp.StatusCode, Body: string(body)}
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			statusErr.RetryAfter = time.Duration(seconds) * time.Second
+		}
+		return nil, statusErr
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, &leetcodeTransportError{Err: fmt.Errorf("failed to read response: %w", err)}
 	}
 
 	var gqlResp graphQLResponse
@@ -393,6 +459,22 @@ func (l *LeetCodeService) executeQuery(query string, variables map[string]interf
 	return gqlResp.Data, nil
 }
 
+// retryBackoff is exponential (1s, 2s, 4s, ...) with jitter, capped at 30s
+// A server-provided Retry-After wins when it's longer
+func retryBackoff(attempt int, retryAfter time.Duration) time.Duration {
+	// Capped before shifting: time.Second << 34 already overflows
+	backoff := 30 * time.Second
+	if attempt < 5 {
+		backoff = time.Second << attempt
+	}
+	backoff += time.Duration(rand.Int63n(int64(backoff / 2)))
+
+	if retryAfter > backoff {
+		return retryAfter
+	}
+	return backoff
+}
+
 // StripHTMLTags converts HTML content to Markdown using a robust library
 func StripHTMLTags(content string) string {
 	// Clean up HTML before conversion
@@ -400,6 +482,10 @@ func StripHTMLTags(content string) string {
 	content = strings.ReplaceAll(content, "<code>`", "<code>")
 	content = strings.ReplaceAll(content, "`</code>", "</code>")
 
+	// Exponents in constraints (10<sup>4</sup>) would otherwise collapse into "104"
+	content = strings.ReplaceAll(content, "<sup>", "^")
+	content = strings.ReplaceAll(content, "</sup>", "")
+
 	converter := md.NewConverter("", true, nil)
 
 	markdown, err := converter.ConvertString(content)
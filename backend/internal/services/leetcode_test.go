@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRetryBackoff(t *testing.T) {
+	tests := []struct {
+		name       string
+		attempt    int
+		retryAfter time.Duration
+		min, max   time.Duration // max is exclusive
+	}{
+		{"first retry", 0, 0, time.Second, 1500 * time.Millisecond},
+		{"doubles", 3, 0, 8 * time.Second, 12 * time.Second},
+		{"last doubling", 4, 0, 16 * time.Second, 24 * time.Second},
+		{"capped", 5, 0, 30 * time.Second, 45 * time.Second},
+		{"capped far past overflow", 70, 0, 30 * time.Second, 45 * time.Second},
+		{"retry-after wins", 0, time.Minute, time.Minute, time.Minute + 1},
+		{"backoff wins over short retry-after", 3, time.Second, 8 * time.Second, 12 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 50; i++ {
+				got := retryBackoff(tt.attempt, tt.retryAfter)
+				if got < tt.min || got >= tt.max {
+					t.Fatalf("retryBackoff(%d, %v) = %v, want [%v, %v)", tt.attempt, tt.retryAfter, got, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"rate limited", &leetcodeStatusError{StatusCode: 429}, true},
+		{"server error", &leetcodeStatusError{StatusCode: 503}, true},
+		{"not found", &leetcodeStatusError{StatusCode: 404}, false},
+		{"forbidden", &leetcodeStatusError{StatusCode: 403}, false},
+		{"transport", &leetcodeTransportError{Err: errors.New("connection reset")}, true},
+		{"wrapped transport", fmt.Errorf("query: %w", &leetcodeTransportError{Err: errors.New("EOF")}), true},
+		{"wrapped status", fmt.Errorf("query: %w", &leetcodeStatusError{StatusCode: 502}), true},
+		{"graphql error", errors.New("GraphQL errors: problem not found"), false},
+		{"canceled", context.Canceled, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryable(tt.err); got != tt.want {
+				t.Errorf("isRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
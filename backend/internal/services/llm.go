@@ -4,10 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"leetcode-anki/backend/config"
 	"leetcode-anki/backend/internal/models"
 	"log"
+	"strings"
 
 	openai "github.com/sashabaranov/go-openai"
 )
@@ -104,8 +104,9 @@ func (l *LLMService) ScoreAnswer(ctx context.Context, questionTitle, questionDes
 
 // ScoreAnswerOnly scores the user's answer and provides feedback WITHOUT generating solution breakdown
 // This is MUCH faster (~3-5s vs ~16s) for repeat cards where we already have the solution cached
-func (l *LLMService) ScoreAnswerOnly(ctx context.Context, questionTitle, questionDescription, userAnswer string, cachedSolution *models.SolutionBreakdown) (int, string, *models.SubScores, error) {
-	prompt := l.buildFastScoringPrompt(questionTitle, questionDescription, userAnswer, cachedSolution)
+// previousAttempt (may be nil) lets the tutor comment on what improved or regressed since last time
+func (l *LLMService) ScoreAnswerOnly(ctx context.Context, questionTitle, questionDescription, userAnswer string, cachedSolution *models.SolutionBreakdown, previousAttempt *models.History) (int, string, *models.SubScores, error) {
+	prompt := l.buildFastScoringPrompt(questionTitle, questionDescription, userAnswer, cachedSolution, previousAttempt)
 
 	resp, err := l.client.CreateChatCompletion(
 		ctx,
@@ -151,6 +152,55 @@ func (l *LLMService) ScoreAnswerOnly(ctx context.Context, questionTitle, questio
 	return score, feedback, subScores, nil
 }
 
+// GenerateSolution asks GPT for the solution breakdown alone, without scoring an answer
+// Used to pre-generate breakdowns so SubmitAnswer can always take the fast path
+func (l *LLMService) GenerateSolution(ctx context.Context, questionTitle, questionDescription string) (*models.SolutionBreakdown, string, error) {
+	prompt := l.buildSolutionPrompt(questionTitle, questionDescription)
+
+	resp, err := l.client.CreateChatCompletion(
+		ctx,
+		openai.ChatCompletionRequest{
+			Model: openai.GPT4oMini,
+			Messages: []openai.ChatCompletionMessage{
+				{
+					Role:    openai.ChatMessageRoleSystem,
+					Content: "You are an expert algorithm tutor. You write clear, structured solution breakdowns in JSON format to help students master problem-solving patterns.",
+				},
+				{
+					Role:    openai.ChatMessageRoleUser,
+					Content: prompt,
+				},
+			},
+			Temperature: 0.1,
+			MaxTokens:   2000,
+		},
+	)
+
+	if err != nil {
+		return nil, "", fmt.Errorf("OpenAI API error: %w", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, "", fmt.Errorf("no response from OpenAI")
+	}
+
+	response := resp.Choices[0].Message.Content
+
+	var solution SolutionJSON
+	if err := json.Unmarshal([]byte(cleanJSONResponse(response)), &solution); err != nil {
+		log.Printf("❌ Failed to parse solution response: %v", err)
+		return nil, "", fmt.Errorf("failed to parse LLM response: JSON unmarshal error: %w", err)
+	}
+
+	if solution.Pattern == "" {
+		return nil, "", fmt.Errorf("LLM response is missing the solution pattern")
+	}
+
+	log.Printf("💡 Generated solution breakdown for %s: %s", questionTitle, solution.Pattern)
+
+	return toSolutionBreakdown(solution), solution.CorrectApproach, nil
+}
+
 func (l *LLMService) buildScoringPrompt(questionTitle, questionDescription, userAnswer string) string {
 	return fmt.Sprintf(`You are evaluating a student's understanding of algorithm problem-solving.
 
@@ -224,8 +274,62 @@ Evaluate the student's understanding and provide comprehensive, pedagogical feed
 }`, questionTitle, questionDescription, userAnswer)
 }
 
+// buildSolutionPrompt creates a prompt that only asks for the reference solution breakdown
+func (l *LLMService) buildSolutionPrompt(questionTitle, questionDescription string) string {
+	return fmt.Sprintf(`You are writing the reference solution for an algorithm problem.
+
+**Problem:** %s
+
+**Problem Description:**
+%s
+
+---
+
+**Your Task:**
+Write a complete step-by-step solution breakdown with pattern, approach, pseudocode, complexity, insights, and common pitfalls. Describe the optimal approach a strong interview candidate would give.
+
+**CRITICAL: You must respond with ONLY valid JSON. No markdown, no backticks, no preamble. Just pure JSON.**
+
+**Output Format:**
+{
+  "pattern": "<Pattern name>",
+  "why_this_pattern": "<Explanation>",
+  "approach_steps": [
+    "<Step 1>",
+    "<Step 2>",
+    "<Step 3>"
+  ],
+  "pseudocode": "<Clean pseudocode here>",
+  "time_complexity": "<e.g., O(n)>",
+  "space_complexity": "<e.g., O(1)>",
+  "complexity_explanation": "<Why this complexity>",
+  "key_insights": [
+    "<Insight 1>",
+    "<Insight 2>"
+  ],
+  "common_pitfalls": [
+    "<Pitfall 1>",
+    "<Pitfall 2>"
+  ],
+  "correct_approach": "<1-2 sentence summary>"
+}`, questionTitle, questionDescription)
+}
+
 // buildFastScoringPrompt creates a focused prompt for scoring only (no solution generation)
-func (l *LLMService) buildFastScoringPrompt(questionTitle, questionDescription, userAnswer string, cachedSolution *models.SolutionBreakdown) string {
+func (l *LLMService) buildFastScoringPrompt(questionTitle, questionDescription, userAnswer string, cachedSolution *models.SolutionBreakdown, previousAttempt *models.History) string {
+	previousSection := ""
+	feedbackGuidance := "2-3 paragraphs covering what they got right, what they missed, and how to improve."
+	if previousAttempt != nil {
+		previousSection = fmt.Sprintf(`
+**Student's Previous Attempt (%s, scored %d/5):**
+%s
+
+**Feedback They Received Last Time:**
+%s
+`, previousAttempt.SubmittedAt.Format("Jan 2, 2006"), previousAttempt.Score, previousAttempt.UserAnswer, previousAttempt.Feedback)
+		feedbackGuidance = "2-3 paragraphs covering what they got right, what they missed, and how to improve. Open with one short paragraph comparing this answer to their previous attempt: what improved, what regressed, and whether they addressed last time's feedback."
+	}
+
 	return fmt.Sprintf(`You are evaluating a student's understanding of algorithm problem-solving.
 
 **Problem:** %s
@@ -237,7 +341,7 @@ func (l *LLMService) buildFastScoringPrompt(questionTitle, questionDescription,
 %s
 
 **Correct Solution Pattern:** %s
-
+%s
 ---
 
 **Your Task:**
@@ -259,7 +363,7 @@ Evaluate the student's understanding and provide FOCUSED feedback. The solution
    - Complexity Understanding
    - Edge Case Awareness
 
-3. **Feedback:** 2-3 paragraphs covering what they got right, what they missed, and how to improve.
+3. **Feedback:** %s
 
 **CRITICAL: You must respond with ONLY valid JSON. No markdown, no backticks, no preamble. Just pure JSON.**
 
@@ -273,7 +377,7 @@ Evaluate the student's understanding and provide FOCUSED feedback. The solution
     "edge_case_awareness": <0-5>
   },
   "feedback": "<Multi-paragraph detailed feedback here>"
-}`, questionTitle, questionDescription, userAnswer, cachedSolution.Pattern)
+}`, questionTitle, questionDescription, userAnswer, cachedSolution.Pattern, previousSection, feedbackGuidance)
 }
 
 func (l *LLMService) parseJSONResponse(response string) (int, string, string, *models.SubScores, *models.SolutionBreakdown, error) {
@@ -295,16 +399,23 @@ func (l *LLMService) parseJSONResponse(response string) (int, string, string, *m
 		EdgeCaseAwareness:       clampScore(llmResp.SubScores.EdgeCaseAwareness),
 	}
 
+	solutionBreakdown := toSolutionBreakdown(llmResp.Solution)
+
+	return score, llmResp.Feedback, llmResp.Solution.CorrectApproach, subScores, solutionBreakdown, nil
+}
+
+// toSolutionBreakdown converts the LLM solution JSON into the cached model
+func toSolutionBreakdown(solution SolutionJSON) *models.SolutionBreakdown {
 	solutionBreakdown := &models.SolutionBreakdown{
-		Pattern:               llmResp.Solution.Pattern,
-		WhyThisPattern:        llmResp.Solution.WhyThisPattern,
-		ApproachSteps:         llmResp.Solution.ApproachSteps,
-		Pseudocode:            llmResp.Solution.Pseudocode,
-		TimeComplexity:        llmResp.Solution.TimeComplexity,
-		SpaceComplexity:       llmResp.Solution.SpaceComplexity,
-		ComplexityExplanation: llmResp.Solution.ComplexityExplanation,
-		KeyInsights:           llmResp.Solution.KeyInsights,
-		CommonPitfalls:        llmResp.Solution.CommonPitfalls,
+		Pattern:               solution.Pattern,
+		WhyThisPattern:        solution.WhyThisPattern,
+		ApproachSteps:         solution.ApproachSteps,
+		Pseudocode:            solution.Pseudocode,
+		TimeComplexity:        solution.TimeComplexity,
+		SpaceComplexity:       solution.SpaceComplexity,
+		ComplexityExplanation: solution.ComplexityExplanation,
+		KeyInsights:           solution.KeyInsights,
+		CommonPitfalls:        solution.CommonPitfalls,
 	}
 
 	// Ensure arrays are not nil
@@ -318,7 +429,7 @@ func (l *LLMService) parseJSONResponse(response string) (int, string, string, *m
 		solutionBreakdown.CommonPitfalls = []string{}
 	}
 
-	return score, llmResp.Feedback, llmResp.Solution.CorrectApproach, subScores, solutionBreakdown, nil
+	return solutionBreakdown
 }
 
 // FastLLMResponse for the simplified scoring-only response
@@ -376,25 +487,6 @@ func clampScore(score int) int {
 	return score
 }
 
-// TranscribeAudio uses OpenAI Whisper API to transcribe audio to text
-func (l *LLMService) TranscribeAudio(ctx context.Context, audioFile io.Reader, filename string) (string, error) {
-	req := openai.AudioRequest{
-		Model:    openai.Whisper1,
-		FilePath: filename,
-		Reader:   audioFile,
-		Language: "en", // Force English transcription for consistent output
-		Prompt:   "This is a technical explanation of an algorithm or data structure problem. The speaker may mention terms like hashmap, binary search, O(n), pseudocode, edge cases, etc.",
-	}
-
-	resp, err := l.client.CreateTranscription(ctx, req)
-	if err != nil {
-		return "", fmt.Errorf("whisper API error: %w", err)
-	}
-
-	log.Printf("🎤 Transcribed audio: %s", resp.Text)
-	return resp.Text, nil
-}
-
 // EnhanceAnswer uses GPT-4o-mini to clean up transcription errors only
 func (l *LLMService) EnhanceAnswer(ctx context.Context, rawTranscription string) (string, error) {
 	prompt := fmt.Sprintf(`You are cleaning up a voice transcription of a student explaining their algorithm approach.
@@ -450,3 +542,260 @@ Fix ONLY speech-to-text errors and basic grammar. DO NOT add any content, explan
 	log.Printf("✨ Enhanced answer: %s", enhanced)
 	return enhanced, nil
 }
+
+// SupportedLanguages maps language keys to the names used in prompts
+var SupportedLanguages = map[string]string{
+	"python": "Python 3",
+	"java":   "Java",
+	"go":     "Go",
+}
+
+// harnessConventions tells the LLM how a runnable single-file program looks per language
+var harnessConventions = map[string]string{
+	"python": "a Python 3 script run with `python3 main.py`",
+	"java":   "a single Java file run with `java Main.java`; the entry point must be `public class Main` and `Solution` must not be public",
+	"go":     "a Go file run with `go run main.go`; it must be `package main` and use only the standard library",
+}
+
+// ImplementationJSON matches the JSON structure for a generated reference implementation
+type ImplementationJSON struct {
+	Code        string `json:"code"`
+	TestHarness string `json:"test_harness"`
+}
+
+// GenerateImplementation writes a reference implementation of the cached solution in a specific language
+// It also returns a self-contained test harness that checks the code against the description's examples.
+// previousFailure carries sandbox output from a failed attempt so the model can fix it.
+func (l *LLMService) GenerateImplementation(ctx context.Context, questionTitle, questionDescription string, solution *models.SolutionBreakdown, language, previousFailure string) (string, string, error) {
+	languageName, ok := SupportedLanguages[language]
+	if !ok {
+		return "", "", fmt.Errorf("unsupported language: %s", language)
+	}
+
+	prompt := l.buildImplementationPrompt(questionTitle, questionDescription, solution, language, languageName, previousFailure)
+
+	resp, err := l.client.CreateChatCompletion(
+		ctx,
+		openai.ChatCompletionRequest{
+			Model: openai.GPT4oMini,
+			Messages: []openai.ChatCompletionMessage{
+				{
+					Role:    openai.ChatMessageRoleSystem,
+					Content: "You are an expert competitive programmer. You write correct, idiomatic, interview-quality code and respond in JSON format.",
+				},
+				{
+					Role:    openai.ChatMessageRoleUser,
+					Content: prompt,
+				},
+			},
+			Temperature: 0.1,
+			MaxTokens:   2500,
+		},
+	)
+
+	if err != nil {
+		return "", "", fmt.Errorf("OpenAI API error: %w", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return "", "", fmt.Errorf("no response from OpenAI")
+	}
+
+	var impl ImplementationJSON
+	if err := json.Unmarshal([]byte(cleanJSONResponse(resp.Choices[0].Message.Content)), &impl); err != nil {
+		log.Printf("❌ Failed to parse implementation response: %v", err)
+		return "", "", fmt.Errorf("failed to parse LLM response: JSON unmarshal error: %w", err)
+	}
+
+	if impl.Code == "" {
+		return "", "", fmt.Errorf("LLM response is missing the implementation code")
+	}
+
+	log.Printf("🧑‍💻 Generated %s implementation for %s", languageName, questionTitle)
+	return impl.Code, impl.TestHarness, nil
+}
+
+// buildImplementationPrompt asks for code that follows the cached solution breakdown
+func (l *LLMService) buildImplementationPrompt(questionTitle, questionDescription string, solution *models.SolutionBreakdown, language, languageName, previousFailure string) string {
+	retryNote := ""
+	if previousFailure != "" {
+		retryNote = fmt.Sprintf(`
+**Your previous attempt failed its tests with this output. Fix the bug:**
+%s
+`, previousFailure)
+	}
+
+	return fmt.Sprintf(`Write a reference implementation in %s for this algorithm problem.
+
+**Problem:** %s
+
+**Problem Description:**
+%s
+
+**Approach to implement:** %s
+%s
+
+**Pseudocode:**
+%s
+%s
+---
+
+**Requirements:**
+- "code": the solution only, in LeetCode's style for %s (a Solution class/function with the usual signature), with brief comments on the key steps.
+- "test_harness": %s. It must contain the exact same solution code, run it on every example from the problem description, print each result, and exit with a non-zero status if any result doesn't match the expected output.
+
+**CRITICAL: You must respond with ONLY valid JSON. No markdown, no backticks, no preamble. Just pure JSON.**
+
+**Output Format:**
+{
+  "code": "<solution code>",
+  "test_harness": "<complete runnable program>"
+}`, languageName, questionTitle, questionDescription, solution.Pattern, solution.WhyThisPattern, solution.Pseudocode, retryNote, languageName, harnessConventions[language])
+}
+
+// SummarizeRecurringMistakes reads every graded attempt at a problem and describes the mistakes that keep coming back
+func (l *LLMService) SummarizeRecurringMistakes(ctx context.Context, questionTitle string, attempts []models.History) (string, error) {
+	var attemptsText strings.Builder
+	for i, attempt := range attempts {
+		fmt.Fprintf(&attemptsText, "### Attempt %d (%s, scored %d/5)\n**Answer:** %s\n**Feedback:** %s\n\n",
+			i+1, attempt.SubmittedAt.Format("Jan 2, 2006"), attempt.Score, attempt.UserAnswer, attempt.Feedback)
+	}
+
+	prompt := fmt.Sprintf(`A student has attempted the algorithm problem "%s" several times. Here are their attempts in chronological order with the feedback they received:
+
+%s
+---
+
+**Your Task:**
+Write a short summary (one paragraph, then at most 4 bullet points) of the mistakes or gaps that RECUR across attempts, noting which ones they have since fixed and which ones persist. Be specific to this problem. Do not re-explain the full solution.`, questionTitle, attemptsText.String())
+
+	resp, err := l.client.CreateChatCompletion(
+		ctx,
+		openai.ChatCompletionRequest{
+			Model: openai.GPT4oMini,
+			Messages: []openai.ChatCompletionMessage{
+				{
+					Role:    openai.ChatMessageRoleSystem,
+					Content: "You are an expert algorithm tutor who tracks a student's progress over time.",
+				},
+				{
+					Role:    openai.ChatMessageRoleUser,
+					Content: prompt,
+				},
+			},
+			Temperature: 0.2,
+			MaxTokens:   500,
+		},
+	)
+
+	if err != nil {
+		return "", fmt.Errorf("OpenAI API error: %w", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("no response from OpenAI")
+	}
+
+	return resp.Choices[0].Message.Content, nil
+}
+
+// DeliveryJSON matches the JSON structure for oral delivery grading
+type DeliveryJSON struct {
+	CommunicationScores struct {
+		Clarity     int `json:"clarity"`
+		Structure   int `json:"structure"`
+		Pacing      int `json:"pacing"`
+		Conciseness int `json:"conciseness"`
+		Confidence  int `json:"confidence"`
+	} `json:"communication_scores"`
+	DeliveryFeedback string `json:"delivery_feedback"`
+}
+
+// GradeDelivery evaluates HOW a spoken explanation was delivered, as an interviewer would
+// It reads the raw (un-enhanced) transcript so filler and hesitation are still visible.
+func (l *LLMService) GradeDelivery(ctx context.Context, questionTitle, rawTranscript string, metrics *models.SpeechMetrics) (*models.CommunicationScores, string, error) {
+	prompt := fmt.Sprintf(`You are a senior software engineer running a mock coding interview. The candidate explained their approach to "%s" out loud.
+
+**Raw Transcript (verbatim, including filler words):**
+%s
+
+**Delivery Measurements:**
+- Duration: %.0f seconds (%.0f seconds speaking)
+- Pace: %.0f words per minute (comfortable interview pace is roughly 120-160)
+- Pauses of 2s or more: %d (longest %.1fs, %.0fs total)
+- Filler words: %d
+
+---
+
+**Your Task:**
+Grade ONLY the communication, not whether the algorithm is correct (that is graded separately).
+
+**Communication Scores (each 0-5):**
+- Clarity: easy to follow, precise terminology
+- Structure: restates the problem, then approach, then complexity, then edge cases
+- Pacing: comfortable speed, no long stalls or rushing
+- Conciseness: free of filler and rambling
+- Confidence: commits to an approach without excessive hedging
+
+**Delivery Feedback:** 1-2 short paragraphs written the way an interviewer would debrief a candidate: what came across well, what would worry an interviewer, and one concrete habit to practice. Quote short phrases from the transcript where helpful.
+
+**CRITICAL: You must respond with ONLY valid JSON. No markdown, no backticks, no preamble. Just pure JSON.**
+
+**Output Format:**
+{
+  "communication_scores": {
+    "clarity": <0-5>,
+    "structure": <0-5>,
+    "pacing": <0-5>,
+    "conciseness": <0-5>,
+    "confidence": <0-5>
+  },
+  "delivery_feedback": "<Interviewer-style feedback>"
+}`, questionTitle, rawTranscript,
+		metrics.DurationSeconds, metrics.SpeakingSeconds, metrics.WordsPerMinute,
+		metrics.PauseCount, metrics.LongestPauseSeconds, metrics.TotalPauseSeconds,
+		metrics.FillerWordCount)
+
+	resp, err := l.client.CreateChatCompletion(
+		ctx,
+		openai.ChatCompletionRequest{
+			Model: openai.GPT4oMini,
+			Messages: []openai.ChatCompletionMessage{
+				{
+					Role:    openai.ChatMessageRoleSystem,
+					Content: "You are an experienced technical interviewer who coaches candidates on how they communicate. You respond in JSON format.",
+				},
+				{
+					Role:    openai.ChatMessageRoleUser,
+					Content: prompt,
+				},
+			},
+			Temperature: 0.2,
+			MaxTokens:   700,
+		},
+	)
+
+	if err != nil {
+		return nil, "", fmt.Errorf("OpenAI API error: %w", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, "", fmt.Errorf("no response from OpenAI")
+	}
+
+	var delivery DeliveryJSON
+	if err := json.Unmarshal([]byte(cleanJSONResponse(resp.Choices[0].Message.Content)), &delivery); err != nil {
+		return nil, "", fmt.Errorf("failed to parse LLM response: JSON unmarshal error: %w", err)
+	}
+
+	scores := &models.CommunicationScores{
+		Clarity:     clampScore(delivery.CommunicationScores.Clarity),
+		Structure:   clampScore(delivery.CommunicationScores.Structure),
+		Pacing:      clampScore(delivery.CommunicationScores.Pacing),
+		Conciseness: clampScore(delivery.CommunicationScores.Conciseness),
+		Confidence:  clampScore(delivery.CommunicationScores.Confidence),
+	}
+
+	log.Printf("🗣️ Delivery scores: %+v", scores)
+	return scores, delivery.DeliveryFeedback, nil
+}
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"leetcode-anki/backend/internal/database"
+	"leetcode-anki/backend/internal/models"
+	"log"
+	"sync"
+	"time"
+)
+
+// ErrPregenRunning is returned when a pre-generation run is already in progress
+var ErrPregenRunning = errors.New("solution pre-generation is already running")
+
+// ErrPregenLimit is returned for a negative question limit
+var ErrPregenLimit = errors.New("limit must be 0 (all questions) or positive")
+
+// PregenProgress reports the state of a solution pre-generation run
+type PregenProgress struct {
+	Running    bool       `json:"running"`
+	Total      int        `json:"total"`     // Questions picked up by this run
+	Processed  int        `json:"processed"` // Generated + skipped + failed
+	Generated  int        `json:"generated"`
+	Skipped    int        `json:"skipped"` // Already cached by the time the worker got to it
+	Failed     int        `json:"failed"`
+	LastError  string     `json:"last_error,omitempty"`
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+}
+
+// SolutionPregenerator fills solution_breakdown for every question that doesn't have one yet
+// The database is the checkpoint: a run only picks up questions still missing a breakdown,
+// so an interrupted run resumes where it stopped when started again
+type SolutionPregenerator struct {
+	llmService  *LLMService
+	concurrency int
+	interval    time.Duration // Minimum spacing between LLM calls across all workers
+
+	mu             sync.Mutex
+	progress       PregenProgress
+	patternChanged bool // A generated breakdown changed a pattern; the prerequisite graph needs a rebuild
+}
+
+func NewSolutionPregenerator(llmService *LLMService, concurrency, requestsPerMinute int) *SolutionPregenerator {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if requestsPerMinute < 1 {
+		requestsPerMinute = 1
+	}
+	return &SolutionPregenerator{
+		llmService:  llmService,
+		concurrency: concurrency,
+		interval:    time.Minute / time.Duration(requestsPerMinute),
+	}
+}
+
+// Progress returns a snapshot of the current (or last) run
+func (p *SolutionPregenerator) Progress() PregenProgress {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.progress
+}
+
+// Run generates breakdowns for up to limit questions (0 = all) and blocks until done
+func (p *SolutionPregenerator) Run(ctx context.Context, limit int) (PregenProgress, error) {
+	if err := p.claim(limit); err != nil {
+		return PregenProgress{}, err
+	}
+	return p.run(ctx, limit)
+}
+
+// Start claims the pregenerator and runs it in the background, detached from any request
+// Returns ErrPregenRunning without starting anything if a run is already in progress
+func (p *SolutionPregenerator) Start(limit int) error {
+	if err := p.claim(limit); err != nil {
+		return err
+	}
+	go func() {
+		if _, err := p.run(context.Background(), limit); err != nil {
+			log.Printf("⚠️ Solution pre-generation stopped: %v", err)
+		}
+	}()
+	return nil
+}
+
+// claim marks a new run as started, atomically with checking that none is running
+func (p *SolutionPregenerator) claim(limit int) error {
+	if limit < 0 {
+		return ErrPregenLimit
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.progress.Running {
+		return ErrPregenRunning
+	}
+	now := time.Now()
+	p.progress = PregenProgress{Running: true, StartedAt: &now}
+	p.patternChanged = false
+	return nil
+}
+
+// run does the work of a claimed run
+func (p *SolutionPregenerator) run(ctx context.Context, limit int) (PregenProgress, error) {
+	questions, err := database.GetQuestionsWithoutSolution(limit)
+	if err != nil {
+		p.finish(err)
+		return p.Progress(), fmt.Errorf("failed to load questions without solution: %w", err)
+	}
+
+	p.mu.Lock()
+	p.progress.Total = len(questions)
+	p.mu.Unlock()
+
+	log.Printf("💡 Pre-generating solution breakdowns for %d questions (concurrency=%d, interval=%s)", len(questions), p.concurrency, p.interval)
+
+	jobs := make(chan models.Question)
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	var wg sync.WaitGroup
+	for i := 0; i < p.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for question := range jobs {
+				// Shared ticker keeps the whole pool under the rate limit
+				select {
+				case <-ticker.C:
+				case <-ctx.Done():
+					return
+				}
+				p.generateOne(ctx, question)
+			}
+		}()
+	}
+
+feed:
+	for _, question := range questions {
+		select {
+		case jobs <- question:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	// One rebuild for the whole batch instead of one per generated pattern
+	p.mu.Lock()
+	rebuild := p.patternChanged
+	p.mu.Unlock()
+	if rebuild {
+		if err := database.RebuildQuestionPrerequisites(); err != nil {
+			log.Printf("⚠️ Failed to rebuild prerequisites after pre-generation: %v", err)
+		}
+	}
+
+	p.finish(ctx.Err())
+	return p.Progress(), ctx.Err()
+}
+
+// generateOne generates and caches the breakdown for a single question
+func (p *SolutionPregenerator) generateOne(ctx context.Context, question models.Question) {
+	// A user may have hit the slow path for this question since the run started
+	current, err := database.GetQuestionByID(question.ID)
+	if err == nil && current.SolutionBreakdown != nil {
+		p.record(question, "skipped", nil)
+		return
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	solution, _, err := p.llmService.GenerateSolution(callCtx, question.Title, question.DescriptionMarkdown)
+	if err != nil {
+		p.record(question, "failed", err)
+		return
+	}
+
+	patternChanged, err := database.CacheQuestionSolution(question.ID, solution)
+	if err != nil {
+		p.record(question, "failed", fmt.Errorf("failed to cache solution: %w", err))
+		return
+	}
+	if patternChanged {
+		p.mu.Lock()
+		p.patternChanged = true
+		p.mu.Unlock()
+	}
+
+	p.record(question, "generated", nil)
+}
+
+// record updates counters and logs a progress line for one question
+func (p *SolutionPregenerator) record(question models.Question, outcome string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.progress.Processed++
+	switch outcome {
+	case "generated":
+		p.progress.Generated++
+	case "skipped":
+		p.progress.Skipped++
+	case "failed":
+		p.progress.Failed++
+		p.progress.LastError = fmt.Sprintf("%d. %s: %v", question.LeetcodeID, question.Title, err)
+	}
+
+	if err != nil {
+		log.Printf("❌ [%d/%d] %d. %s: %v", p.progress.Processed, p.progress.Total, question.LeetcodeID, question.Title, err)
+	} else {
+		log.Printf("✅ [%d/%d] %d. %s: %s", p.progress.Processed, p.progress.Total, question.LeetcodeID, question.Title, outcome)
+	}
+}
+
+// finish marks the run as complete
+func (p *SolutionPregenerator) finish(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	p.progress.Running = false
+	p.progress.FinishedAt = &now
+	if err != nil {
+		p.progress.LastError = err.Error()
+	}
+}
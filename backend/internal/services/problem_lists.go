@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"leetcode-anki/backend/internal/database"
+	"leetcode-anki/backend/internal/models"
+	"log"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+)
+
+// builtinLists are the curated lists shipped with the app
+//
+//go:embed lists/*.yaml
+var builtinLists embed.FS
+
+var listSlugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// ProblemListFile is the on-disk format of a curated list
+// Sections keep the grouping (e.g. "Sliding Window") that used to live in code comments
+type ProblemListFile struct {
+	Slug        string               `json:"slug"`
+	Name        string               `json:"name"`
+	Description string               `json:"description"`
+	URL         string               `json:"url"`
+	Sections    []ProblemListSection `json:"sections"`
+}
+
+// ProblemListSection is a named, ordered group of LeetCode IDs
+type ProblemListSection struct {
+	Name     string `json:"name"`
+	Problems []int  `json:"problems"`
+}
+
+// BuiltinProblemLists parses every embedded list, sorted by slug
+func BuiltinProblemLists() ([]*ProblemListFile, error) {
+	entries, err := builtinLists.ReadDir("lists")
+	if err != nil {
+		return nil, err
+	}
+
+	var lists []*ProblemListFile
+	for _, entry := range entries {
+		name := path.Join("lists", entry.Name())
+		data, err := builtinLists.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+
+		list, err := parseProblemList(name, data)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, list)
+	}
+
+	sort.Slice(lists, func(i, j int) bool { return lists[i].Slug < lists[j].Slug })
+	return lists, nil
+}
+
+// BuiltinProblemList returns one embedded list by slug
+func BuiltinProblemList(slug string) (*ProblemListFile, error) {
+	lists, err := BuiltinProblemLists()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, list := range lists {
+		if list.Slug == slug {
+			return list, nil
+		}
+	}
+	return nil, fmt.Errorf("no built-in list named %s", slug)
+}
+
+// LoadProblemListFile reads a custom list from a JSON or YAML file
+func LoadProblemListFile(filePath string) (*ProblemListFile, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filePath, err)
+	}
+	return parseProblemList(filePath, data)
+}
+
+// parseProblemList decodes and validates a list file
+func parseProblemList(name string, data []byte) (*ProblemListFile, error) {
+	data, err := dataToJSON(name, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var list ProblemListFile
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+
+	if !listSlugPattern.MatchString(list.Slug) {
+		return nil, fmt.Errorf("%s: slug must be lowercase words separated by dashes, got %q", name, list.Slug)
+	}
+	if list.Name == "" {
+		return nil, fmt.Errorf("%s: name is required", name)
+	}
+
+	seen := make(map[int]bool)
+	for _, section := range list.Sections {
+		for _, id := range section.Problems {
+			if id <= 0 {
+				return nil, fmt.Errorf("%s: invalid problem ID %d in section %q", name, id, section.Name)
+			}
+			if seen[id] {
+				return nil, fmt.Errorf("%s: problem %d is listed more than once", name, id)
+			}
+			seen[id] = true
+		}
+	}
+	if len(seen) == 0 {
+		return nil, fmt.Errorf("%s: list has no problems", name)
+	}
+
+	return &list, nil
+}
+
+// ProblemIDs returns every LeetCode ID in list order
+func (f *ProblemListFile) ProblemIDs() []int {
+	var ids []int
+	for _, section := range f.Sections {
+		ids = append(ids, section.Problems...)
+	}
+	return ids
+}
+
+// Items flattens the sections into positioned list items
+func (f *ProblemListFile) Items() []models.ProblemListItem {
+	var items []models.ProblemListItem
+	for _, section := range f.Sections {
+		for _, id := range section.Problems {
+			items = append(items, models.ProblemListItem{
+				Position:   len(items) + 1,
+				Section:    section.Name,
+				LeetcodeID: id,
+			})
+		}
+	}
+	return items
+}
+
+// ImportProblemList saves a list and its items; source is "builtin" or "custom"
+func ImportProblemList(file *ProblemListFile, source string) (*models.ProblemList, error) {
+	list := &models.ProblemList{
+		Slug:        file.Slug,
+		Name:        file.Name,
+		Description: file.Description,
+		URL:         file.URL,
+		Source:      source,
+	}
+
+	if err := database.UpsertProblemList(list, file.Items()); err != nil {
+		return nil, fmt.Errorf("failed to save list %s: %w", file.Slug, err)
+	}
+	return list, nil
+}
+
+// ImportBuiltinProblemLists saves every embedded list
+func ImportBuiltinProblemLists() ([]*models.ProblemList, error) {
+	files, err := BuiltinProblemLists()
+	if err != nil {
+		return nil, err
+	}
+
+	lists := make([]*models.ProblemList, 0, len(files))
+	for _, file := range files {
+		list, err := ImportProblemList(file, "builtin")
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, list)
+	}
+	return lists, nil
+}
+
+// FetchMissingListProblems imports list problems that aren't in the question pool yet
+func FetchMissingListProblems(ctx context.Context, source ProblemSource, listID string) (ImportResult, error) {
+	ids, err := database.GetMissingListProblemIDs(listID)
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	// The missing set itself is the progress marker here, so no checkpoint job is needed
+	fetcher := NewProblemBatchFetcher(source)
+	result, err := fetcher.FetchAndImport(ctx, "", ids, nil)
+
+	if len(ids) > 0 {
+		log.Printf("📋 Fetched missing list problems from %s: %d inserted, %d failed", source.Name(), result.Inserted, result.Failed)
+	}
+	return result.ImportResult, err
+}
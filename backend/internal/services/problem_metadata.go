@@ -0,0 +1,128 @@
+package services
+
+import (
+	"leetcode-anki/backend/internal/models"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	exampleHeading    = regexp.MustCompile(`(?i)^example\s*\d*\s*:?$`)
+	constraintHeading = regexp.MustCompile(`(?i)^constraints\s*:?$`)
+	exampleField      = regexp.MustCompile(`(?i)^(input|output|explanation)\s*:\s*(.*)$`)
+)
+
+// cleanMarkdownLine strips the emphasis and inline-code markers that LeetCode's HTML turns into
+func cleanMarkdownLine(line string) string {
+	line = strings.TrimSpace(line)
+	line = strings.ReplaceAll(line, "**", "")
+	line = strings.ReplaceAll(line, "`", "")
+	line = strings.TrimPrefix(line, "> ")
+	return strings.TrimSpace(line)
+}
+
+// ParseExamples extracts "Example N" blocks (Input/Output/Explanation) from a Markdown description
+func ParseExamples(markdown string) []models.QuestionExample {
+	var examples []models.QuestionExample
+	var current *models.QuestionExample
+	field := ""
+
+	flush := func() {
+		if current != nil && (current.Input != "" || current.Output != "") {
+			current.Explanation = strings.TrimSpace(current.Explanation)
+			examples = append(examples, *current)
+		}
+		current = nil
+		field = ""
+	}
+
+	for _, raw := range strings.Split(markdown, "\n") {
+		line := cleanMarkdownLine(raw)
+
+		switch {
+		case exampleHeading.MatchString(line):
+			flush()
+			current = &models.QuestionExample{}
+			continue
+		case constraintHeading.MatchString(line), strings.HasPrefix(strings.ToLower(line), "follow-up"), strings.HasPrefix(strings.ToLower(line), "follow up"):
+			flush()
+			continue
+		}
+
+		if current == nil || line == "```" || strings.HasPrefix(line, "![") {
+			continue
+		}
+
+		if match := exampleField.FindStringSubmatch(line); match != nil {
+			field = strings.ToLower(match[1])
+			value := strings.TrimSpace(match[2])
+			switch field {
+			case "input":
+				current.Input = value
+			case "output":
+				current.Output = value
+			case "explanation":
+				current.Explanation = value
+			}
+			continue
+		}
+
+		// Explanations often wrap onto following lines
+		if field == "explanation" && line != "" {
+			current.Explanation += "\n" + line
+		}
+	}
+	flush()
+
+	return examples
+}
+
+// ParseConstraints extracts the bullet list following the "Constraints:" heading
+func ParseConstraints(markdown string) []string {
+	var constraints []string
+	inConstraints := false
+
+	for _, raw := range strings.Split(markdown, "\n") {
+		line := cleanMarkdownLine(raw)
+
+		if constraintHeading.MatchString(line) {
+			inConstraints = true
+			continue
+		}
+		if !inConstraints || line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* ") {
+			constraints = append(constraints, strings.TrimSpace(line[2:]))
+			continue
+		}
+
+		// First non-bullet line (usually "Follow-up") ends the list
+		break
+	}
+
+	return constraints
+}
+
+// statsFromLeetCode converts likes and the acceptance stats into stored numbers
+func statsFromLeetCode(problem *LeetCodeProblem) *models.LeetCodeStats {
+	if problem.Stats == nil && problem.Likes == 0 && problem.Dislikes == 0 {
+		return nil
+	}
+
+	stats := &models.LeetCodeStats{
+		Likes:    problem.Likes,
+		Dislikes: problem.Dislikes,
+	}
+	if problem.Stats != nil {
+		stats.TotalAccepted = problem.Stats.TotalAcceptedRaw
+		stats.TotalSubmissions = problem.Stats.TotalSubmissionRaw
+		stats.AcceptanceRate, _ = strconv.ParseFloat(strings.TrimSuffix(problem.Stats.ACRate, "%"), 64)
+		if stats.AcceptanceRate == 0 && stats.TotalSubmissions > 0 {
+			stats.AcceptanceRate = float64(stats.TotalAccepted) / float64(stats.TotalSubmissions) * 100
+		}
+	}
+	return stats
+}
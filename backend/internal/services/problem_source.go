@@ -0,0 +1,231 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"leetcode-anki/backend/config"
+	"leetcode-anki/backend/internal/models"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// ProblemSource provides LeetCode-shaped problems for seeding and refilling the question pool
+// Implemented by the GraphQL client (LeetCodeService) and by FixtureSource for offline use
+type ProblemSource interface {
+	Name() string
+	FetchProblemDetail(ctx context.Context, titleSlug string) (*LeetCodeProblem, error)
+	FetchProblemByID(ctx context.Context, questionID int) (*LeetCodeProblem, error)
+	FetchRandomProblems(ctx context.Context, easyCount, mediumCount, hardCount int) ([]*LeetCodeProblem, error)
+}
+
+// NewProblemSource builds the source selected by PROBLEM_SOURCE
+func NewProblemSource() (ProblemSource, error) {
+	switch config.AppConfig.ProblemSource {
+	case "", "leetcode":
+		return NewLeetCodeService(), nil
+	case "fixtures":
+		return NewFixtureSource(config.AppConfig.ProblemFixturesDir)
+	default:
+		return nil, fmt.Errorf("unknown PROBLEM_SOURCE: %s", config.AppConfig.ProblemSource)
+	}
+}
+
+// FixtureSource serves problems from a directory of JSON/YAML files
+// Each file holds one problem or a list, in the same shape as LeetCode's GraphQL `question` object
+type FixtureSource struct {
+	dir    string
+	bySlug map[string]*LeetCodeProblem
+	byID   map[int]*LeetCodeProblem
+	slugs  []string // Sorted load order, for deterministic iteration
+}
+
+// NewFixtureSource loads every *.json, *.yaml and *.yml file in dir
+func NewFixtureSource(dir string) (*FixtureSource, error) {
+	source := &FixtureSource{
+		dir:    dir,
+		bySlug: make(map[string]*LeetCodeProblem),
+		byID:   make(map[int]*LeetCodeProblem),
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixtures dir %s: %w", dir, err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !isDataFile(entry.Name()) {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		var problems []*LeetCodeProblem
+		if err := readDataFile(path, &problems); err != nil {
+			return nil, err
+		}
+
+		for _, problem := range problems {
+			if problem.TitleSlug == "" {
+				return nil, fmt.Errorf("fixture in %s is missing titleSlug", path)
+			}
+			id, err := strconv.Atoi(problem.QuestionID)
+			if err != nil {
+				return nil, fmt.Errorf("fixture %s in %s has invalid questionId %q", problem.TitleSlug, path, problem.QuestionID)
+			}
+			source.bySlug[problem.TitleSlug] = problem
+			source.byID[id] = problem
+			source.slugs = append(source.slugs, problem.TitleSlug)
+		}
+	}
+
+	return source, nil
+}
+
+func (f *FixtureSource) Name() string {
+	return "fixtures"
+}
+
+// Problems returns every loaded fixture in file order
+func (f *FixtureSource) Problems() []*LeetCodeProblem {
+	problems := make([]*LeetCodeProblem, 0, len(f.slugs))
+	for _, slug := range f.slugs {
+		problems = append(problems, f.bySlug[slug])
+	}
+	return problems
+}
+
+func (f *FixtureSource) FetchProblemDetail(ctx context.Context, titleSlug string) (*LeetCodeProblem, error) {
+	problem, ok := f.bySlug[titleSlug]
+	if !ok {
+		return nil, fmt.Errorf("problem not found in fixtures: %s", titleSlug)
+	}
+	return problem, nil
+}
+
+func (f *FixtureSource) FetchProblemByID(ctx context.Context, questionID int) (*LeetCodeProblem, error) {
+	problem, ok := f.byID[questionID]
+	if !ok {
+		return nil, fmt.Errorf("problem ID %d not found in fixtures", questionID)
+	}
+	return problem, nil
+}
+
+// FetchRandomProblems picks distinct fixtures by difficulty, returning fewer if the fixtures run out
+func (f *FixtureSource) FetchRandomProblems(ctx context.Context, easyCount, mediumCount, hardCount int) ([]*LeetCodeProblem, error) {
+	wanted := map[string]int{
+		"Easy":   easyCount,
+		"Medium": mediumCount,
+		"Hard":   hardCount,
+	}
+
+	problems := []*LeetCodeProblem{}
+	for _, i := range rand.Perm(len(f.slugs)) {
+		problem := f.bySlug[f.slugs[i]]
+		if wanted[problem.Difficulty] > 0 {
+			wanted[problem.Difficulty]--
+			problems = append(problems, problem)
+		}
+	}
+
+	return problems, nil
+}
+
+// QuestionFromLeetCode converts a fetched problem into the questions row shape
+func QuestionFromLeetCode(problem *LeetCodeProblem) (*models.Question, error) {
+	leetcodeID, err := strconv.Atoi(problem.QuestionID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid question ID: %s", problem.QuestionID)
+	}
+
+	topics := make([]string, len(problem.TopicTags))
+	for i, tag := range problem.TopicTags {
+		topics[i] = tag.Name
+	}
+
+	description := StripHTMLTags(problem.Content)
+
+	hints := make([]string, len(problem.Hints))
+	for i, hint := range problem.Hints {
+		hints[i] = StripHTMLTags(hint)
+	}
+
+	snippets := make([]models.CodeSnippet, len(problem.CodeSnippets))
+	for i, snippet := range problem.CodeSnippets {
+		snippets[i] = models.CodeSnippet{Lang: snippet.Lang, LangSlug: snippet.LangSlug, Code: snippet.Code}
+	}
+
+	similar := make([]models.SimilarQuestion, len(problem.SimilarQuestions))
+	for i, s := range problem.SimilarQuestions {
+		similar[i] = models.SimilarQuestion{Title: s.Title, Slug: s.TitleSlug, Difficulty: s.Difficulty}
+	}
+
+	exampleTestcases := problem.ExampleTestcases
+	if exampleTestcases == "" {
+		exampleTestcases = problem.SampleTestCase
+	}
+
+	return &models.Question{
+		LeetcodeID:          leetcodeID,
+		Title:               problem.Title,
+		Slug:                problem.TitleSlug,
+		Difficulty:          problem.Difficulty,
+		DescriptionMarkdown: description,
+		Topics:              topics,
+		Hints:               hints,
+		Examples:            ParseExamples(description),
+		Constraints:         ParseConstraints(description),
+		ExampleTestcases:    exampleTestcases,
+		CodeSnippets:        snippets,
+		SimilarQuestions:    similar,
+		Stats:               statsFromLeetCode(problem),
+	}, nil
+}
+
+// isDataFile reports whether a file is JSON or YAML by extension
+func isDataFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json", ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+// readDataFile decodes a JSON or YAML file holding either one object or a list into out (a slice pointer)
+func readDataFile(path string, out interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	data, err = dataToJSON(path, data)
+	if err != nil {
+		return err
+	}
+
+	trimmed := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(trimmed, "[") {
+		trimmed = "[" + trimmed + "]"
+	}
+
+	if err := json.Unmarshal([]byte(trimmed), out); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// dataToJSON converts YAML (by file extension) to JSON so both formats share the json struct tags
+func dataToJSON(name string, data []byte) ([]byte, error) {
+	if ext := strings.ToLower(filepath.Ext(name)); ext == ".yaml" || ext == ".yml" {
+		converted, err := yaml.YAMLToJSON(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse YAML %s: %w", name, err)
+		}
+		return converted, nil
+	}
+	return data, nil
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const fixturesDir = "../../fixtures/problems"
+
+func TestFixtureSourceLoadsJSONAndYAML(t *testing.T) {
+	source, err := NewFixtureSource(fixturesDir)
+	if err != nil {
+		t.Fatalf("NewFixtureSource: %v", err)
+	}
+
+	if got := len(source.Problems()); got != 5 {
+		t.Fatalf("Problems() = %d fixtures, want 5", got)
+	}
+
+	tests := []struct {
+		slug       string
+		id         int
+		difficulty string
+	}{
+		{"two-sum", 1, "Easy"},
+		{"longest-substring-without-repeating-characters", 3, "Medium"},
+		{"valid-parentheses", 20, "Easy"},
+		{"min-stack", 155, "Medium"},
+		{"trapping-rain-water", 42, "Hard"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.slug, func(t *testing.T) {
+			bySlug, err := source.FetchProblemDetail(context.Background(), tt.slug)
+			if err != nil {
+				t.Fatalf("FetchProblemDetail: %v", err)
+			}
+			if bySlug.Difficulty != tt.difficulty {
+				t.Errorf("difficulty = %q, want %q", bySlug.Difficulty, tt.difficulty)
+			}
+
+			byID, err := source.FetchProblemByID(context.Background(), tt.id)
+			if err != nil {
+				t.Fatalf("FetchProblemByID: %v", err)
+			}
+			if byID != bySlug {
+				t.Errorf("FetchProblemByID(%d) returned %q, want %q", tt.id, byID.TitleSlug, tt.slug)
+			}
+		})
+	}
+
+	if _, err := source.FetchProblemDetail(context.Background(), "no-such-problem"); err == nil {
+		t.Error("FetchProblemDetail of an unknown slug succeeded")
+	}
+	if _, err := source.FetchProblemByID(context.Background(), 999999); err == nil {
+		t.Error("FetchProblemByID of an unknown ID succeeded")
+	}
+}
+
+func TestFixtureSourceFetchRandomProblems(t *testing.T) {
+	source, err := NewFixtureSource(fixturesDir)
+	if err != nil {
+		t.Fatalf("NewFixtureSource: %v", err)
+	}
+
+	tests := []struct {
+		name                string
+		easy, medium, hard  int
+		wantE, wantM, wantH int
+	}{
+		{"none", 0, 0, 0, 0, 0, 0},
+		{"one of each", 1, 1, 1, 1, 1, 1},
+		{"runs out", 5, 5, 5, 2, 2, 1},
+		{"easy only", 2, 0, 0, 2, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems, err := source.FetchRandomProblems(context.Background(), tt.easy, tt.medium, tt.hard)
+			if err != nil {
+				t.Fatalf("FetchRandomProblems: %v", err)
+			}
+
+			counts := map[string]int{}
+			seen := map[string]bool{}
+			for _, p := range problems {
+				if seen[p.TitleSlug] {
+					t.Errorf("%s returned twice", p.TitleSlug)
+				}
+				seen[p.TitleSlug] = true
+				counts[p.Difficulty]++
+			}
+			if counts["Easy"] != tt.wantE || counts["Medium"] != tt.wantM || counts["Hard"] != tt.wantH {
+				t.Errorf("got %v, want Easy=%d Medium=%d Hard=%d", counts, tt.wantE, tt.wantM, tt.wantH)
+			}
+		})
+	}
+}
+
+func TestNewFixtureSourceRejectsBadFixtures(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr string
+	}{
+		{"missing slug", "a.json", `{"questionId": "1", "title": "Two Sum"}`, "missing titleSlug"},
+		{"bad id", "a.yaml", "titleSlug: two-sum\nquestionId: abc\n", "invalid questionId"},
+		{"bad json", "a.json", `{"questionId": `, "failed to parse"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, tt.file), []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := NewFixtureSource(dir)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("ignores other files", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("# not a fixture"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		source, err := NewFixtureSource(dir)
+		if err != nil {
+			t.Fatalf("NewFixtureSource: %v", err)
+		}
+		if got := len(source.Problems()); got != 0 {
+			t.Errorf("Problems() = %d, want 0", got)
+		}
+	})
+}
+
+func TestQuestionFromLeetCode(t *testing.T) {
+	source, err := NewFixtureSource(fixturesDir)
+	if err != nil {
+		t.Fatalf("NewFixtureSource: %v", err)
+	}
+
+	problem, err := source.FetchProblemDetail(context.Background(), "two-sum")
+	if err != nil {
+		t.Fatalf("FetchProblemDetail: %v", err)
+	}
+
+	q, err := QuestionFromLeetCode(problem)
+	if err != nil {
+		t.Fatalf("QuestionFromLeetCode: %v", err)
+	}
+	if q.LeetcodeID != 1 || q.Slug != "two-sum" || q.Title != "Two Sum" || q.Difficulty != "Easy" {
+		t.Errorf("got %d %q %q %q", q.LeetcodeID, q.Slug, q.Title, q.Difficulty)
+	}
+	if strings.Join(q.Topics, ",") != "Array,Hash Table" {
+		t.Errorf("topics = %v", q.Topics)
+	}
+	if strings.Contains(q.DescriptionMarkdown, "<p>") {
+		t.Errorf("description still has HTML: %q", q.DescriptionMarkdown)
+	}
+	if len(q.Hints) != len(problem.Hints) {
+		t.Errorf("hints = %d, want %d", len(q.Hints), len(problem.Hints))
+	}
+	if q.ExampleTestcases != problem.ExampleTestcases {
+		t.Errorf("example testcases = %q, want %q", q.ExampleTestcases, problem.ExampleTestcases)
+	}
+
+	t.Run("falls back to sampleTestCase", func(t *testing.T) {
+		p := *problem
+		p.ExampleTestcases = ""
+		q, err := QuestionFromLeetCode(&p)
+		if err != nil {
+			t.Fatalf("QuestionFromLeetCode: %v", err)
+		}
+		if q.ExampleTestcases != problem.SampleTestCase {
+			t.Errorf("example testcases = %q, want %q", q.ExampleTestcases, problem.SampleTestCase)
+		}
+	})
+
+	t.Run("invalid ID", func(t *testing.T) {
+		p := *problem
+		p.QuestionID = "abc"
+		if _, err := QuestionFromLeetCode(&p); err == nil {
+			t.Error("QuestionFromLeetCode accepted a non-numeric ID")
+		}
+	})
+}
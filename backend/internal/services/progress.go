@@ -0,0 +1,80 @@
+package services
+
+import "leetcode-anki/backend/internal/models"
+
+// subScoreDimensions maps sub-score JSON names to their values
+var subScoreDimensions = map[string]func(*models.SubScores) int{
+	"pattern_recognition":      func(s *models.SubScores) int { return s.PatternRecognition },
+	"algorithmic_correctness":  func(s *models.SubScores) int { return s.AlgorithmicCorrectness },
+	"complexity_understanding": func(s *models.SubScores) int { return s.ComplexityUnderstanding },
+	"edge_case_awareness":      func(s *models.SubScores) int { return s.EdgeCaseAwareness },
+}
+
+// BuildAnswerProgress computes per-dimension trends from a question's attempts
+// attempts must be ordered oldest first
+func BuildAnswerProgress(questionID, questionTitle string, attempts []models.History) *models.AnswerProgress {
+	progress := &models.AnswerProgress{
+		QuestionID:      questionID,
+		QuestionTitle:   questionTitle,
+		Attempts:        len(attempts),
+		Timeline:        make([]models.AttemptPoint, 0, len(attempts)),
+		DimensionTrends: make(map[string]models.DimensionTrend),
+	}
+
+	scores := make([]int, 0, len(attempts))
+	dimensionScores := make(map[string][]int)
+
+	for _, attempt := range attempts {
+		progress.Timeline = append(progress.Timeline, models.AttemptPoint{
+			HistoryID:   attempt.ID,
+			SubmittedAt: attempt.SubmittedAt,
+			Score:       attempt.Score,
+			SubScores:   attempt.SubScores,
+		})
+		scores = append(scores, attempt.Score)
+
+		// Older history rows may not have sub-scores
+		if attempt.SubScores == nil {
+			continue
+		}
+		for name, value := range subScoreDimensions {
+			dimensionScores[name] = append(dimensionScores[name], value(attempt.SubScores))
+		}
+	}
+
+	progress.ScoreTrend = buildTrend(scores)
+	for name, values := range dimensionScores {
+		progress.DimensionTrends[name] = buildTrend(values)
+	}
+
+	return progress
+}
+
+// buildTrend compares the first and latest values of a series
+func buildTrend(values []int) models.DimensionTrend {
+	if len(values) == 0 {
+		return models.DimensionTrend{Trend: "steady"}
+	}
+
+	trend := models.DimensionTrend{
+		First:  values[0],
+		Latest: values[len(values)-1],
+	}
+	for _, v := range values {
+		if v > trend.Best {
+			trend.Best = v
+		}
+	}
+	trend.Delta = trend.Latest - trend.First
+
+	switch {
+	case trend.Delta > 0:
+		trend.Trend = "improving"
+	case trend.Delta < 0:
+		trend.Trend = "declining"
+	default:
+		trend.Trend = "steady"
+	}
+
+	return trend
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"leetcode-anki/backend/internal/models"
+	"testing"
+	"time"
+)
+
+func TestBuildAnswerProgress(t *testing.T) {
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	attempt := func(i, score int, sub *models.SubScores) models.History {
+		return models.History{
+			ID:          string(rune('a' + i)),
+			Score:       score,
+			SubScores:   sub,
+			SubmittedAt: start.AddDate(0, 0, i),
+		}
+	}
+
+	attempts := []models.History{
+		attempt(0, 2, &models.SubScores{PatternRecognition: 1, AlgorithmicCorrectness: 3, ComplexityUnderstanding: 2, EdgeCaseAwareness: 4}),
+		attempt(1, 3, nil), // Graded before sub-scores existed
+		attempt(2, 5, &models.SubScores{PatternRecognition: 4, AlgorithmicCorrectness: 3, ComplexityUnderstanding: 5, EdgeCaseAwareness: 2}),
+		attempt(3, 4, &models.SubScores{PatternRecognition: 5, AlgorithmicCorrectness: 3, ComplexityUnderstanding: 4, EdgeCaseAwareness: 1}),
+	}
+
+	progress := BuildAnswerProgress("q1", "Two Sum", attempts)
+
+	if progress.QuestionID != "q1" || progress.QuestionTitle != "Two Sum" || progress.Attempts != 4 {
+		t.Errorf("header = %q %q %d", progress.QuestionID, progress.QuestionTitle, progress.Attempts)
+	}
+	if len(progress.Timeline) != 4 || progress.Timeline[1].HistoryID != "b" || progress.Timeline[1].SubScores != nil {
+		t.Errorf("timeline = %+v, want every attempt in order", progress.Timeline)
+	}
+
+	tests := []struct {
+		name string
+		got  models.DimensionTrend
+		want models.DimensionTrend
+	}{
+		{"score", progress.ScoreTrend, models.DimensionTrend{First: 2, Latest: 4, Best: 5, Delta: 2, Trend: "improving"}},
+		{"pattern_recognition", progress.DimensionTrends["pattern_recognition"], models.DimensionTrend{First: 1, Latest: 5, Best: 5, Delta: 4, Trend: "improving"}},
+		{"algorithmic_correctness", progress.DimensionTrends["algorithmic_correctness"], models.DimensionTrend{First: 3, Latest: 3, Best: 3, Delta: 0, Trend: "steady"}},
+		{"complexity_understanding", progress.DimensionTrends["complexity_understanding"], models.DimensionTrend{First: 2, Latest: 4, Best: 5, Delta: 2, Trend: "improving"}},
+		{"edge_case_awareness", progress.DimensionTrends["edge_case_awareness"], models.DimensionTrend{First: 4, Latest: 1, Best: 4, Delta: -3, Trend: "declining"}},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s trend = %+v, want %+v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestBuildAnswerProgressWithoutSubScores(t *testing.T) {
+	progress := BuildAnswerProgress("q1", "Two Sum", []models.History{{ID: "a", Score: 3}})
+
+	if len(progress.DimensionTrends) != 0 {
+		t.Errorf("dimension trends = %v, want none", progress.DimensionTrends)
+	}
+	want := models.DimensionTrend{First: 3, Latest: 3, Best: 3, Trend: "steady"}
+	if progress.ScoreTrend != want {
+		t.Errorf("score trend = %+v, want %+v", progress.ScoreTrend, want)
+	}
+
+	empty := BuildAnswerProgress("q1", "Two Sum", nil)
+	if empty.Attempts != 0 || empty.ScoreTrend.Trend != "steady" || empty.Timeline == nil {
+		t.Errorf("empty progress = %+v", empty)
+	}
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"fmt"
+	"leetcode-anki/backend/internal/models"
+)
+
+// NewCardPositions are where new cards go relative to reviews (learning cards always come first)
+var NewCardPositions = map[string]string{
+	"before": "New cards before reviews",
+	"after":  "New cards after reviews",
+	"mixed":  "New cards spread evenly between reviews",
+}
+
+// ReviewSorts are the orders due review cards can be shown in
+var ReviewSorts = map[string]string{
+	"due":            "Oldest due date first",
+	"overdueness":    "Most overdue relative to their interval first",
+	"retrievability": "Lowest predicted recall first",
+	"random":         "Random",
+	"difficulty":     "Lowest ease (hardest for you) first",
+}
+
+// NewGatherOrders are the orders new cards are introduced in
+var NewGatherOrders = map[string]string{
+	"list":       "Subscribed lists in list order, then random",
+	"random":     "Random, subscribed lists first",
+	"difficulty": "Easy before Medium before Hard, subscribed lists first",
+}
+
+// NewCardTurn decides, with new cards mixed into reviews, whether the next card should be new
+// New cards are spread evenly over the day's cards: answered so far plus what's left. Using the
+// day's totals keeps the spacing steady as both queues drain, so new cards don't bunch up.
+func NewCardTurn(answeredToday, newStudiedToday, newAvailable, reviewsDue int) bool {
+	if newAvailable <= 0 {
+		return false
+	}
+	if reviewsDue <= 0 {
+		return true
+	}
+
+	newTotal := newStudiedToday + newAvailable
+	dayTotal := answeredToday + newAvailable + reviewsDue
+	// Bresenham-style: the (answeredToday+1)th card should bring new cards up to their share
+	return (answeredToday+1)*newTotal/dayTotal > newStudiedToday
+}
+
+// Difficulties are the question difficulties, easiest first
+var Difficulties = []string{"Easy", "Medium", "Hard"}
+
+// MaxNewQuota bounds a per-difficulty new card quota
+const MaxNewQuota = 999
+
+// NormalizeNewQuotas validates per-difficulty new card quotas, accepting difficulty keys in any case
+func NormalizeNewQuotas(quotas map[string]int) (map[string]int, error) {
+	normalized := make(map[string]int, len(quotas))
+	for key, quota := range quotas {
+		difficulty, ok := normalizeDifficulty(key)
+		if !ok {
+			return nil, fmt.Errorf("quotas must be keyed by Easy, Medium or Hard")
+		}
+		if quota < 0 || quota > MaxNewQuota {
+			return nil, fmt.Errorf("quotas must be between 0 and %d", MaxNewQuota)
+		}
+		normalized[difficulty] = quota
+	}
+	return normalized, nil
+}
+
+// NewQuotaBuckets reports today's new cards (introduced, by difficulty) against the user's quotas
+func NewQuotaBuckets(quotas, introduced map[string]int) []models.NewQuotaBucket {
+	buckets := make([]models.NewQuotaBucket, 0, len(Difficulties))
+	for _, difficulty := range Difficulties {
+		bucket := models.NewQuotaBucket{Difficulty: difficulty, Introduced: introduced[difficulty]}
+		if quota, ok := quotas[difficulty]; ok {
+			remaining := max(quota-bucket.Introduced, 0)
+			bucket.Quota = &quota
+			bucket.Remaining = &remaining
+		}
+		buckets = append(buckets, bucket)
+	}
+	return buckets
+}
+
+// ExhaustedDifficulties lists the difficulties with no new cards left in their quota today
+func ExhaustedDifficulties(buckets []models.NewQuotaBucket) []string {
+	var exhausted []string
+	for _, bucket := range buckets {
+		if bucket.Remaining != nil && *bucket.Remaining <= 0 {
+			exhausted = append(exhausted, bucket.Difficulty)
+		}
+	}
+	return exhausted
+}
+
+// ChargeNewQuota counts a newly introduced card of the difficulty against its bucket
+func ChargeNewQuota(buckets []models.NewQuotaBucket, difficulty string) {
+	for i := range buckets {
+		if buckets[i].Difficulty != difficulty {
+			continue
+		}
+		buckets[i].Introduced++
+		if buckets[i].Remaining != nil && *buckets[i].Remaining > 0 {
+			remaining := *buckets[i].Remaining - 1
+			buckets[i].Remaining = &remaining
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"fmt"
+	"leetcode-anki/backend/internal/models"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewCardTurn(t *testing.T) {
+	tests := []struct {
+		name                                        string
+		answered, newStudied, newAvailable, reviews int
+		want                                        bool
+	}{
+		{"no new cards", 3, 0, 0, 10, false},
+		{"no reviews", 0, 0, 3, 0, true},
+		{"one in four, first card", 0, 0, 5, 15, false},
+		{"one in four, fourth card", 3, 0, 5, 12, true},
+		{"one in four, fifth card", 4, 1, 4, 12, false},
+		{"one in four, eighth card", 7, 1, 4, 9, true},
+		{"behind on new cards", 10, 0, 5, 5, true},
+		{"mostly new, first card", 0, 0, 10, 5, false},
+		{"mostly new, second card", 1, 0, 10, 4, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewCardTurn(tt.answered, tt.newStudied, tt.newAvailable, tt.reviews); got != tt.want {
+				t.Errorf("NewCardTurn(%d, %d, %d, %d) = %v, want %v", tt.answered, tt.newStudied, tt.newAvailable, tt.reviews, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewCardTurnSpreadsEvenly(t *testing.T) {
+	tests := []struct {
+		name              string
+		newCards, reviews int
+		wantGap           int // Minimum reviews between two new cards
+	}{
+		{"one in four", 5, 15, 3},
+		{"one in two", 10, 10, 1},
+		{"one in eleven", 3, 30, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newLeft, reviewsLeft, newStudied := tt.newCards, tt.reviews, 0
+			var positions []int
+			for answered := 0; newLeft+reviewsLeft > 0; answered++ {
+				if NewCardTurn(answered, newStudied, newLeft, reviewsLeft) {
+					newLeft--
+					newStudied++
+					positions = append(positions, answered)
+				} else {
+					reviewsLeft--
+				}
+			}
+
+			if len(positions) != tt.newCards {
+				t.Fatalf("introduced %d new cards, want %d", len(positions), tt.newCards)
+			}
+			for i := 1; i < len(positions); i++ {
+				if gap := positions[i] - positions[i-1] - 1; gap < tt.wantGap {
+					t.Fatalf("new cards at %v, only %d reviews between two of them", positions, gap)
+				}
+			}
+		})
+	}
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestNewQuotaBuckets(t *testing.T) {
+	tests := []struct {
+		name       string
+		quotas     map[string]int
+		introduced map[string]int
+		want       []models.NewQuotaBucket
+		exhausted  []string
+	}{
+		{
+			name: "no quotas",
+			want: []models.NewQuotaBucket{
+				{Difficulty: "Easy"},
+				{Difficulty: "Medium"},
+				{Difficulty: "Hard"},
+			},
+		},
+		{
+			name:       "partly used",
+			quotas:     map[string]int{"Easy": 3, "Hard": 1},
+			introduced: map[string]int{"Easy": 1, "Medium": 4},
+			want: []models.NewQuotaBucket{
+				{Difficulty: "Easy", Quota: intPtr(3), Introduced: 1, Remaining: intPtr(2)},
+				{Difficulty: "Medium", Introduced: 4},
+				{Difficulty: "Hard", Quota: intPtr(1), Remaining: intPtr(1)},
+			},
+		},
+		{
+			name:       "over quota after lowering it",
+			quotas:     map[string]int{"Easy": 0, "Medium": 2},
+			introduced: map[string]int{"Medium": 5},
+			want: []models.NewQuotaBucket{
+				{Difficulty: "Easy", Quota: intPtr(0), Remaining: intPtr(0)},
+				{Difficulty: "Medium", Quota: intPtr(2), Introduced: 5, Remaining: intPtr(0)},
+				{Difficulty: "Hard"},
+			},
+			exhausted: []string{"Easy", "Medium"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewQuotaBuckets(tt.quotas, tt.introduced)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewQuotaBuckets = %s, want %s", formatBuckets(got), formatBuckets(tt.want))
+			}
+			if exhausted := ExhaustedDifficulties(got); !reflect.DeepEqual(exhausted, tt.exhausted) {
+				t.Errorf("ExhaustedDifficulties = %v, want %v", exhausted, tt.exhausted)
+			}
+		})
+	}
+}
+
+func TestChargeNewQuota(t *testing.T) {
+	buckets := NewQuotaBuckets(map[string]int{"Easy": 1}, nil)
+
+	ChargeNewQuota(buckets, "Easy")
+	ChargeNewQuota(buckets, "Easy")
+	ChargeNewQuota(buckets, "Hard")
+
+	if buckets[0].Introduced != 2 || *buckets[0].Remaining != 0 {
+		t.Errorf("Easy = %s, want 2 introduced and none remaining", formatBuckets(buckets[:1]))
+	}
+	if buckets[2].Introduced != 1 || buckets[2].Remaining != nil {
+		t.Errorf("Hard = %s, want 1 introduced and no quota", formatBuckets(buckets[2:]))
+	}
+}
+
+func TestNormalizeNewQuotas(t *testing.T) {
+	tests := []struct {
+		name    string
+		quotas  map[string]int
+		want    map[string]int
+		wantErr bool
+	}{
+		{"empty", map[string]int{}, map[string]int{}, false},
+		{"any case", map[string]int{"easy": 3, "MEDIUM": 2, "Hard": 0}, map[string]int{"Easy": 3, "Medium": 2, "Hard": 0}, false},
+		{"unknown difficulty", map[string]int{"Expert": 1}, nil, true},
+		{"negative", map[string]int{"Easy": -1}, nil, true},
+		{"too large", map[string]int{"Easy": MaxNewQuota + 1}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeNewQuotas(tt.quotas)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NormalizeNewQuotas = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func formatBuckets(buckets []models.NewQuotaBucket) string {
+	parts := make([]string, len(buckets))
+	for i, b := range buckets {
+		quota, remaining := "-", "-"
+		if b.Quota != nil {
+			quota = fmt.Sprint(*b.Quota)
+		}
+		if b.Remaining != nil {
+			remaining = fmt.Sprint(*b.Remaining)
+		}
+		parts[i] = fmt.Sprintf("%s(quota %s, introduced %d, remaining %s)", b.Difficulty, quota, b.Introduced, remaining)
+	}
+	return strings.Join(parts, " ")
+}
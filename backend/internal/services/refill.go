@@ -0,0 +1,247 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"leetcode-anki/backend/internal/database"
+	"leetcode-anki/backend/internal/models"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// Requests arriving within this window of each other share a run
+	refillCoalesceDelay = 2 * time.Second
+	refillRunTimeout    = 5 * time.Minute
+	refillTopicCount    = 5
+)
+
+// refillTargetMix is the unused-question mix a refill tops each user back up to
+var refillTargetMix = map[string]int{"Easy": 10, "Medium": 20, "Hard": 10}
+
+// targetedProblemSource is implemented by sources that can steer picks toward topics (LeetCode, via the catalog)
+type targetedProblemSource interface {
+	FetchTargetedProblems(ctx context.Context, counts map[string]int, topics []string) ([]*LeetCodeProblem, error)
+}
+
+// RefillStatus is a snapshot of the refill worker
+type RefillStatus struct {
+	Running bool       `json:"running"`
+	Pending int        `json:"pending"` // Users waiting for the next run
+	LastRun *time.Time `json:"last_run,omitempty"`
+}
+
+// PoolRefiller is the single background worker that tops up the shared question pool
+// Requests from many users are coalesced into one run, spaced at least minInterval apart,
+// and each run targets the difficulties and topics where those users are under-covered
+type PoolRefiller struct {
+	source      ProblemSource
+	threshold   int
+	batchSize   int
+	minInterval time.Duration
+	wake        chan struct{}
+
+	mu      sync.Mutex
+	pending map[string]bool
+	running bool
+	lastRun time.Time
+}
+
+func NewPoolRefiller(source ProblemSource, threshold, batchSize int, minInterval time.Duration) *PoolRefiller {
+	r := &PoolRefiller{
+		source:      source,
+		threshold:   threshold,
+		batchSize:   batchSize,
+		minInterval: minInterval,
+		wake:        make(chan struct{}, 1),
+		pending:     make(map[string]bool),
+	}
+	go r.loop()
+	return r
+}
+
+// Threshold is the unused-question count at or below which a user should request a refill
+func (r *PoolRefiller) Threshold() int {
+	return r.threshold
+}
+
+// Request queues a user for the next refill run; it never blocks
+func (r *PoolRefiller) Request(userID string) {
+	r.mu.Lock()
+	r.pending[userID] = true
+	r.mu.Unlock()
+
+	select {
+	case r.wake <- struct{}{}:
+	default:
+		// A run is already scheduled and will pick this user up
+	}
+}
+
+// Status returns a snapshot of the worker
+func (r *PoolRefiller) Status() RefillStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	status := RefillStatus{Running: r.running, Pending: len(r.pending)}
+	if !r.lastRun.IsZero() {
+		lastRun := r.lastRun
+		status.LastRun = &lastRun
+	}
+	return status
+}
+
+func (r *PoolRefiller) loop() {
+	for range r.wake {
+		time.Sleep(refillCoalesceDelay)
+
+		r.mu.Lock()
+		wait := r.minInterval - time.Since(r.lastRun)
+		r.mu.Unlock()
+		if wait > 0 {
+			time.Sleep(wait)
+		}
+
+		r.mu.Lock()
+		users := make([]string, 0, len(r.pending))
+		for userID := range r.pending {
+			users = append(users, userID)
+		}
+		r.pending = make(map[string]bool)
+		r.running = true
+		r.mu.Unlock()
+
+		if len(users) > 0 {
+			run := r.run(users)
+			if err := database.SaveRefillRun(run); err != nil {
+				log.Printf("⚠️ Failed to record refill run: %v", err)
+			}
+		}
+
+		r.mu.Lock()
+		r.running = false
+		r.lastRun = time.Now()
+		r.mu.Unlock()
+	}
+}
+
+// run performs one refill for the given users and returns its record
+func (r *PoolRefiller) run(userIDs []string) *models.RefillRun {
+	run := &models.RefillRun{
+		Source:    r.source.Name(),
+		Requested: map[string]int{},
+		Topics:    []string{},
+		Errors:    []string{},
+		StartedAt: time.Now(),
+	}
+	defer func() {
+		now := time.Now()
+		run.FinishedAt = &now
+	}()
+
+	// Users another run already topped up drop out here
+	deficits := map[string]int{}
+	var lowUsers []string
+	for _, userID := range userIDs {
+		counts, err := database.GetUnusedCountsByDifficulty(userID)
+		if err != nil {
+			run.Errors = append(run.Errors, fmt.Sprintf("coverage for %s: %v", userID, err))
+			continue
+		}
+
+		total := 0
+		for _, count := range counts {
+			total += count
+		}
+		if total > r.threshold {
+			continue
+		}
+
+		lowUsers = append(lowUsers, userID)
+		for difficulty, target := range refillTargetMix {
+			if deficit := target - counts[difficulty]; deficit > deficits[difficulty] {
+				deficits[difficulty] = deficit
+			}
+		}
+	}
+	run.Users = len(lowUsers)
+	if run.Users == 0 {
+		return run
+	}
+	run.Requested = scaleToBatch(deficits, r.batchSize)
+
+	if topics, err := database.GetUnderCoveredTopics(lowUsers, refillTopicCount); err != nil {
+		run.Errors = append(run.Errors, fmt.Sprintf("topic coverage: %v", err))
+	} else {
+		run.Topics = topics
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), refillRunTimeout)
+	defer cancel()
+
+	var problems []*LeetCodeProblem
+	var err error
+	if targeted, ok := r.source.(targetedProblemSource); ok {
+		problems, err = targeted.FetchTargetedProblems(ctx, run.Requested, run.Topics)
+	} else {
+		problems, err = r.source.FetchRandomProblems(ctx, run.Requested["Easy"], run.Requested["Medium"], run.Requested["Hard"])
+	}
+	if err != nil {
+		run.Errors = append(run.Errors, fmt.Sprintf("fetch: %v", err))
+	}
+	run.Fetched = len(problems)
+
+	keep := problems[:0]
+	for _, problem := range problems {
+		if !problem.IsFreeAlgorithm() {
+			run.Skipped++
+			continue
+		}
+		keep = append(keep, problem)
+	}
+
+	result := ImportProblems(keep)
+	run.Inserted = result.Inserted
+	run.Updated = result.Updated
+	run.Unchanged = result.Unchanged
+	run.Failed = result.Failed
+	run.Errors = append(run.Errors, result.Errors...)
+
+	log.Printf("🔄 Pool refill for %d users from %s: fetched %d, inserted %d, skipped %d, failed %d",
+		run.Users, run.Source, run.Fetched, run.Inserted, run.Skipped, run.Failed)
+	return run
+}
+
+// scaleToBatch shrinks per-difficulty deficits proportionally so they add up to at most batchSize
+func scaleToBatch(deficits map[string]int, batchSize int) map[string]int {
+	total := 0
+	for _, deficit := range deficits {
+		total += deficit
+	}
+	if total <= batchSize {
+		return deficits
+	}
+
+	// Largest remainder keeps the sum exact
+	type share struct {
+		difficulty string
+		remainder  float64
+	}
+	scaled := map[string]int{}
+	var shares []share
+	assigned := 0
+	for difficulty, deficit := range deficits {
+		exact := float64(deficit) * float64(batchSize) / float64(total)
+		scaled[difficulty] = int(exact)
+		assigned += int(exact)
+		shares = append(shares, share{difficulty, exact - float64(int(exact))})
+	}
+	sort.Slice(shares, func(i, j int) bool { return shares[i].remainder > shares[j].remainder })
+	for i := 0; assigned < batchSize && i < len(shares); i++ {
+		scaled[shares[i].difficulty]++
+		assigned++
+	}
+	return scaled
+}
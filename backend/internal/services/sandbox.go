@@ -0,0 +1,154 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// maxSandboxOutput caps how much program output is kept for display
+const maxSandboxOutput = 4096
+
+// sandboxPidsLimit bounds processes and threads in a sandbox container (the JVM alone needs a few dozen)
+const sandboxPidsLimit = 128
+
+// ErrToolchainUnavailable is returned when the container runtime isn't installed
+var ErrToolchainUnavailable = errors.New("toolchain not available in sandbox")
+
+// sandboxRunner describes how to run a single-file program for one language
+type sandboxRunner struct {
+	filename string
+	image    string
+	args     []string
+}
+
+var sandboxRunners = map[string]sandboxRunner{
+	"python": {filename: "main.py", image: "python:3.12-alpine", args: []string{"python3", "main.py"}},
+	"java":   {filename: "Main.java", image: "eclipse-temurin:21-jdk-alpine", args: []string{"java", "Main.java"}}, // Single-file source launch
+	"go":     {filename: "main.go", image: "golang:1.22-alpine", args: []string{"go", "run", "main.go"}},
+}
+
+// CodeSandbox runs generated programs in a throwaway container: no network, read-only root
+// filesystem, an unprivileged user, and memory, CPU and process limits. The program's directory
+// is mounted read-only; only a small tmpfs is writable.
+type CodeSandbox struct {
+	runtime  string // Docker-compatible CLI
+	timeout  time.Duration
+	memoryMB int
+}
+
+func NewCodeSandbox(runtime string, timeout time.Duration, memoryMB int) *CodeSandbox {
+	return &CodeSandbox{runtime: runtime, timeout: timeout, memoryMB: memoryMB}
+}
+
+// SandboxResult is the outcome of running a program
+type SandboxResult struct {
+	Passed   bool          `json:"passed"` // Exited with status 0 before the timeout
+	Output   string        `json:"output"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Run writes the program to a temp directory and executes it in a container
+func (s *CodeSandbox) Run(ctx context.Context, language, program string) (*SandboxResult, error) {
+	runner, ok := sandboxRunners[language]
+	if !ok {
+		return nil, fmt.Errorf("unsupported sandbox language: %s", language)
+	}
+
+	runtime, err := exec.LookPath(s.runtime)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrToolchainUnavailable, s.runtime)
+	}
+
+	dir, err := os.MkdirTemp("", "leetcode-anki-sandbox-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sandbox dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The container runs as nobody, so the mount must be world-readable
+	if err := os.Chmod(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to prepare sandbox dir: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, runner.filename), []byte(program), 0o644); err != nil {
+		return nil, fmt.Errorf("failed to write program: %w", err)
+	}
+
+	// The temp dir name is unique, so it doubles as the container name for cleanup
+	name := filepath.Base(dir)
+
+	runCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(runCtx, runtime, s.containerArgs(name, dir, runner)...)
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	start := time.Now()
+	runErr := cmd.Run()
+	result := &SandboxResult{
+		Passed:   runErr == nil,
+		Output:   truncateOutput(output.String()),
+		Duration: time.Since(start),
+	}
+
+	if runCtx.Err() != nil {
+		// Killing the CLI client doesn't stop the container
+		killCtx, killCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer killCancel()
+		exec.CommandContext(killCtx, runtime, "rm", "-f", name).Run()
+	}
+	if runCtx.Err() == context.DeadlineExceeded {
+		result.Passed = false
+		result.Output = truncateOutput(fmt.Sprintf("timed out after %s\n%s", s.timeout, output.String()))
+	}
+
+	return result, nil
+}
+
+// containerArgs builds the runtime arguments that run the program in dir under every isolation flag
+func (s *CodeSandbox) containerArgs(name, dir string, runner sandboxRunner) []string {
+	memory := strconv.Itoa(s.memoryMB) + "m"
+	cpuSeconds := strconv.Itoa(int(s.timeout.Seconds()) + 1)
+
+	args := []string{
+		"run", "--rm", "--name", name,
+		"--network", "none",
+		"--read-only",
+		"--tmpfs", "/tmp:rw,exec,size=256m",
+		"--user", "65534:65534",
+		"--cap-drop", "ALL",
+		"--security-opt", "no-new-privileges",
+		"--memory", memory, "--memory-swap", memory,
+		"--cpus", "1",
+		"--pids-limit", strconv.Itoa(sandboxPidsLimit),
+		"--ulimit", "cpu=" + cpuSeconds + ":" + cpuSeconds,
+		"--ulimit", "nofile=256:256",
+		"--ulimit", "fsize=16777216:16777216",
+		// Go and Java write caches and class files; only /tmp is writable
+		"--env", "HOME=/tmp",
+		"--env", "GOCACHE=/tmp/gocache",
+		"--env", "GOPATH=/tmp/gopath",
+		"--env", "GOTOOLCHAIN=local",
+		"--volume", dir + ":/sandbox:ro",
+		"--workdir", "/sandbox",
+		runner.image,
+	}
+	return append(args, runner.args...)
+}
+
+// truncateOutput keeps the tail of the output, which is where failures show up
+func truncateOutput(output string) string {
+	if len(output) <= maxSandboxOutput {
+		return output
+	}
+	return "..." + output[len(output)-maxSandboxOutput:]
+}
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSandboxContainerArgsIsolate(t *testing.T) {
+	sandbox := NewCodeSandbox("docker", 20*time.Second, 256)
+	args := sandbox.containerArgs("leetcode-anki-sandbox-1", "/tmp/leetcode-anki-sandbox-1", sandboxRunners["python"])
+	joined := " " + strings.Join(args, " ") + " "
+
+	for _, want := range []string{
+		" --network none ",
+		" --read-only ",
+		" --user 65534:65534 ",
+		" --cap-drop ALL ",
+		" --security-opt no-new-privileges ",
+		" --memory 256m --memory-swap 256m ",
+		" --pids-limit 128 ",
+		" --ulimit cpu=21:21 ",
+		" --volume /tmp/leetcode-anki-sandbox-1:/sandbox:ro ",
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("container args lack %q: %s", strings.TrimSpace(want), joined)
+		}
+	}
+
+	// The program runs inside the image, never on the host
+	if !strings.HasSuffix(joined, " python:3.12-alpine python3 main.py ") {
+		t.Errorf("container args end with %q, want the image then the command", args[len(args)-3:])
+	}
+}
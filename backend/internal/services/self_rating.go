@@ -0,0 +1,74 @@
+package services
+
+import "leetcode-anki/backend/internal/models"
+
+// SelfRatings are the buttons shown after an answer, in display order, with the score each gives the scheduler
+var SelfRatings = []struct {
+	Name  string
+	Score int
+}{
+	{"again", 1},
+	{"hard", 3},
+	{"good", 4},
+	{"easy", 5},
+}
+
+// SelfRatingScore returns the scheduler score of a button name
+func SelfRatingScore(rating string) (int, bool) {
+	for _, r := range SelfRatings {
+		if r.Name == rating {
+			return r.Score, true
+		}
+	}
+	return 0, false
+}
+
+// SelfRatingForScore returns the button matching a grader score (anything below 3 is "again")
+func SelfRatingForScore(score int) string {
+	name := SelfRatings[0].Name
+	for _, r := range SelfRatings {
+		if score >= r.Score {
+			name = r.Name
+		}
+	}
+	return name
+}
+
+// AnswerCoins is what an answer earns: 1 coin for a non-zero score, plus 10 for graduating the card
+// to review and 10 for it turning mature (interval over 21 days). before and after are the card
+// around the answer.
+func AnswerCoins(before, after *models.Review, score int) int {
+	coins := 0
+	if score > 0 {
+		coins++
+	}
+
+	wasLearning := before.CardState == "learning" || before.CardState == "relearning" || before.CardState == "new"
+	if wasLearning && after.CardState == "review" {
+		coins += 10
+	}
+
+	wasMature := before.CardState == "review" && before.IntervalDays > 21
+	if !wasMature && after.CardState == "review" && after.IntervalDays > 21 {
+		coins += 10
+	}
+	return coins
+}
+
+// PreviewRatings schedules a copy of the card with each button's score, leaving review untouched
+func (s *SM2Algorithm) PreviewRatings(review *models.Review) []models.RatingOption {
+	options := make([]models.RatingOption, 0, len(SelfRatings))
+	for _, r := range SelfRatings {
+		preview := *review
+		s.CalculateNextReview(&preview, r.Score)
+		options = append(options, models.RatingOption{
+			Rating:          r.Name,
+			Score:           r.Score,
+			CardState:       preview.CardState,
+			NextReviewAt:    preview.NextReviewAt,
+			IntervalMinutes: preview.IntervalMinutes,
+			IntervalDays:    preview.IntervalDays,
+		})
+	}
+	return options
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"leetcode-anki/backend/internal/models"
+	"testing"
+)
+
+func TestSelfRatingForScore(t *testing.T) {
+	tests := []struct {
+		score int
+		want  string
+	}{
+		{-1, "again"},
+		{0, "again"},
+		{1, "again"},
+		{2, "again"},
+		{3, "hard"},
+		{4, "good"},
+		{5, "easy"},
+		{6, "easy"},
+	}
+	for _, tt := range tests {
+		if got := SelfRatingForScore(tt.score); got != tt.want {
+			t.Errorf("SelfRatingForScore(%d) = %q, want %q", tt.score, got, tt.want)
+		}
+	}
+}
+
+func TestSelfRatingScoreRoundTrips(t *testing.T) {
+	for _, r := range SelfRatings {
+		score, ok := SelfRatingScore(r.Name)
+		if !ok || score != r.Score {
+			t.Errorf("SelfRatingScore(%q) = %d, %v, want %d", r.Name, score, ok, r.Score)
+		}
+		if got := SelfRatingForScore(score); got != r.Name {
+			t.Errorf("SelfRatingForScore(%d) = %q, want %q", score, got, r.Name)
+		}
+	}
+
+	if _, ok := SelfRatingScore("perfect"); ok {
+		t.Error("SelfRatingScore accepted an unknown rating")
+	}
+}
+
+func TestAnswerCoins(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after models.Review
+		score         int
+		want          int
+	}{
+		{"zero score", models.Review{CardState: "learning"}, models.Review{CardState: "learning"}, 0, 0},
+		{"still learning", models.Review{CardState: "learning"}, models.Review{CardState: "learning"}, 3, 1},
+		{"graduates", models.Review{CardState: "learning"}, models.Review{CardState: "review", IntervalDays: 1}, 4, 11},
+		{"new card graduates", models.Review{CardState: "new"}, models.Review{CardState: "review", IntervalDays: 4}, 5, 11},
+		{"relearned", models.Review{CardState: "relearning"}, models.Review{CardState: "review", IntervalDays: 1}, 4, 11},
+		{"review stays young", models.Review{CardState: "review", IntervalDays: 6}, models.Review{CardState: "review", IntervalDays: 15}, 4, 1},
+		{"turns mature", models.Review{CardState: "review", IntervalDays: 15}, models.Review{CardState: "review", IntervalDays: 38}, 4, 11},
+		{"already mature", models.Review{CardState: "review", IntervalDays: 38}, models.Review{CardState: "review", IntervalDays: 95}, 4, 1},
+		{"lapses", models.Review{CardState: "review", IntervalDays: 38}, models.Review{CardState: "relearning"}, 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AnswerCoins(&tt.before, &tt.after, tt.score); got != tt.want {
+				t.Errorf("AnswerCoins = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
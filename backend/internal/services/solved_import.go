@@ -0,0 +1,409 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"leetcode-anki/backend/internal/database"
+	"leetcode-anki/backend/internal/models"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SolvedProblem is one problem a user has an accepted LeetCode submission for
+type SolvedProblem struct {
+	Slug          string     `json:"slug"`
+	Title         string     `json:"title,omitempty"`
+	AcceptedCount int        `json:"accepted_count"`
+	LastSolvedAt  *time.Time `json:"last_solved_at,omitempty"` // Nil when the export has no dates
+}
+
+// SolvedImportItem is the plan for one solved problem
+type SolvedImportItem struct {
+	SolvedProblem
+	Status       string     `json:"status"` // "create", "exists" (already has a card) or "unmatched" (not in the pool)
+	QuestionID   string     `json:"question_id,omitempty"`
+	IntervalDays int        `json:"interval_days,omitempty"`
+	NextReviewAt *time.Time `json:"next_review_at,omitempty"`
+
+	review *models.Review
+}
+
+// SolvedImportPlan previews (or, once applied, reports) importing a user's solves
+type SolvedImportPlan struct {
+	DryRun    bool               `json:"dry_run"`
+	Solved    int                `json:"solved"`    // Distinct accepted problems in the export
+	Create    int                `json:"create"`    // Cards to create
+	Existing  int                `json:"existing"`  // Already had a card, left alone
+	Unmatched int                `json:"unmatched"` // Not in the question pool
+	Created   int                `json:"created"`   // Cards actually created (after apply)
+	Items     []SolvedImportItem `json:"items"`
+}
+
+// ParseSolvedExport reads a LeetCode export and returns the distinct accepted problems
+// Supported: the submissions API dump ({"submissions_dump": [...]} or a bare array of submissions),
+// a saved recentAcSubmissionList GraphQL response, the /api/problems/all/ response (stat_status_pairs),
+// and CSV with a slug or title column plus optional status and timestamp/date columns
+func ParseSolvedExport(name string, data []byte) ([]SolvedProblem, error) {
+	data = bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%s is empty", name)
+	}
+
+	var submissions []exportSubmission
+	var err error
+	if strings.EqualFold(filepath.Ext(name), ".csv") || (data[0] != '{' && data[0] != '[') {
+		submissions, err = parseSubmissionCSV(data)
+	} else {
+		submissions, err = parseSubmissionJSON(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return aggregateSolved(submissions), nil
+}
+
+// exportSubmission is one submission, normalized across export formats
+type exportSubmission struct {
+	Slug     string
+	Title    string
+	Accepted bool
+	At       *time.Time
+}
+
+// jsonSubmission covers the field spellings used by LeetCode's REST and GraphQL responses
+type jsonSubmission struct {
+	TitleSlug     string          `json:"title_slug"`
+	TitleSlugAlt  string          `json:"titleSlug"`
+	Slug          string          `json:"slug"`
+	Title         string          `json:"title"`
+	StatusDisplay string          `json:"status_display"`
+	StatusAlt     string          `json:"statusDisplay"`
+	Status        json.RawMessage `json:"status"`
+	Timestamp     json.RawMessage `json:"timestamp"`
+}
+
+func parseSubmissionJSON(data []byte) ([]exportSubmission, error) {
+	var list []jsonSubmission
+	if data[0] == '[' {
+		if err := json.Unmarshal(data, &list); err != nil {
+			return nil, err
+		}
+		return convertJSONSubmissions(list), nil
+	}
+
+	var doc struct {
+		SubmissionsDump []jsonSubmission `json:"submissions_dump"`
+		Data            struct {
+			RecentAcSubmissionList []jsonSubmission `json:"recentAcSubmissionList"`
+		} `json:"data"`
+		StatStatusPairs []struct {
+			Stat struct {
+				Slug  string `json:"question__title_slug"`
+				Title string `json:"question__title"`
+			} `json:"stat"`
+			Status *string `json:"status"`
+		} `json:"stat_status_pairs"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case len(doc.SubmissionsDump) > 0:
+		return convertJSONSubmissions(doc.SubmissionsDump), nil
+	case len(doc.Data.RecentAcSubmissionList) > 0:
+		// Only accepted submissions are in this list, and it has no status field
+		return convertJSONSubmissions(doc.Data.RecentAcSubmissionList), nil
+	case len(doc.StatStatusPairs) > 0:
+		var submissions []exportSubmission
+		for _, pair := range doc.StatStatusPairs {
+			submissions = append(submissions, exportSubmission{
+				Slug:     pair.Stat.Slug,
+				Title:    pair.Stat.Title,
+				Accepted: pair.Status != nil && *pair.Status == "ac",
+			})
+		}
+		return submissions, nil
+	}
+	return nil, fmt.Errorf("no submissions found (expected submissions_dump, recentAcSubmissionList or stat_status_pairs)")
+}
+
+func convertJSONSubmissions(list []jsonSubmission) []exportSubmission {
+	submissions := make([]exportSubmission, 0, len(list))
+	for _, s := range list {
+		slug := firstNonEmpty(s.TitleSlug, s.TitleSlugAlt, s.Slug)
+		if slug == "" && s.Title != "" {
+			slug = slugify(s.Title)
+		}
+
+		status := firstNonEmpty(s.StatusDisplay, s.StatusAlt, strings.Trim(string(s.Status), `"`))
+		submissions = append(submissions, exportSubmission{
+			Slug:     slug,
+			Title:    s.Title,
+			Accepted: isAcceptedStatus(status),
+			At:       parseSolvedTime(strings.Trim(string(s.Timestamp), `"`)),
+		})
+	}
+	return submissions
+}
+
+func parseSubmissionCSV(data []byte) ([]exportSubmission, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		key := strings.NewReplacer(" ", "", "_", "", "-", "").Replace(strings.ToLower(strings.TrimSpace(name)))
+		columns[key] = i
+	}
+	column := func(names ...string) int {
+		for _, name := range names {
+			if i, ok := columns[name]; ok {
+				return i
+			}
+		}
+		return -1
+	}
+
+	slugCol := column("titleslug", "slug", "questionslug")
+	titleCol := column("title", "question", "questiontitle", "problem")
+	statusCol := column("status", "statusdisplay", "result")
+	timeCol := column("timestamp", "date", "submittedat", "time", "solvedat")
+	if slugCol < 0 && titleCol < 0 {
+		return nil, fmt.Errorf("CSV needs a slug or title column")
+	}
+
+	field := func(record []string, i int) string {
+		if i < 0 || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var submissions []exportSubmission
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		slug := field(record, slugCol)
+		title := field(record, titleCol)
+		if slug == "" {
+			slug = slugify(title)
+		}
+
+		// A CSV without a status column is taken to be a list of solved problems
+		accepted := true
+		if statusCol >= 0 {
+			accepted = isAcceptedStatus(field(record, statusCol))
+		}
+
+		submissions = append(submissions, exportSubmission{
+			Slug:     slug,
+			Title:    title,
+			Accepted: accepted,
+			At:       parseSolvedTime(field(record, timeCol)),
+		})
+	}
+	return submissions, nil
+}
+
+// aggregateSolved collapses accepted submissions into one entry per problem
+func aggregateSolved(submissions []exportSubmission) []SolvedProblem {
+	bySlug := map[string]*SolvedProblem{}
+	for _, s := range submissions {
+		if !s.Accepted || s.Slug == "" {
+			continue
+		}
+
+		solved, ok := bySlug[s.Slug]
+		if !ok {
+			solved = &SolvedProblem{Slug: s.Slug, Title: s.Title}
+			bySlug[s.Slug] = solved
+		}
+		solved.AcceptedCount++
+		if s.At != nil && (solved.LastSolvedAt == nil || s.At.After(*solved.LastSolvedAt)) {
+			solved.LastSolvedAt = s.At
+		}
+	}
+
+	problems := make([]SolvedProblem, 0, len(bySlug))
+	for _, solved := range bySlug {
+		problems = append(problems, *solved)
+	}
+	sort.Slice(problems, func(i, j int) bool { return problems[i].Slug < problems[j].Slug })
+	return problems
+}
+
+func isAcceptedStatus(status string) bool {
+	switch strings.ToLower(strings.TrimSpace(status)) {
+	case "", "accepted", "ac", "10":
+		// Empty: formats that only list accepted submissions
+		return true
+	}
+	return false
+}
+
+// millisecondEpochThreshold separates epoch milliseconds from seconds (1e12 seconds is year 33658)
+const millisecondEpochThreshold = 1e12
+
+// parseSolvedTime accepts unix seconds or milliseconds, or a few common date layouts
+func parseSolvedTime(value string) *time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "null" {
+		return nil
+	}
+
+	if epoch, err := strconv.ParseInt(value, 10, 64); err == nil {
+		t := time.Unix(epoch, 0)
+		if epoch > millisecondEpochThreshold {
+			t = time.UnixMilli(epoch)
+		}
+		return &t
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02", "01/02/2006"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
+
+func slugify(title string) string {
+	slug := slugInvalidChars.ReplaceAllString(strings.ToLower(title), "-")
+	return strings.Trim(slug, "-")
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// PlanSolvedImport matches solved problems to the question pool and works out each new card's schedule
+func PlanSolvedImport(srs *SM2Algorithm, userID string, solved []SolvedProblem) (*SolvedImportPlan, error) {
+	plan := &SolvedImportPlan{DryRun: true, Solved: len(solved), Items: []SolvedImportItem{}}
+
+	slugs := make([]string, len(solved))
+	for i, s := range solved {
+		slugs[i] = s.Slug
+	}
+	questions, err := database.GetQuestionsBySlugs(slugs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to match questions: %w", err)
+	}
+
+	questionIDs := make([]string, 0, len(questions))
+	for _, q := range questions {
+		questionIDs = append(questionIDs, q.ID)
+	}
+	existing, err := database.GetUserCardQuestionIDs(userID, questionIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load existing cards: %w", err)
+	}
+
+	now := time.Now()
+	for _, s := range solved {
+		item := SolvedImportItem{SolvedProblem: s}
+
+		question, ok := questions[s.Slug]
+		switch {
+		case !ok:
+			item.Status = "unmatched"
+			plan.Unmatched++
+		case existing[question.ID]:
+			item.Status = "exists"
+			item.QuestionID = question.ID
+			plan.Existing++
+		default:
+			item.Status = "create"
+			item.QuestionID = question.ID
+			if item.Title == "" {
+				item.Title = question.Title
+			}
+
+			review := srs.InitializeSolvedCard(userID, question.ID, s.AcceptedCount, s.LastSolvedAt, spreadFraction(s.Slug), now)
+			// Date the card from the solve, so the import doesn't use up today's new-card limit
+			review.CreatedAt = now.Add(-24 * time.Hour)
+			if s.LastSolvedAt != nil && s.LastSolvedAt.Before(review.CreatedAt) {
+				review.CreatedAt = *s.LastSolvedAt
+			}
+
+			item.review = review
+			item.IntervalDays = review.IntervalDays
+			item.NextReviewAt = &review.NextReviewAt
+			plan.Create++
+		}
+		plan.Items = append(plan.Items, item)
+	}
+
+	return plan, nil
+}
+
+// Apply creates the planned cards
+func (p *SolvedImportPlan) Apply() error {
+	var reviews []*models.Review
+	for _, item := range p.Items {
+		if item.review != nil {
+			reviews = append(reviews, item.review)
+		}
+	}
+
+	created, err := database.CreateImportedReviews(reviews)
+	if err != nil {
+		return fmt.Errorf("failed to create cards: %w", err)
+	}
+
+	p.DryRun = false
+	p.Created = created
+	return nil
+}
+
+// FetchUnmatchedSolved imports unmatched solved problems from the source by slug, so a re-plan can match them
+func FetchUnmatchedSolved(ctx context.Context, source ProblemSource, plan *SolvedImportPlan) ImportResult {
+	var result ImportResult
+	for _, item := range plan.Items {
+		if item.Status != "unmatched" {
+			continue
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		problem, err := source.FetchProblemDetail(ctx, item.Slug)
+		if err != nil {
+			result.fail(item.Slug, err)
+			continue
+		}
+		result.merge(ImportProblems([]*LeetCodeProblem{problem}))
+	}
+	return result
+}
+
+// spreadFraction maps a slug to a stable value in (0, 1], so dry runs and real imports agree
+func spreadFraction(slug string) float64 {
+	h := fnv.New32a()
+	h.Write([]byte(slug))
+	return float64(h.Sum32()%1000+1) / 1000
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSolvedTime(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Time // Zero means nil
+	}{
+		{"", time.Time{}},
+		{"  ", time.Time{}},
+		{"null", time.Time{}},
+		{"yesterday", time.Time{}},
+		{"1700000000", time.Unix(1700000000, 0)},
+		{"1700000000123", time.UnixMilli(1700000000123)},
+		{" 1700000000 ", time.Unix(1700000000, 0)},
+		{"2024-03-05T10:20:30Z", time.Date(2024, 3, 5, 10, 20, 30, 0, time.UTC)},
+		{"2024-03-05T10:20:30+07:00", time.Date(2024, 3, 5, 3, 20, 30, 0, time.UTC)},
+		{"2024-03-05 10:20:30", time.Date(2024, 3, 5, 10, 20, 30, 0, time.UTC)},
+		{"2024-03-05T10:20:30", time.Date(2024, 3, 5, 10, 20, 30, 0, time.UTC)},
+		{"2024-03-05", time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)},
+		{"03/05/2024", time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got := parseSolvedTime(tt.value)
+			switch {
+			case tt.want.IsZero() && got != nil:
+				t.Errorf("parseSolvedTime(%q) = %v, want nil", tt.value, *got)
+			case !tt.want.IsZero() && got == nil:
+				t.Errorf("parseSolvedTime(%q) = nil, want %v", tt.value, tt.want)
+			case got != nil && !got.Equal(tt.want):
+				t.Errorf("parseSolvedTime(%q) = %v, want %v", tt.value, *got, tt.want)
+			}
+		})
+	}
+}
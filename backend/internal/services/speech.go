@@ -0,0 +1,80 @@
+package services
+
+import (
+	"leetcode-anki/backend/internal/models"
+	"math"
+	"strings"
+)
+
+// pauseThresholdSeconds is the silence length counted as a pause
+const pauseThresholdSeconds = 2.0
+
+// fillerWords are single-word hesitations counted against conciseness
+var fillerWords = map[string]bool{
+	"um": true, "umm": true, "uh": true, "uhh": true, "uhm": true, "erm": true,
+	"er": true, "ah": true, "hmm": true, "mm": true, "basically": true, "literally": true,
+}
+
+// fillerPhrases are multi-word hesitations
+var fillerPhrases = []string{"you know", "i mean", "kind of", "sort of"}
+
+// ComputeSpeechMetrics measures pacing, pauses and filler words from a raw transcript
+func ComputeSpeechMetrics(transcript *Transcript, audioDurationSeconds float64) *models.SpeechMetrics {
+	metrics := &models.SpeechMetrics{
+		DurationSeconds: transcript.DurationSeconds,
+		FillerWords:     make(map[string]int),
+	}
+	// Normalized audio length is exact; prefer it over the recognizer's estimate
+	if audioDurationSeconds > 0 {
+		metrics.DurationSeconds = audioDurationSeconds
+	}
+
+	lower := strings.ToLower(transcript.Text)
+	words := strings.FieldsFunc(lower, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '\'' || r == '(' || r == ')')
+	})
+	metrics.WordCount = len(words)
+
+	for _, word := range words {
+		if fillerWords[word] {
+			metrics.FillerWords[word]++
+			metrics.FillerWordCount++
+		}
+	}
+	joined := " " + strings.Join(words, " ") + " "
+	for _, phrase := range fillerPhrases {
+		if count := strings.Count(joined, " "+phrase+" "); count > 0 {
+			metrics.FillerWords[phrase] += count
+			metrics.FillerWordCount += count
+		}
+	}
+
+	// Pauses: silence before the first segment, between segments, and after the last
+	previousEnd := 0.0
+	for _, segment := range transcript.Segments {
+		recordPause(metrics, segment.Start-previousEnd)
+		previousEnd = segment.End
+	}
+	if len(transcript.Segments) > 0 && metrics.DurationSeconds > previousEnd {
+		recordPause(metrics, metrics.DurationSeconds-previousEnd)
+	}
+
+	metrics.SpeakingSeconds = math.Max(metrics.DurationSeconds-metrics.TotalPauseSeconds, 0)
+	if metrics.SpeakingSeconds > 0 {
+		metrics.WordsPerMinute = math.Round(float64(metrics.WordCount)/(metrics.SpeakingSeconds/60)*10) / 10
+	}
+
+	return metrics
+}
+
+// recordPause counts a silence if it's long enough to be noticeable
+func recordPause(metrics *models.SpeechMetrics, gap float64) {
+	if gap < pauseThresholdSeconds {
+		return
+	}
+	metrics.PauseCount++
+	metrics.TotalPauseSeconds += gap
+	if gap > metrics.LongestPauseSeconds {
+		metrics.LongestPauseSeconds = gap
+	}
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"leetcode-anki/backend/internal/models"
+	"reflect"
+	"testing"
+)
+
+func TestComputeSpeechMetrics(t *testing.T) {
+	tests := []struct {
+		name          string
+		transcript    Transcript
+		audioDuration float64
+		want          models.SpeechMetrics
+	}{
+		{
+			name: "pauses and fillers",
+			transcript: Transcript{
+				Text:            "Um, so I think we use a hash map, you know, and uh basically it's O(n).",
+				DurationSeconds: 24,
+				Segments: []TranscriptSegment{
+					{Start: 0.5, End: 4},   // Short lead-in isn't a pause
+					{Start: 7, End: 10},    // 3s pause
+					{Start: 10.5, End: 20}, // 0.5s gap isn't
+				},
+			},
+			audioDuration: 25, // Trailing 5s pause, measured on the audio rather than the recognizer's 24s
+			want: models.SpeechMetrics{
+				DurationSeconds:     25,
+				SpeakingSeconds:     17,
+				WordCount:           16,
+				WordsPerMinute:      56.5,
+				PauseCount:          2,
+				LongestPauseSeconds: 5,
+				TotalPauseSeconds:   8,
+				FillerWordCount:     4,
+				FillerWords:         map[string]int{"um": 1, "uh": 1, "basically": 1, "you know": 1},
+			},
+		},
+		{
+			name: "recognizer duration without segments",
+			transcript: Transcript{
+				Text:            "I mean the umbrella sort of covers it, sort of.",
+				DurationSeconds: 30,
+			},
+			want: models.SpeechMetrics{
+				DurationSeconds: 30,
+				SpeakingSeconds: 30,
+				WordCount:       10,
+				WordsPerMinute:  20,
+				FillerWordCount: 3,
+				FillerWords:     map[string]int{"i mean": 1, "sort of": 2},
+			},
+		},
+		{
+			name:       "silence",
+			transcript: Transcript{},
+			want:       models.SpeechMetrics{FillerWords: map[string]int{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ComputeSpeechMetrics(&tt.transcript, tt.audioDuration)
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("ComputeSpeechMetrics =\n  %+v\nwant\n  %+v", *got, tt.want)
+			}
+		})
+	}
+}
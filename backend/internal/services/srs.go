@@ -1,6 +1,7 @@
 package services
 
 import (
+	"errors"
 	"leetcode-anki/backend/internal/models"
 	"math"
 	"time"
@@ -21,6 +22,12 @@ var LearningSteps = []int{30}
 // CalculateNextReview updates the review card based on the score
 // Implements Anki-like spaced repetition with sub-day intervals for learning cards
 func (s *SM2Algorithm) CalculateNextReview(review *models.Review, score int) {
+	s.CalculateNextReviewAt(review, score, time.Now())
+}
+
+// CalculateNextReviewAt is CalculateNextReview for an answer given at now
+// Re-rating an earlier answer schedules from when it was given, not from when it's rated
+func (s *SM2Algorithm) CalculateNextReviewAt(review *models.Review, score int, now time.Time) {
 	// Clamp score to 0-5
 	if score < 0 {
 		score = 0
@@ -31,7 +38,6 @@ func (s *SM2Algorithm) CalculateNextReview(review *models.Review, score int) {
 
 	review.Quality = &score
 	review.TotalReviews++
-	now := time.Now()
 
 	// Handle based on current card state
 	switch review.CardState {
@@ -149,7 +155,29 @@ func (s *SM2Algorithm) handleReviewCard(review *models.Review, score int, now ti
 		}
 
 		// Calculate new interval in days
-		newIntervalDays := int(math.Round(float64(review.IntervalDays) * multiplier))
+		baseDays := float64(review.IntervalDays)
+		minDays := 1
+
+		// Early review (e.g. reviewing ahead): grow from the time actually elapsed rather than the
+		// full interval, but don't let a passed card come back sooner than it already would have
+		if review.LastReviewedAt != nil && now.Before(review.NextReviewAt) {
+			if elapsed := now.Sub(*review.LastReviewedAt).Hours() / 24; elapsed < baseDays {
+				baseDays = elapsed
+				if score == 3 {
+					minDays = int(math.Round(float64(review.IntervalDays) * multiplier / 2))
+				} else {
+					minDays = review.IntervalDays
+				}
+				if score == 5 {
+					multiplier = review.EasinessFactor * 1.15 // Half the easy bonus
+				}
+			}
+		}
+
+		newIntervalDays := int(math.Round(baseDays * multiplier))
+		if newIntervalDays < minDays {
+			newIntervalDays = minDays
+		}
 		if newIntervalDays < 1 {
 			newIntervalDays = 1
 		}
@@ -217,3 +245,109 @@ func (s *SM2Algorithm) InitializeNewCard(userID, questionID string) *models.Revi
 		CreatedAt:       now,
 	}
 }
+
+// solvedIntervalDays is the starting interval for an imported solved problem, by number of accepted solves
+var solvedIntervalDays = []int{2, 4, 8, 15, 30}
+
+// InitializeSolvedCard creates a review card for a problem the user already solved on LeetCode
+// More solves start with a longer interval and higher ease; a solve long ago shortens the interval,
+// since it has likely been forgotten. dueFraction (0-1] spreads due dates over the first interval
+// so a large import doesn't all come due on the same day.
+func (s *SM2Algorithm) InitializeSolvedCard(userID, questionID string, solves int, lastSolved *time.Time, dueFraction float64, now time.Time) *models.Review {
+	if solves < 1 {
+		solves = 1
+	}
+	strength := solves
+	if strength > len(solvedIntervalDays) {
+		strength = len(solvedIntervalDays)
+	}
+
+	intervalDays := solvedIntervalDays[strength-1]
+	if lastSolved != nil {
+		switch age := now.Sub(*lastSolved); {
+		case age > 180*24*time.Hour:
+			intervalDays /= 4
+		case age > 60*24*time.Hour:
+			intervalDays /= 2
+		}
+	}
+	if intervalDays < 1 {
+		intervalDays = 1
+	}
+
+	if dueFraction <= 0 || dueFraction > 1 {
+		dueFraction = 1
+	}
+	dueDays := int(math.Ceil(float64(intervalDays) * dueFraction))
+
+	return &models.Review{
+		UserID:          userID,
+		QuestionID:      questionID,
+		CardState:       "review",
+		Quality:         nil,
+		EasinessFactor:  2.5 + 0.05*float64(strength-1),
+		IntervalDays:    intervalDays,
+		IntervalMinutes: intervalDays * 1440,
+		CurrentStep:     0,
+		Repetitions:     strength,
+		NextReviewAt:    now.Add(time.Duration(dueDays) * 24 * time.Hour),
+		LastReviewedAt:  lastSolved,
+		TotalReviews:    0,
+		TotalLapses:     0,
+		CreatedAt:       now,
+	}
+}
+
+// ErrCardSuspended is returned when rescheduling a suspended card; unsuspend it first
+var ErrCardSuspended = errors.New("card is suspended")
+
+// ResetCard puts a card back to "new" with a fresh schedule, keeping its identity and queue position
+// keepLapses preserves the lapse count, so the card's history of being forgotten survives the reset
+func (s *SM2Algorithm) ResetCard(review *models.Review, keepLapses bool) {
+	fresh := s.InitializeNewCard(review.UserID, review.QuestionID)
+	fresh.ID = review.ID
+	fresh.CreatedAt = review.CreatedAt
+	fresh.NewPosition = review.NewPosition
+	if keepLapses {
+		fresh.TotalLapses = review.TotalLapses
+	}
+	*review = *fresh
+}
+
+// SetDueDate makes a card due days from today (0 = now)
+// New, learning and relearning cards become review cards with that many days as their interval;
+// review cards keep theirs unless setInterval is true
+func (s *SM2Algorithm) SetDueDate(review *models.Review, days int, setInterval bool, now time.Time) error {
+	if review.CardState == "suspended" {
+		return ErrCardSuspended
+	}
+	if days < 0 {
+		return errors.New("due date can't be in the past")
+	}
+
+	interval := days
+	if interval < 1 {
+		interval = 1
+	}
+
+	if review.CardState != "review" {
+		review.CardState = "review"
+		review.CurrentStep = 0
+		if review.Repetitions < 1 {
+			review.Repetitions = 1
+		}
+		setInterval = true
+	}
+	if setInterval {
+		review.IntervalDays = interval
+		review.IntervalMinutes = interval * 1440
+	}
+
+	if days == 0 {
+		review.NextReviewAt = now
+	} else {
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		review.NextReviewAt = today.AddDate(0, 0, days)
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"errors"
+	"leetcode-anki/backend/internal/models"
+	"testing"
+	"time"
+)
+
+func TestHandleReviewCardEarlyReview(t *testing.T) {
+	lastReviewed := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	due := lastReviewed.AddDate(0, 0, 10)
+
+	tests := []struct {
+		name         string
+		answeredAt   time.Time
+		score        int
+		wantState    string
+		wantInterval int
+	}{
+		// On time: the full interval grows
+		{"on time good", due, 4, "review", 25},
+		{"on time easy", due, 5, "review", 33},
+		{"on time hard", due, 3, "review", 12},
+		// Four days in: growth is based on the 4 days elapsed, never shorter than the current schedule
+		{"early good keeps the interval", lastReviewed.AddDate(0, 0, 4), 4, "review", 10},
+		{"early easy gets half the bonus", lastReviewed.AddDate(0, 0, 4), 5, "review", 12},
+		{"early hard", lastReviewed.AddDate(0, 0, 4), 3, "review", 6},
+		{"slightly early good grows from elapsed", lastReviewed.AddDate(0, 0, 8), 4, "review", 20},
+		{"early again lapses", lastReviewed.AddDate(0, 0, 4), 1, "relearning", 0},
+	}
+
+	sm2 := NewSM2Algorithm()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			last := lastReviewed
+			review := models.Review{
+				CardState:       "review",
+				EasinessFactor:  2.5,
+				IntervalDays:    10,
+				IntervalMinutes: 10 * 1440,
+				Repetitions:     3,
+				LastReviewedAt:  &last,
+				NextReviewAt:    due,
+			}
+
+			sm2.CalculateNextReviewAt(&review, tt.score, tt.answeredAt)
+
+			if review.CardState != tt.wantState {
+				t.Fatalf("state = %s, want %s", review.CardState, tt.wantState)
+			}
+			if tt.wantState == "relearning" {
+				if review.TotalLapses != 1 || review.IntervalMinutes != LearningSteps[0] {
+					t.Errorf("lapses = %d, interval = %d minutes", review.TotalLapses, review.IntervalMinutes)
+				}
+				return
+			}
+			if review.IntervalDays != tt.wantInterval {
+				t.Errorf("interval = %d days, want %d", review.IntervalDays, tt.wantInterval)
+			}
+			if want := tt.answeredAt.AddDate(0, 0, tt.wantInterval); !review.NextReviewAt.Equal(want) {
+				t.Errorf("due = %v, want %v", review.NextReviewAt, want)
+			}
+		})
+	}
+}
+
+func TestCalculateNextReviewAtSchedulesFromAnswerTime(t *testing.T) {
+	answeredAt := time.Date(2024, 3, 5, 15, 30, 0, 0, time.UTC)
+	sm2 := NewSM2Algorithm()
+
+	review := models.Review{CardState: "new"}
+	sm2.CalculateNextReviewAt(&review, 1, answeredAt)
+
+	if review.LastReviewedAt == nil || !review.LastReviewedAt.Equal(answeredAt) {
+		t.Errorf("last reviewed = %v, want %v", review.LastReviewedAt, answeredAt)
+	}
+	if want := answeredAt.Add(time.Duration(LearningSteps[0]) * time.Minute); !review.NextReviewAt.Equal(want) {
+		t.Errorf("due = %v, want %v", review.NextReviewAt, want)
+	}
+}
+
+func TestSetDueDate(t *testing.T) {
+	now := time.Date(2024, 3, 5, 15, 30, 0, 0, time.UTC)
+	midnight := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		review       models.Review
+		days         int
+		setInterval  bool
+		wantErr      error
+		wantDue      time.Time
+		wantInterval int
+		wantReps     int
+	}{
+		{
+			name:         "new card due now",
+			review:       models.Review{CardState: "new"},
+			days:         0,
+			wantDue:      now,
+			wantInterval: 1,
+			wantReps:     1,
+		},
+		{
+			name:         "learning card in a week",
+			review:       models.Review{CardState: "learning", CurrentStep: 1, IntervalMinutes: 30},
+			days:         7,
+			wantDue:      midnight.AddDate(0, 0, 7),
+			wantInterval: 7,
+			wantReps:     1,
+		},
+		{
+			name:         "relearning card keeps repetitions",
+			review:       models.Review{CardState: "relearning", Repetitions: 4, IntervalDays: 20},
+			days:         3,
+			wantDue:      midnight.AddDate(0, 0, 3),
+			wantInterval: 3,
+			wantReps:     4,
+		},
+		{
+			name:         "review card keeps its interval",
+			review:       models.Review{CardState: "review", Repetitions: 3, IntervalDays: 12, IntervalMinutes: 12 * 1440},
+			days:         2,
+			wantDue:      midnight.AddDate(0, 0, 2),
+			wantInterval: 12,
+			wantReps:     3,
+		},
+		{
+			name:         "review card with setInterval",
+			review:       models.Review{CardState: "review", Repetitions: 3, IntervalDays: 12, IntervalMinutes: 12 * 1440},
+			days:         2,
+			setInterval:  true,
+			wantDue:      midnight.AddDate(0, 0, 2),
+			wantInterval: 2,
+			wantReps:     3,
+		},
+		{
+			name:    "suspended",
+			review:  models.Review{CardState: "suspended"},
+			days:    1,
+			wantErr: ErrCardSuspended,
+		},
+		{
+			name:    "in the past",
+			review:  models.Review{CardState: "review", IntervalDays: 5},
+			days:    -1,
+			wantErr: errors.New("due date can't be in the past"),
+		},
+	}
+
+	sm2 := NewSM2Algorithm()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			review := tt.review
+			err := sm2.SetDueDate(&review, tt.days, tt.setInterval, now)
+
+			if tt.wantErr != nil {
+				if err == nil || err.Error() != tt.wantErr.Error() {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				if review != tt.review {
+					t.Errorf("review changed on error: %+v", review)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SetDueDate: %v", err)
+			}
+
+			if review.CardState != "review" || review.CurrentStep != 0 {
+				t.Errorf("state = %s step %d, want review step 0", review.CardState, review.CurrentStep)
+			}
+			if !review.NextReviewAt.Equal(tt.wantDue) {
+				t.Errorf("due = %v, want %v", review.NextReviewAt, tt.wantDue)
+			}
+			if review.IntervalDays != tt.wantInterval || review.IntervalMinutes != tt.wantInterval*1440 {
+				t.Errorf("interval = %d days / %d minutes, want %d days", review.IntervalDays, review.IntervalMinutes, tt.wantInterval)
+			}
+			if review.Repetitions != tt.wantReps {
+				t.Errorf("repetitions = %d, want %d", review.Repetitions, tt.wantReps)
+			}
+		})
+	}
+}
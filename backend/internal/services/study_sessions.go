@@ -0,0 +1,74 @@
+package services
+
+import (
+	"fmt"
+	"leetcode-anki/backend/internal/models"
+	"strings"
+)
+
+// StudySessionInput is what a user submits to build a filtered study session
+type StudySessionInput struct {
+	Name       string                   `json:"name"`
+	Query      models.StudySessionQuery `json:"query"`
+	Reschedule bool                     `json:"reschedule"` // false = practice only
+
+	// ReviewAheadDays builds an Anki-style "review ahead" session: review cards due within
+	// the next N days, rescheduled as early reviews. Overrides Query.States and DueWithinDays.
+	ReviewAheadDays int `json:"review_ahead_days"`
+}
+
+// ToStudySession validates the input and builds the session for userID
+func (in StudySessionInput) ToStudySession(userID string) (*models.StudySession, error) {
+	query := in.Query
+
+	for i, d := range query.Difficulties {
+		difficulty, ok := normalizeDifficulty(d)
+		if !ok {
+			return nil, fmt.Errorf("difficulties must be Easy, Medium or Hard")
+		}
+		query.Difficulties[i] = difficulty
+	}
+	for _, state := range query.States {
+		switch state {
+		case "new", "learning", "review", "relearning":
+		case "suspended":
+			return nil, fmt.Errorf("suspended cards can't be studied in a session")
+		default:
+			return nil, fmt.Errorf("unknown card state %q", state)
+		}
+	}
+	switch query.Order {
+	case "", "due", "random", "lapses", "score":
+	default:
+		return nil, fmt.Errorf("order must be due, random, lapses or score")
+	}
+	if query.MinLapses < 0 || query.FailedWithinDays < 0 || (query.DueWithinDays != nil && *query.DueWithinDays < 0) {
+		return nil, fmt.Errorf("day and lapse filters can't be negative")
+	}
+
+	reschedule := in.Reschedule
+	if in.ReviewAheadDays < 0 {
+		return nil, fmt.Errorf("review_ahead_days can't be negative")
+	}
+	if in.ReviewAheadDays > 0 {
+		days := in.ReviewAheadDays
+		query.States = []string{"review"}
+		query.DueWithinDays = &days
+		reschedule = true
+	}
+
+	name := strings.TrimSpace(in.Name)
+	if name == "" {
+		name = "Custom study"
+		if in.ReviewAheadDays > 0 {
+			name = fmt.Sprintf("Review ahead (%d days)", in.ReviewAheadDays)
+		}
+	}
+
+	return &models.StudySession{
+		UserID:     userID,
+		Name:       name,
+		Query:      query,
+		Reschedule: reschedule,
+	}, nil
+}
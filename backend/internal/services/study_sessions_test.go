@@ -0,0 +1,50 @@
+package services
+
+import (
+	"leetcode-anki/backend/internal/models"
+	"testing"
+)
+
+func TestStudySessionInputToStudySession(t *testing.T) {
+	negative := -1
+	tests := []struct {
+		name       string
+		input      StudySessionInput
+		wantErr    bool
+		wantName   string
+		wantStates []string
+		wantResch  bool
+	}{
+		{"defaults", StudySessionInput{}, false, "Custom study", nil, false},
+		{"states", StudySessionInput{Query: models.StudySessionQuery{States: []string{"learning", "relearning"}}}, false, "Custom study", []string{"learning", "relearning"}, false},
+		{"suspended", StudySessionInput{Query: models.StudySessionQuery{States: []string{"review", "suspended"}}}, true, "", nil, false},
+		{"unknown state", StudySessionInput{Query: models.StudySessionQuery{States: []string{"buried"}}}, true, "", nil, false},
+		{"bad difficulty", StudySessionInput{Query: models.StudySessionQuery{Difficulties: []string{"Expert"}}}, true, "", nil, false},
+		{"bad order", StudySessionInput{Query: models.StudySessionQuery{Order: "alphabetical"}}, true, "", nil, false},
+		{"negative due window", StudySessionInput{Query: models.StudySessionQuery{DueWithinDays: &negative}}, true, "", nil, false},
+		{"review ahead", StudySessionInput{ReviewAheadDays: 3, Query: models.StudySessionQuery{States: []string{"new"}}}, false, "Review ahead (3 days)", []string{"review"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session, err := tt.input.ToStudySession("user-1")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if session.Name != tt.wantName || session.Reschedule != tt.wantResch {
+				t.Errorf("name = %q, reschedule = %v", session.Name, session.Reschedule)
+			}
+			if len(session.Query.States) != len(tt.wantStates) {
+				t.Fatalf("states = %v, want %v", session.Query.States, tt.wantStates)
+			}
+			for i := range tt.wantStates {
+				if session.Query.States[i] != tt.wantStates[i] {
+					t.Errorf("states = %v, want %v", session.Query.States, tt.wantStates)
+				}
+			}
+		})
+	}
+}
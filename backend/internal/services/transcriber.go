@@ -0,0 +1,215 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"leetcode-anki/backend/config"
+	"log"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// transcriptionPrompt biases speech recognition towards algorithm vocabulary
+// The leading fillers nudge Whisper into keeping "um"/"uh" instead of silently dropping them,
+// which oral grading needs; EnhanceAnswer strips them again for the typed answer.
+const transcriptionPrompt = "Umm, so, uh, this is a technical explanation of an algorithm or data structure problem. The speaker may mention terms like hashmap, binary search, O(n), pseudocode, edge cases, etc."
+
+// Transcriber turns a (normalized) audio file into text
+// language is an ISO-639-1 code, or "" to let the backend auto-detect
+type Transcriber interface {
+	Transcribe(ctx context.Context, audioPath, language string) (*Transcript, error)
+	Name() string
+}
+
+// Transcript is recognized speech with segment timing when the backend provides it
+type Transcript struct {
+	Text            string
+	DurationSeconds float64
+	Segments        []TranscriptSegment
+}
+
+// TranscriptSegment is a span of speech, in seconds from the start of the recording
+type TranscriptSegment struct {
+	Start float64
+	End   float64
+	Text  string
+}
+
+// whisperCppLine matches whisper.cpp's timestamped output: [00:00:01.000 --> 00:00:04.500]  text
+var whisperCppLine = regexp.MustCompile(`^\[(\d+):(\d+):(\d+(?:\.\d+)?) --> (\d+):(\d+):(\d+(?:\.\d+)?)\]\s*(.*)$`)
+
+// NewTranscriber builds the transcriber selected by TRANSCRIBER_BACKEND
+func NewTranscriber() (Transcriber, error) {
+	cfg := config.AppConfig
+
+	switch cfg.TranscriberBackend {
+	case "", "openai":
+		return NewOpenAITranscriber(cfg.OpenAIKey, "", cfg.TranscriberModel), nil
+	case "local-server":
+		if cfg.TranscriberURL == "" {
+			return nil, fmt.Errorf("TRANSCRIBER_URL is required for the local-server transcriber")
+		}
+		return NewOpenAITranscriber(cfg.OpenAIKey, cfg.TranscriberURL, cfg.TranscriberModel), nil
+	case "whisper-cpp":
+		if cfg.WhisperCppModel == "" {
+			return nil, fmt.Errorf("WHISPER_CPP_MODEL is required for the whisper-cpp transcriber")
+		}
+		return NewWhisperCppTranscriber(cfg.WhisperCppBinary, cfg.WhisperCppModel), nil
+	default:
+		return nil, fmt.Errorf("unknown TRANSCRIBER_BACKEND: %s", cfg.TranscriberBackend)
+	}
+}
+
+// OpenAITranscriber talks to OpenAI Whisper or any server exposing the same
+// /v1/audio/transcriptions API (faster-whisper-server, LocalAI, whisper.cpp server, ...)
+type OpenAITranscriber struct {
+	client *openai.Client
+	model  string
+	name   string
+}
+
+func NewOpenAITranscriber(apiKey, baseURL, model string) *OpenAITranscriber {
+	clientConfig := openai.DefaultConfig(apiKey)
+	name := "openai"
+	if baseURL != "" {
+		clientConfig.BaseURL = baseURL
+		name = "local-server"
+	}
+	if model == "" {
+		model = openai.Whisper1
+	}
+
+	return &OpenAITranscriber{
+		client: openai.NewClientWithConfig(clientConfig),
+		model:  model,
+		name:   name,
+	}
+}
+
+func (t *OpenAITranscriber) Name() string {
+	return t.name
+}
+
+// Transcribe sends the audio file to the transcription endpoint
+// verbose_json gives per-segment timestamps for pacing metrics
+func (t *OpenAITranscriber) Transcribe(ctx context.Context, audioPath, language string) (*Transcript, error) {
+	req := openai.AudioRequest{
+		Model:    t.model,
+		FilePath: audioPath,
+		Language: language,
+		Prompt:   transcriptionPrompt,
+		Format:   openai.AudioResponseFormatVerboseJSON,
+		TimestampGranularities: []openai.TranscriptionTimestampGranularity{
+			openai.TranscriptionTimestampGranularitySegment,
+		},
+	}
+
+	resp, err := t.client.CreateTranscription(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("whisper API error: %w", err)
+	}
+
+	transcript := &Transcript{
+		Text:            strings.TrimSpace(resp.Text),
+		DurationSeconds: resp.Duration,
+	}
+	for _, segment := range resp.Segments {
+		transcript.Segments = append(transcript.Segments, TranscriptSegment{
+			Start: segment.Start,
+			End:   segment.End,
+			Text:  strings.TrimSpace(segment.Text),
+		})
+	}
+
+	log.Printf("🎤 Transcribed audio (%s): %s", t.name, transcript.Text)
+	return transcript, nil
+}
+
+// WhisperCppTranscriber runs a local whisper.cpp binary
+// Expects 16kHz mono WAV input, which NormalizeAudio produces
+type WhisperCppTranscriber struct {
+	binary string
+	model  string
+}
+
+func NewWhisperCppTranscriber(binary, model string) *WhisperCppTranscriber {
+	return &WhisperCppTranscriber{binary: binary, model: model}
+}
+
+func (t *WhisperCppTranscriber) Name() string {
+	return "whisper-cpp"
+}
+
+// Transcribe invokes whisper.cpp and parses the timestamped transcript from stdout
+func (t *WhisperCppTranscriber) Transcribe(ctx context.Context, audioPath, language string) (*Transcript, error) {
+	if language == "" {
+		language = "auto"
+	}
+
+	args := []string{
+		"-m", t.model,
+		"-f", audioPath,
+		"-l", language,
+		"--prompt", transcriptionPrompt,
+	}
+
+	cmd := exec.CommandContext(ctx, t.binary, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("whisper.cpp failed: %w: %s", err, truncateOutput(stderr.String()))
+	}
+
+	transcript := parseWhisperCppOutput(stdout.String())
+	log.Printf("🎤 Transcribed audio (whisper-cpp): %s", transcript.Text)
+	return transcript, nil
+}
+
+// parseWhisperCppOutput turns "[start --> end]  text" lines into segments
+// Lines without timestamps are kept as text so nothing is lost if the format changes
+func parseWhisperCppOutput(output string) *Transcript {
+	transcript := &Transcript{}
+	var texts []string
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		match := whisperCppLine.FindStringSubmatch(line)
+		if match == nil {
+			texts = append(texts, line)
+			continue
+		}
+
+		segment := TranscriptSegment{
+			Start: clockToSeconds(match[1], match[2], match[3]),
+			End:   clockToSeconds(match[4], match[5], match[6]),
+			Text:  strings.TrimSpace(match[7]),
+		}
+		transcript.Segments = append(transcript.Segments, segment)
+		texts = append(texts, segment.Text)
+		if segment.End > transcript.DurationSeconds {
+			transcript.DurationSeconds = segment.End
+		}
+	}
+
+	transcript.Text = strings.Join(texts, " ")
+	return transcript
+}
+
+// clockToSeconds converts hh, mm, ss.mmm strings into seconds
+func clockToSeconds(hours, minutes, seconds string) float64 {
+	h, _ := strconv.Atoi(hours)
+	m, _ := strconv.Atoi(minutes)
+	sec, _ := strconv.ParseFloat(seconds, 64)
+	return float64(h*3600+m*60) + sec
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"fmt"
+	"leetcode-anki/backend/internal/database"
+	"leetcode-anki/backend/internal/models"
+	"time"
+)
+
+// VacationInput is a vacation as submitted by the user, dates as YYYY-MM-DD (both days included)
+type VacationInput struct {
+	StartsOn string `json:"starts_on" binding:"required"`
+	EndsOn   string `json:"ends_on" binding:"required"`
+}
+
+// ToVacation validates the input and builds the vacation for userID
+// Whether it starts in the past is checked when saving, against the database's date
+func (in VacationInput) ToVacation(userID string) (*models.Vacation, error) {
+	startsOn, err := time.Parse("2006-01-02", in.StartsOn)
+	if err != nil {
+		return nil, fmt.Errorf("starts_on must be a date like 2024-07-01")
+	}
+	endsOn, err := time.Parse("2006-01-02", in.EndsOn)
+	if err != nil {
+		return nil, fmt.Errorf("ends_on must be a date like 2024-07-14")
+	}
+
+	if endsOn.Before(startsOn) {
+		return nil, fmt.Errorf("ends_on must not be before starts_on")
+	}
+	if days := int(endsOn.Sub(startsOn).Hours()/24) + 1; days > database.MaxVacationDays {
+		return nil, fmt.Errorf("vacation can be at most %d days", database.MaxVacationDays)
+	}
+
+	return &models.Vacation{
+		UserID:   userID,
+		StartsOn: startsOn,
+		EndsOn:   endsOn,
+	}, nil
+}